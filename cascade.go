@@ -0,0 +1,57 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CascadeFiles returns the paths of the files used by the common .env
+// convention in dir for the application environment appEnv (for example
+// "development" or "production"), lowest precedence first:
+//
+//  1. .env
+//  2. .env.<appEnv>
+//  3. .env.local
+//  4. .env.<appEnv>.local
+//
+// Like the Node and Rails implementations, .env.local is not part of the
+// cascade for the "test" environment so test results do not depend on local
+// overrides. When appEnv is empty only .env and .env.local are returned.
+//
+// All candidate paths are returned, whether the files exist or not.
+func CascadeFiles(dir, appEnv string) []string {
+	names := []string{".env"}
+	if appEnv != "" {
+		names = append(names, ".env."+appEnv)
+	}
+	if appEnv != "test" {
+		names = append(names, ".env.local")
+	}
+	if appEnv != "" {
+		names = append(names, ".env."+appEnv+".local")
+	}
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths
+}
+
+// UnmarshalCascade stores the configuration of the .env convention files in
+// dir for the application environment appEnv in the struct pointed to by v.
+// Files that do not exist are skipped.
+//
+// The files returned by CascadeFiles are applied as layers by UnmarshalLayers,
+// so defaults from struct tags have the lowest precedence and variables set in
+// the process environment the highest, matching the behavior of dotenv
+// loaders that never override existing environment variables.
+func UnmarshalCascade(v interface{}, dir, appEnv string) (Provenance, error) {
+	var files []string
+	for _, path := range CascadeFiles(dir, appEnv) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		files = append(files, path)
+	}
+	return UnmarshalLayers(v, files...)
+}
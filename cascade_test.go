@@ -0,0 +1,58 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCascadeFiles(t *testing.T) {
+	cases := []struct {
+		AppEnv string
+		Output []string
+	}{
+		{"", []string{".env", ".env.local"}},
+		{"production", []string{".env", ".env.production", ".env.local", ".env.production.local"}},
+		{"test", []string{".env", ".env.test", ".env.test.local"}},
+	}
+	for _, c := range cases {
+		var want []string
+		for _, name := range c.Output {
+			want = append(want, filepath.Join("config", name))
+		}
+		got := CascadeFiles("config", c.AppEnv)
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("[%s] files did not match, want %v, got %v", c.AppEnv, want, got)
+		}
+	}
+}
+
+func TestUnmarshalCascade(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":                  "HOST=base\nPORT=80\nUSER=base\nNAME=base\n",
+		".env.production":       "PORT=443\nUSER=production\n",
+		".env.production.local": "USER=local\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got struct {
+		Host string
+		Port string
+		User string
+	}
+	p, err := UnmarshalCascade(&got, dir, "production")
+	if err != nil {
+		t.Fatalf("unmarshal cascade returned an error: %v", err)
+	}
+	if got.Host != "base" || got.Port != "443" || got.User != "local" {
+		t.Errorf("files were not applied in precedence order, got %+v", got)
+	}
+	if want := filepath.Join(dir, ".env.production.local"); p["USER"] != want {
+		t.Errorf("provenance of USER did not match, want %q, got %q", want, p["USER"])
+	}
+}
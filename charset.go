@@ -0,0 +1,39 @@
+package envfile
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to their code
+// points. The bytes that are not defined map to the C1 control character with
+// the same value, like in Latin-1 (ISO 8859-1), which equals Unicode for all
+// other bytes.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// transcodeLegacy returns s converted from Windows-1252 to UTF-8 when it is
+// not valid UTF-8. Text in a legacy charset is rarely also valid UTF-8, as
+// that requires every byte above 0x7F to be part of a multi-byte sequence.
+func transcodeLegacy(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s) + len(s)/2)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0:
+			b.WriteRune(windows1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/basvdlei/envfile"
+)
+
+// runCheck validates files against a JSON Schema, as exported with
+// envfile.JSONSchema, and prints a line per violation:
+//
+//	file.env:3: PORT: has value "http" not matching pattern ^[+-]?[0-9]+$
+//	file.env:0: TOKEN: is required
+//
+// The line number is 0 for required variables that are not set. With -json
+// the violations of all files are written as a JSON array of
+// envfile.Diagnostic instead.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	schemaFile := fs.String("schema", "", "JSON Schema `file` as written by envfile.JSONSchema")
+	asJSON := fs.Bool("json", false, "write the violations as a JSON array")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile check [-json] -schema schema.json file.env...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *schemaFile == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	schema, err := os.ReadFile(*schemaFile)
+	if err != nil {
+		return fail(err)
+	}
+	status := exitOK
+	diagnostics := []envfile.Diagnostic{}
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fail(err)
+		}
+		d, err := envfile.ParseDocument(data)
+		if err != nil {
+			return fail(envfile.ErrorFile{Path: name, Err: err})
+		}
+		err = d.ValidateSchema(schema)
+		errs, ok := err.(envfile.ErrorList)
+		if err != nil && !ok {
+			return fail(err)
+		}
+		if *asJSON {
+			diagnostics = append(diagnostics, envfile.ErrorDiagnostics(name, data, errs)...)
+		}
+		for _, err := range errs {
+			e := err.(envfile.ErrorPolicy)
+			if !*asJSON {
+				fmt.Fprintf(os.Stdout, "%s:%d: %s: %s\n", name, e.Line, e.Key, e.Reason)
+			}
+			status = exitFindings
+		}
+	}
+	if *asJSON {
+		if err := writeJSON(diagnostics); err != nil {
+			return fail(err)
+		}
+	}
+	return status
+}
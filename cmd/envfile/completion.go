@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// exampleFile is the file whose keys are completed when the file passed with
+// -file, or the default file, does not exist yet.
+const exampleFile = ".env.example"
+
+// keysCommand is the hidden command used by the completion scripts to list
+// the keys of the first file that can be read.
+const keysCommand = "__keys"
+
+// runCompletion prints the completion script for a shell. The scripts
+// complete the commands, the flags that take files and the keys of the file
+// for get and set.
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: envfile completion bash|zsh|fish\n\n"+
+			"Load the completion in the current shell with:\n\n"+
+			"  bash: source <(envfile completion bash)\n"+
+			"  zsh:  source <(envfile completion zsh)\n"+
+			"  fish: envfile completion fish | source\n")
+		return exitError
+	}
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Printf(bashCompletion, strings.Join(names, " "), keysCommand, exampleFile)
+	case "zsh":
+		fmt.Print("autoload -U +X bashcompinit && bashcompinit\n")
+		fmt.Printf(bashCompletion, strings.Join(names, " "), keysCommand, exampleFile)
+	case "fish":
+		fmt.Printf(fishCompletion, keysCommand, exampleFile)
+		for _, cmd := range commands {
+			fmt.Printf("complete -c envfile -n __fish_use_subcommand -a %s -d '%s'\n", cmd.name, cmd.short)
+		}
+	default:
+		return fail(fmt.Errorf("unsupported shell %q", args[0]))
+	}
+	return exitOK
+}
+
+// runKeys prints the keys of the first of the files that can be read.
+func runKeys(args []string) int {
+	for _, name := range args {
+		d, err := readDocument(name)
+		if err != nil {
+			continue
+		}
+		for _, key := range d.Keys() {
+			fmt.Println(key)
+		}
+		return exitOK
+	}
+	return exitFindings
+}
+
+// bashCompletion is the completion script for bash, and zsh with
+// bashcompinit. The arguments are the command names, the keys command and
+// the example file.
+const bashCompletion = `_envfile() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	if [[ $COMP_CWORD -eq 1 ]]; then
+		COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+		return
+	fi
+	case $prev in
+	-file|--file|-schema|--schema)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return
+		;;
+	esac
+	local file=.env i
+	for ((i = 2; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		-file|--file) file=${COMP_WORDS[i+1]} ;;
+		esac
+	done
+	case ${COMP_WORDS[1]} in
+	get)
+		COMPREPLY=($(compgen -W "$(envfile %[2]s "$file" %[3]s 2>/dev/null)" -- "$cur"))
+		;;
+	set)
+		COMPREPLY=($(compgen -S = -W "$(envfile %[2]s "$file" %[3]s 2>/dev/null)" -- "$cur"))
+		compopt -o nospace 2>/dev/null
+		;;
+	completion)
+		COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+		;;
+	*)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		;;
+	esac
+}
+complete -F _envfile envfile
+`
+
+// fishCompletion is the completion script for fish, without the commands.
+// The arguments are the keys command and the example file.
+const fishCompletion = `function __envfile_keys
+	set -l tokens (commandline -opc)
+	set -l file .env
+	for i in (seq (count $tokens))
+		if contains -- $tokens[$i] -file --file
+			set file $tokens[(math $i + 1)]
+		end
+	end
+	envfile %[1]s $file %[2]s 2>/dev/null
+end
+complete -c envfile -f
+complete -c envfile -n '__fish_seen_subcommand_from get set watch' -o file -r -F
+complete -c envfile -n '__fish_seen_subcommand_from check' -o schema -r -F
+complete -c envfile -n '__fish_seen_subcommand_from check fmt lint redact' -F
+complete -c envfile -n '__fish_seen_subcommand_from get' -a '(__envfile_keys)'
+complete -c envfile -n '__fish_seen_subcommand_from set' -a '(__envfile_keys | string replace -r "$" "=")'
+complete -c envfile -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+`
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/basvdlei/envfile"
+	"github.com/basvdlei/envfile/internal/atomicfile"
+)
+
+// runFmt formats files with envfile.Format. The result is written to the
+// standard output, or back to the files with -w. The standard input is
+// formatted when no files are given.
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "write the result to the files instead of the standard output")
+	list := fs.Bool("l", false, "list the files whose formatting differs, exit with status 1 when there are any")
+	sort := fs.Bool("sort", false, "sort the variables by name")
+	quote := fs.Bool("quote", false, "quote all values")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile fmt [-w] [-l] [-sort] [-quote] [file.env...]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	opts := envfile.FormatOptions{Sort: *sort}
+	if *quote {
+		opts.Quoting = envfile.QuoteAlways
+	}
+	if fs.NArg() == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fail(err)
+		}
+		out, err := envfile.Format(data, opts)
+		if err != nil {
+			return fail(envfile.ErrorFile{Path: "<stdin>", Err: err})
+		}
+		os.Stdout.Write(out)
+		return exitOK
+	}
+	status := exitOK
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fail(err)
+		}
+		out, err := envfile.Format(data, opts)
+		if err != nil {
+			return fail(envfile.ErrorFile{Path: name, Err: err})
+		}
+		switch {
+		case *list:
+			if !bytes.Equal(data, out) {
+				fmt.Println(name)
+				status = exitFindings
+			}
+		case *write:
+			if bytes.Equal(data, out) {
+				continue
+			}
+			if err := atomicfile.WriteFile(name, out, 0600); err != nil {
+				return fail(err)
+			}
+		default:
+			os.Stdout.Write(out)
+		}
+	}
+	return status
+}
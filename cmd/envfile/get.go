@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/basvdlei/envfile"
+)
+
+// defaultFile is the file read by the commands that take a -file flag.
+const defaultFile = ".env"
+
+// runGet prints the values of variables in a file, one per line. The exit
+// status is exitFindings when one of the variables is not set.
+func runGet(args []string) int {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	file := fs.String("file", defaultFile, "the `file` to read")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile get [-file .env] KEY...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	d, err := readDocument(*file)
+	if err != nil {
+		return fail(err)
+	}
+	status := exitOK
+	for _, key := range fs.Args() {
+		value, ok := d.Get(key)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "envfile: %s: variable %q is not set\n", *file, key)
+			status = exitFindings
+			continue
+		}
+		fmt.Println(value)
+	}
+	return status
+}
+
+// runSet sets variables in a file, keeping its comments and layout. The file
+// is created when it does not exist.
+func runSet(args []string) int {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	file := fs.String("file", defaultFile, "the `file` to update")
+	lock := fs.Bool("lock", false, "hold an advisory lock on the file while updating it")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile set [-file .env] [-lock] KEY=VALUE...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	if *lock {
+		unlock, err := envfile.LockFile(*file)
+		if err != nil {
+			return fail(err)
+		}
+		defer unlock()
+	}
+	d, err := readDocument(*file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fail(err)
+	}
+	if d == nil {
+		d, _ = envfile.ParseDocument(nil)
+	}
+	for _, arg := range fs.Args() {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fail(fmt.Errorf("argument %q is not of the form KEY=VALUE", arg))
+		}
+		if err := d.Set(key, value); err != nil {
+			return fail(err)
+		}
+	}
+	if err := d.WriteFile(*file); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+// readDocument reads and parses the file name.
+func readDocument(name string) (*envfile.Document, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	d, err := envfile.ParseDocument(data)
+	if err != nil {
+		return nil, envfile.ErrorFile{Path: name, Err: err}
+	}
+	return d, nil
+}
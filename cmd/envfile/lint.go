@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/basvdlei/envfile"
+)
+
+// runLint checks files with envfile.Lint and prints a line per finding:
+//
+//	file.env:2:1: warning: whitespace around the variable name or value (spacing)
+//
+// With -json the findings of all files are written as a JSON array of
+// envfile.Diagnostic instead. The exit status is exitFindings when there are
+// findings of the minimum severity or more severe.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "write the findings as a JSON array")
+	level := fs.String("severity", "warning", "the minimum `severity` of the findings that fail the check: error, warning or info")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile lint [-json] [-severity level] file.env...\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	min := envfile.SeverityInfo
+	for min >= envfile.SeverityError && min.String() != *level {
+		min--
+	}
+	if min < envfile.SeverityError {
+		return fail(fmt.Errorf("unknown severity %q", *level))
+	}
+	status := exitOK
+	diagnostics := []envfile.Diagnostic{}
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fail(err)
+		}
+		findings, err := envfile.Lint(data, nil)
+		if err != nil {
+			return fail(envfile.ErrorFile{Path: name, Err: err})
+		}
+		for _, f := range findings {
+			if *asJSON {
+				diagnostics = append(diagnostics, f.Diagnostic(name))
+			} else {
+				fmt.Printf("%s:%s\n", name, f)
+			}
+			if f.Severity <= min {
+				status = exitFindings
+			}
+		}
+	}
+	if *asJSON {
+		if err := writeJSON(diagnostics); err != nil {
+			return fail(err)
+		}
+	}
+	return status
+}
+
+// writeJSON writes v as JSON to the standard output.
+func writeJSON(v interface{}) error {
+	return json.NewEncoder(os.Stdout).Encode(v)
+}
@@ -0,0 +1,100 @@
+// Command envfile works with EnvironmentFile (dot env) files from the command
+// line, so they can be checked in CI and shell scripts without writing Go.
+//
+// Usage:
+//
+//	envfile <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	check       validate files against a JSON Schema
+//	completion  print a shell completion script
+//	fmt         format files in canonical form
+//	get         print the values of variables
+//	lint        report problems in files
+//	redact      print a file with its values masked
+//	set         set variables in a file
+//	watch       run a command and restart it when files change
+//
+// Run "envfile <command> -h" for the flags of a command.
+//
+// The exit status is 0 on success, 1 when problems were found in the files
+// and 2 when the command could not be run, for example because of invalid
+// flags or unreadable files.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Exit statuses.
+const (
+	exitOK       = 0
+	exitFindings = 1
+	exitError    = 2
+)
+
+// A command is a subcommand of envfile. Its run function is called with the
+// arguments after the command name and returns the exit status.
+type command struct {
+	name  string
+	short string
+	run   func(args []string) int
+}
+
+// commands are the subcommands in the order they are listed in the usage.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"check", "validate files against a JSON Schema", runCheck},
+		{"completion", "print a shell completion script", runCompletion},
+		{"fmt", "format files in canonical form", runFmt},
+		{"get", "print the values of variables", runGet},
+		{"lint", "report problems in files", runLint},
+		{"redact", "print a file with its values masked", runRedact},
+		{"set", "set variables in a file", runSet},
+		{"watch", "run a command and restart it when files change", runWatch},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command named by the first argument.
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitError
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	if args[0] == keysCommand {
+		return runKeys(args[1:])
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "envfile: unknown command %q\n", args[0])
+	usage()
+	return exitError
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: envfile <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s%s\n", cmd.name, cmd.short)
+	}
+}
+
+// fail reports an error that stops the command and returns exitError.
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "envfile: %v\n", err)
+	return exitError
+}
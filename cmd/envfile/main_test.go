@@ -0,0 +1,251 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/basvdlei/envfile"
+)
+
+// runCommand runs the envfile command with args and stdin as its standard
+// input. It returns the standard output, the standard error and the exit
+// status.
+func runCommand(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	var files [3]*os.File
+	for i, name := range []string{"stdin", "stdout", "stderr"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	if _, err := io.WriteString(files[0], stdin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := files[0].Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	saved := [3]*os.File{os.Stdin, os.Stdout, os.Stderr}
+	os.Stdin, os.Stdout, os.Stderr = files[0], files[1], files[2]
+	status := run(args)
+	os.Stdin, os.Stdout, os.Stderr = saved[0], saved[1], saved[2]
+	var out [2]string
+	for i, f := range files[1:] {
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = string(b)
+	}
+	return out[0], out[1], status
+}
+
+// writeFile writes a file with contents to a new temporary directory and
+// returns its path.
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readFile returns the contents of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestUsage(t *testing.T) {
+	for _, c := range []struct {
+		Args   []string
+		Status int
+	}{
+		{nil, exitError},
+		{[]string{"help"}, exitOK},
+		{[]string{"unknown"}, exitError},
+		{[]string{"get"}, exitError},
+		{[]string{"get", "-unknown"}, exitError},
+	} {
+		_, stderr, status := runCommand(t, "", c.Args...)
+		if status != c.Status {
+			t.Errorf("[%v] exit status did not match, want: %d, got %d", c.Args, c.Status, status)
+		}
+		if !strings.Contains(stderr, "usage: envfile") {
+			t.Errorf("[%v] usage was not printed, got %q", c.Args, stderr)
+		}
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if _, stderr, status := runCommand(t, "", "set", "-file", path, "A=1", "B=two words"); status != exitOK {
+		t.Fatalf("set failed with status %d: %s", status, stderr)
+	}
+	if want, got := "A=1\nB=\"two words\"\n", readFile(t, path); want != got {
+		t.Errorf("file did not match, want: %q, got %q", want, got)
+	}
+	if _, stderr, status := runCommand(t, "", "set", "-file", path, "-lock", "A=2"); status != exitOK {
+		t.Fatalf("set with lock failed with status %d: %s", status, stderr)
+	}
+	stdout, stderr, status := runCommand(t, "", "get", "-file", path, "A", "B", "C")
+	if status != exitFindings {
+		t.Errorf("exit status for a missing variable did not match, want: %d, got %d", exitFindings, status)
+	}
+	if want := "2\ntwo words\n"; stdout != want {
+		t.Errorf("output did not match, want: %q, got %q", want, stdout)
+	}
+	if !strings.Contains(stderr, `"C" is not set`) {
+		t.Errorf("missing variable was not reported, got %q", stderr)
+	}
+
+	for _, arg := range []string{"A B=x", "NOVALUE"} {
+		if _, _, status := runCommand(t, "", "set", "-file", path, arg); status != exitError {
+			t.Errorf("[%s] exit status did not match, want: %d, got %d", arg, exitError, status)
+		}
+	}
+	if want, got := "A=2\nB=\"two words\"\n", readFile(t, path); want != got {
+		t.Errorf("failed set modified the file, want: %q, got %q", want, got)
+	}
+	if _, _, status := runCommand(t, "", "get", "-file", path+".missing", "A"); status != exitError {
+		t.Errorf("exit status for a missing file did not match, want: %d, got %d", exitError, status)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	input := "# Settings.\nHOST=localhost\nDB_PASSWORD=hunter2\n"
+	for _, c := range []struct {
+		Args   []string
+		Output string
+	}{
+		{nil, "# Settings.\nHOST=********\nDB_PASSWORD=********\n"},
+		{[]string{"-secrets"}, "# Settings.\nHOST=localhost\nDB_PASSWORD=********\n"},
+		{[]string{"-keys", "^HOST$"}, "# Settings.\nHOST=********\nDB_PASSWORD=hunter2\n"},
+	} {
+		stdout, stderr, status := runCommand(t, input, append([]string{"redact"}, c.Args...)...)
+		if status != exitOK {
+			t.Fatalf("[%v] redact failed with status %d: %s", c.Args, status, stderr)
+		}
+		if stdout != c.Output {
+			t.Errorf("[%v] output did not match, want: %q, got %q", c.Args, c.Output, stdout)
+		}
+	}
+	path := writeFile(t, ".env", input)
+	stdout, _, status := runCommand(t, "", "redact", "-secrets", path)
+	if want := "# Settings.\nHOST=localhost\nDB_PASSWORD=********\n"; status != exitOK || stdout != want {
+		t.Errorf("output for file did not match, want: %q, got %q (status %d)", want, stdout, status)
+	}
+	if _, _, status := runCommand(t, "INVALID\n", "redact"); status != exitError {
+		t.Errorf("exit status for invalid input did not match, want: %d, got %d", exitError, status)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	input := "A = 1\nB='x y'\n"
+	want := "A=1\nB=\"x y\"\n"
+	path := writeFile(t, ".env", input)
+	if stdout, _, status := runCommand(t, "", "fmt", path); status != exitOK || stdout != want {
+		t.Errorf("output did not match, want: %q, got %q (status %d)", want, stdout, status)
+	}
+	if stdout, _, status := runCommand(t, input, "fmt", "-sort", "-quote"); status != exitOK || stdout != "A=\"1\"\nB=\"x y\"\n" {
+		t.Errorf("output of standard input did not match, got %q (status %d)", stdout, status)
+	}
+	if stdout, _, status := runCommand(t, "", "fmt", "-l", path); status != exitFindings || stdout != path+"\n" {
+		t.Errorf("list did not report the file, got %q (status %d)", stdout, status)
+	}
+	if readFile(t, path) != input {
+		t.Errorf("file was modified without -w")
+	}
+	if _, stderr, status := runCommand(t, "", "fmt", "-w", path); status != exitOK {
+		t.Fatalf("fmt -w failed with status %d: %s", status, stderr)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("file did not match, want: %q, got %q", want, got)
+	}
+	if stdout, _, status := runCommand(t, "", "fmt", "-l", path); status != exitOK || stdout != "" {
+		t.Errorf("list reported a formatted file, got %q (status %d)", stdout, status)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	schema, err := envfile.JSONSchema(struct {
+		Port  int
+		Token string
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaFile := writeFile(t, "schema.json", string(schema))
+	valid := writeFile(t, ".env", "PORT=8080\nTOKEN=secret\n")
+	invalid := writeFile(t, ".env", "PORT=http\n")
+
+	if stdout, stderr, status := runCommand(t, "", "check", "-schema", schemaFile, valid); status != exitOK || stdout != "" {
+		t.Errorf("valid file was reported, got %q %q (status %d)", stdout, stderr, status)
+	}
+	stdout, _, status := runCommand(t, "", "check", "-schema", schemaFile, invalid)
+	if status != exitFindings {
+		t.Errorf("exit status did not match, want: %d, got %d", exitFindings, status)
+	}
+	want := invalid + ":1: PORT: has value \"http\" not matching pattern ^[+-]?[0-9]+$\n" +
+		invalid + ":0: TOKEN: is required\n"
+	if stdout != want {
+		t.Errorf("output did not match, want: %q, got %q", want, stdout)
+	}
+	stdout, _, status = runCommand(t, "", "check", "-json", "-schema", schemaFile, invalid)
+	if status != exitFindings || !strings.HasPrefix(stdout, "[{") {
+		t.Errorf("JSON output did not match, got %q (status %d)", stdout, status)
+	}
+	if _, _, status := runCommand(t, "", "check", valid); status != exitError {
+		t.Errorf("exit status without schema did not match, want: %d, got %d", exitError, status)
+	}
+}
+
+func TestLint(t *testing.T) {
+	path := writeFile(t, ".env", "A=1\nA=2\nlower=x\n")
+	for _, c := range []struct {
+		Args   []string
+		Status int
+	}{
+		{nil, exitFindings},
+		{[]string{"-severity", "error"}, exitOK},
+		{[]string{"-severity", "unknown"}, exitError},
+	} {
+		stdout, _, status := runCommand(t, "", append(append([]string{"lint"}, c.Args...), path)...)
+		if status != c.Status {
+			t.Errorf("[%v] exit status did not match, want: %d, got %d", c.Args, c.Status, status)
+		}
+		if c.Status != exitError && (!strings.Contains(stdout, path+":2:1: warning:") || !strings.Contains(stdout, "("+envfile.RuleKeyCase+")")) {
+			t.Errorf("[%v] findings were not reported, got %q", c.Args, stdout)
+		}
+	}
+	if stdout, _, status := runCommand(t, "", "lint", writeFile(t, ".env", "A=1\n")); status != exitOK || stdout != "" {
+		t.Errorf("clean file was reported, got %q (status %d)", stdout, status)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		stdout, _, status := runCommand(t, "", "completion", shell)
+		if status != exitOK || !strings.Contains(stdout, keysCommand) {
+			t.Errorf("[%s] script was not printed, got status %d", shell, status)
+		}
+	}
+	if _, _, status := runCommand(t, "", "completion", "csh"); status != exitError {
+		t.Errorf("exit status for an unsupported shell did not match, want: %d, got %d", exitError, status)
+	}
+	path := writeFile(t, ".env", "B=1\nA=2\n")
+	if stdout, _, status := runCommand(t, "", keysCommand, path+".missing", path); status != exitOK || stdout != "B\nA\n" {
+		t.Errorf("keys did not match, got %q (status %d)", stdout, status)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/basvdlei/envfile"
+)
+
+// secretKeys matches the names of variables that commonly hold secrets.
+var secretKeys = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|PASSPHRASE|TOKEN|API_?KEY|PRIVATE|CREDENTIAL|AUTH|DSN)`)
+
+// runRedact writes a file to the standard output with its values replaced by
+// envfile.Redacted, keeping comments and layout, so it can be shared safely.
+// The standard input is read when no file or "-" is given.
+func runRedact(args []string) int {
+	fs := flag.NewFlagSet("redact", flag.ContinueOnError)
+	secrets := fs.Bool("secrets", false, "only redact variables whose names look like secrets, such as *_PASSWORD and *_TOKEN")
+	keys := fs.String("keys", "", "only redact variables whose names match the `regexp`")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile redact [-secrets] [-keys regexp] [file.env]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+	var match func(key string) bool
+	switch {
+	case *keys != "":
+		re, err := regexp.Compile(*keys)
+		if err != nil {
+			return fail(err)
+		}
+		match = re.MatchString
+	case *secrets:
+		match = secretKeys.MatchString
+	}
+	name := fs.Arg(0)
+	var data []byte
+	var err error
+	if name == "" || name == "-" {
+		name = "<stdin>"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	d, err := envfile.ParseDocument(data)
+	if err != nil {
+		return fail(envfile.ErrorFile{Path: name, Err: err})
+	}
+	d.Redact(match)
+	if _, err := os.Stdout.Write(d.Bytes()); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
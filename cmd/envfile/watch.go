@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/basvdlei/envfile"
+	"github.com/basvdlei/envfile/internal/filestate"
+)
+
+// fileList is a flag that can be given multiple times.
+type fileList []string
+
+func (l *fileList) String() string { return strings.Join(*l, ",") }
+
+func (l *fileList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// runWatch runs a command with the variables of the files added to its
+// environment and restarts it when any of the files changes. Changes are
+// detected by polling the modification time and size of the files, a restart
+// waits until they did not change for the debounce period, so editors that
+// write a file in multiple steps cause a single restart.
+//
+// When the command exits by itself, it is started again on the next change.
+// An interrupt stops the command and envfile.
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	var files fileList
+	fs.Var(&files, "file", "the `file` to read and watch, can be repeated (default .env)")
+	interval := fs.Duration("interval", 500*time.Millisecond, "how often the files are checked for changes")
+	debounce := fs.Duration("debounce", 300*time.Millisecond, "how long the files must be unchanged before restarting")
+	grace := fs.Duration("grace", 5*time.Second, "how long to wait for the command to stop before it is killed")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: envfile watch [-file .env]... -- command [args...]\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	if len(files) == 0 {
+		files = fileList{defaultFile}
+	}
+	w := &watcher{files: files, args: fs.Args(), grace: *grace}
+	signals := make(chan os.Signal, 1)
+	defer notifySignals(signals)()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	last := filestate.Of(files)
+	var changed time.Time
+	w.start()
+	for {
+		select {
+		case sig := <-signals:
+			w.stop()
+			if sig == os.Interrupt {
+				return 130
+			}
+			return 143
+		case err := <-w.done:
+			w.cmd = nil
+			fmt.Fprintf(os.Stderr, "envfile: %s, waiting for changes\n", exitMessage(err))
+		case now := <-ticker.C:
+			if current := filestate.Of(files); current != last {
+				last, changed = current, now
+				continue
+			}
+			if changed.IsZero() || now.Sub(changed) < *debounce {
+				continue
+			}
+			changed = time.Time{}
+			fmt.Fprintf(os.Stderr, "envfile: %s changed, restarting\n", files.String())
+			w.stop()
+			w.start()
+		}
+	}
+}
+
+// notifySignals relays the signals that stop the watch command to c until
+// the returned function is called. Tests replace it to send the signals
+// without signaling the test process.
+var notifySignals = func(c chan<- os.Signal) (stop func()) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	return func() { signal.Stop(c) }
+}
+
+// watcher runs the command of the watch command.
+type watcher struct {
+	files []string
+	args  []string
+	grace time.Duration
+	// cmd is the running command, or nil when it is not running. done
+	// receives the result of its Wait.
+	cmd  *exec.Cmd
+	done chan error
+}
+
+// start starts the command with the current variables of the files. Errors
+// are reported and the command is then started on the next change.
+func (w *watcher) start() {
+	env, err := envfile.CommandEnv(os.Environ(), w.files...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "envfile: %v, waiting for changes\n", err)
+		return
+	}
+	cmd := exec.Command(w.args[0], w.args[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "envfile: %v, waiting for changes\n", err)
+		return
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	w.cmd, w.done = cmd, done
+}
+
+// stop terminates the command, if it is running, and waits for it to exit.
+// The command is killed when it does not exit within the grace period, or
+// when it can not be signaled, as on Windows.
+func (w *watcher) stop() {
+	if w.cmd == nil {
+		return
+	}
+	if err := w.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		w.cmd.Process.Kill()
+	}
+	select {
+	case <-w.done:
+	case <-time.After(w.grace):
+		w.cmd.Process.Kill()
+		<-w.done
+	}
+	w.cmd, w.done = nil, nil
+}
+
+// exitMessage describes how the command exited.
+func exitMessage(err error) string {
+	if err == nil {
+		return "command exited"
+	}
+	return "command failed: " + err.Error()
+}
@@ -0,0 +1,115 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startWatch runs the watch command with args in the background. It returns
+// the channel the command receives its stop signals on and a channel that
+// receives its exit status. The standard error is discarded for the duration
+// of the test.
+func startWatch(t *testing.T, args ...string) (chan<- os.Signal, <-chan int) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedNotify := os.Stderr, notifySignals
+	registered := make(chan chan<- os.Signal, 1)
+	os.Stderr = f
+	notifySignals = func(c chan<- os.Signal) func() {
+		registered <- c
+		return func() {}
+	}
+	status := make(chan int, 1)
+	go func() { status <- run(append([]string{"watch"}, args...)) }()
+	t.Cleanup(func() {
+		os.Stderr, notifySignals = saved, savedNotify
+		f.Close()
+	})
+	select {
+	case c := <-registered:
+		return c, status
+	case s := <-status:
+		t.Fatalf("watch exited with status %d", s)
+	}
+	return nil, nil
+}
+
+// waitFile waits until the file at path has the contents want.
+func waitFile(t *testing.T, path, want string) {
+	t.Helper()
+	var got []byte
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if got, _ = os.ReadFile(path); string(got) == want {
+			return
+		}
+	}
+	t.Fatalf("file did not match, want: %q, got %q", want, got)
+}
+
+// waitStatus waits for the exit status of the watch command.
+func waitStatus(t *testing.T, status <-chan int, want int) {
+	t.Helper()
+	select {
+	case got := <-status:
+		if got != want {
+			t.Errorf("exit status did not match, want: %d, got %d", want, got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("watch did not exit")
+	}
+}
+
+func TestWatchRestart(t *testing.T) {
+	dir := t.TempDir()
+	env, out := filepath.Join(dir, ".env"), filepath.Join(dir, "out")
+	if err := os.WriteFile(env, []byte("NAME=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signals, status := startWatch(t, "-file", env, "-interval", "10ms", "-debounce", "200ms", "-grace", "5s",
+		"--", "sh", "-c", `echo "$NAME" >> "$0"; exec sleep 60`, out)
+	waitFile(t, out, "1\n")
+
+	// Writes within the debounce period cause a single restart with the
+	// last contents.
+	for _, value := range []string{"22", "333"} {
+		if err := os.WriteFile(env, []byte("NAME="+value+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFile(t, out, "1\n333\n")
+
+	signals <- syscall.SIGTERM
+	waitStatus(t, status, 143)
+	if got, _ := os.ReadFile(out); string(got) != "1\n333\n" {
+		t.Errorf("command was not restarted once, got output %q", got)
+	}
+}
+
+func TestWatchGrace(t *testing.T) {
+	dir := t.TempDir()
+	env, out := filepath.Join(dir, ".env"), filepath.Join(dir, "out")
+	if err := os.WriteFile(env, []byte("NAME=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The command ignores SIGTERM, so it is only stopped by the kill after
+	// the grace period.
+	signals, status := startWatch(t, "-file", env, "-interval", "10ms", "-debounce", "20ms", "-grace", "100ms",
+		"--", "sh", "-c", `trap "" TERM; echo "$NAME" >> "$0"; while :; do sleep 0.05; done`, out)
+	waitFile(t, out, "1\n")
+
+	if err := os.WriteFile(env, []byte("NAME=22\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitFile(t, out, "1\n22\n")
+
+	signals <- os.Interrupt
+	waitStatus(t, status, 130)
+}
@@ -0,0 +1,75 @@
+package envfile
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A Codec marshals and unmarshals values of struct type T. The struct type is
+// analyzed once by Compile, so the per-call work is limited to converting the
+// values.
+//
+// A Codec is safe for concurrent use. Compile it after registering any
+// conversions for the field types with RegisterDecoder or RegisterEncoder.
+// The zero Codec is usable and behaves like Marshal and Unmarshal.
+type Codec[T any] struct {
+	si *structInfo
+}
+
+// Compile analyzes struct type T and returns a Codec for it. It returns a
+// ErrorUnsupportedType when T is not a struct or contains fields of
+// unsupported types that are not explicitly ignored, and a ErrorDuplicateKey
+// when two fields map to the same variable.
+func Compile[T any]() (Codec[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return Codec[T]{}, ErrorUnsupportedType{t.Kind()}
+	}
+	si := cachedStruct(t)
+	if err := si.checkDecode(); err != nil {
+		return Codec[T]{}, err
+	}
+	return Codec[T]{si: si}, nil
+}
+
+// Marshal returns the EnvironmentFile encoding of v, like Marshal.
+func (c Codec[T]) Marshal(v T) ([]byte, error) {
+	if c.si != nil && c.si.plain {
+		if b, ok := c.si.appendPlain(nil, reflect.ValueOf(&v).Elem()); ok {
+			return b, nil
+		}
+	}
+	return appendEncoded(nil, NewEncoder(nil), v)
+}
+
+// Unmarshal parses the EnvironmentFile encoded data and stores the result in
+// the value pointed to by v, like Unmarshal.
+func (c Codec[T]) Unmarshal(data []byte, v *T) error {
+	if v == nil {
+		return ErrorInvalidTarget{reflect.TypeOf(v)}
+	}
+	if c.si == nil {
+		return Unmarshal(data, v)
+	}
+	rv := reflect.ValueOf(v).Elem()
+	// Lines borrow from data, so the keys and values are copied before they
+	// are stored.
+	lr := newBorrowingLineReader(data)
+	for lr.Next() {
+		l, ok := parseLine(lr.Text())
+		if l == nil {
+			continue
+		}
+		if !ok {
+			return ErrorLineParsing{lr.Line()}
+		}
+		key, value := strings.Clone(l.Key), strings.Clone(l.Value)
+		if _, err := c.si.assign(rv, key, value, false); err != nil {
+			return err
+		}
+	}
+	if err := lr.Err(); err != nil {
+		return err
+	}
+	return validate(v)
+}
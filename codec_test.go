@@ -0,0 +1,120 @@
+package envfile
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCodec(t *testing.T) {
+	c, err := Compile[benchmarkConfig]()
+	if err != nil {
+		t.Fatalf("compile returned an error: %v", err)
+	}
+	v := newBenchmarkConfig()
+	got, err := c.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	want, _ := Marshal(v)
+	if !bytes.Equal(want, got) {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	var decoded benchmarkConfig
+	if err := c.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(v, decoded) {
+		t.Errorf("round trip did not match\nwant:\n%+v,\tgot\n%+v", v, decoded)
+	}
+	if err := c.Unmarshal([]byte("NAME=app\nINVALID\n"), &decoded); err != (ErrorLineParsing{2}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{2}, err)
+	}
+
+	ordered, err := Compile[orderedConfig]()
+	if err != nil {
+		t.Fatalf("compile returned an error: %v", err)
+	}
+	if got, _ := ordered.Marshal(orderedConfig{}); string(got) != "HOST=\nZOO=\nALPHA=\nLAST=\n" {
+		t.Errorf("ordered output did not match, got %q", got)
+	}
+}
+
+func TestCodecZeroAndNil(t *testing.T) {
+	var zero Codec[benchmarkConfig]
+	v := newBenchmarkConfig()
+	data, err := zero.Marshal(v)
+	if err != nil {
+		t.Fatalf("zero codec marshal returned an error: %v", err)
+	}
+	var decoded benchmarkConfig
+	if err := zero.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("zero codec unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(v, decoded) {
+		t.Errorf("zero codec round trip did not match\nwant:\n%+v,\tgot\n%+v", v, decoded)
+	}
+
+	c, err := Compile[benchmarkConfig]()
+	if err != nil {
+		t.Fatalf("compile returned an error: %v", err)
+	}
+	for _, codec := range []Codec[benchmarkConfig]{zero, c} {
+		err := codec.Unmarshal(data, nil)
+		if _, ok := err.(ErrorInvalidTarget); !ok {
+			t.Errorf("error did not match, want: ErrorInvalidTarget, got %v", err)
+		}
+	}
+}
+
+func TestCompileUnsupported(t *testing.T) {
+	if _, err := Compile[string](); err != (ErrorUnsupportedType{reflect.String}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.String}, err)
+	}
+	type unsupported struct {
+		Count chan int
+	}
+	if _, err := Compile[unsupported](); err != (ErrorUnsupportedType{reflect.Chan}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.Chan}, err)
+	}
+}
+
+func BenchmarkCodecMarshal(b *testing.B) {
+	c, err := Compile[benchmarkConfig]()
+	if err != nil {
+		b.Fatal(err)
+	}
+	v := newBenchmarkConfig()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecUnmarshal(b *testing.B) {
+	c, err := Compile[benchmarkConfig]()
+	if err != nil {
+		b.Fatal(err)
+	}
+	data, _ := c.Marshal(newBenchmarkConfig())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v benchmarkConfig
+		if err := c.Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data, _ := Marshal(newBenchmarkConfig())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v benchmarkConfig
+		if err := Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
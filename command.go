@@ -0,0 +1,81 @@
+package envfile
+
+import (
+	"bytes"
+	"os"
+	"strings"
+)
+
+// CommandEnv returns the environment for a child process, for example as
+// exec.Cmd.Env, composed from base and the variables in the EnvironmentFiles.
+// Usually base is os.Environ().
+//
+// The entries of base come first, followed by the files in the order they are
+// given, where a variable overrides any earlier entry with the same name. The
+// result holds every variable once, at the position where it first appeared,
+// with its last value. Errors are returned as a ErrorFile.
+func CommandEnv(base []string, files ...string) ([]string, error) {
+	env := newEnvList(base)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, ErrorFile{file, err}
+		}
+		pairs, err := readPairs(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrorFile{file, err}
+		}
+		for _, p := range pairs {
+			env.set(p.Key, p.Value)
+		}
+	}
+	return env.entries, nil
+}
+
+// CommandEnvStruct is like CommandEnv but takes the variables from the
+// EnvironmentFile encoding of v, as Marshal would write them.
+func CommandEnvStruct(base []string, v interface{}) ([]string, error) {
+	vars, err := encodeVars(v, ZeroByTag)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeys(vars, false); err != nil {
+		return nil, err
+	}
+	env := newEnvList(base)
+	for _, p := range vars {
+		env.set(p.Key, p.Value)
+	}
+	return env.entries, nil
+}
+
+// envList is a list of "key=value" environment entries with unique keys.
+type envList struct {
+	entries []string
+	index   map[string]int
+}
+
+// newEnvList returns an envList holding the entries of base.
+func newEnvList(base []string) *envList {
+	l := &envList{index: make(map[string]int)}
+	for _, kv := range base {
+		key := strings.SplitN(kv, "=", 2)[0]
+		l.put(key, kv)
+	}
+	return l
+}
+
+// set sets the variable key to value.
+func (l *envList) set(key, value string) {
+	l.put(key, key+"="+value)
+}
+
+// put replaces the entry of key by kv, or appends it when key is not set.
+func (l *envList) put(key, kv string) {
+	if i, ok := l.index[key]; ok {
+		l.entries[i] = kv
+		return
+	}
+	l.index[key] = len(l.entries)
+	l.entries = append(l.entries, kv)
+}
@@ -0,0 +1,47 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandEnv(t *testing.T) {
+	paths := writeTempFiles(t,
+		"HOST=filehost\nPORT=80\n",
+		"PORT=8080\nDEBUG=1\n",
+	)
+	base := []string{"PATH=/bin", "HOST=envhost", "PATH=/usr/bin"}
+	got, err := CommandEnv(base, paths...)
+	if err != nil {
+		t.Fatalf("command env returned an error: %v", err)
+	}
+	want := []string{"PATH=/usr/bin", "HOST=filehost", "PORT=8080", "DEBUG=1"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("environment did not match, want %v, got %v", want, got)
+	}
+	if want, got := []string{"PATH=/bin", "HOST=envhost", "PATH=/usr/bin"}, base; !reflect.DeepEqual(want, got) {
+		t.Errorf("base was modified: %v", got)
+	}
+
+	if _, err := CommandEnv(nil, paths[0]+".missing"); err == nil {
+		t.Errorf("missing file did not return an error")
+	} else if _, ok := err.(ErrorFile); !ok {
+		t.Errorf("error is not a ErrorFile: %v", err)
+	}
+}
+
+func TestCommandEnvStruct(t *testing.T) {
+	v := struct {
+		Host string
+		Port string `env:",omitempty"`
+	}{
+		Host: "structhost",
+	}
+	got, err := CommandEnvStruct([]string{"HOST=envhost", "PORT=80"}, v)
+	if err != nil {
+		t.Fatalf("command env returned an error: %v", err)
+	}
+	if want := []string{"HOST=structhost", "PORT=80"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("environment did not match, want %v, got %v", want, got)
+	}
+}
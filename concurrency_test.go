@@ -0,0 +1,67 @@
+package envfile
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Run with the race detector, go test -race, to check the guarantees in the
+// package documentation.
+
+type concurrencyLevel int
+
+func TestConcurrentUnmarshal(t *testing.T) {
+	type config struct {
+		Host   string
+		Port   int
+		Level  concurrencyLevel
+		Labels map[string]string
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				want := config{Host: "host" + strconv.Itoa(i), Port: j, Labels: map[string]string{"A": "b"}}
+				data, err := Marshal(want)
+				if err != nil {
+					t.Errorf("marshal returned an error: %v", err)
+					return
+				}
+				var got config
+				if err := Unmarshal(data, &got); err != nil {
+					t.Errorf("unmarshal returned an error: %v", err)
+					return
+				}
+				if got.Host != want.Host || got.Port != want.Port || got.Labels["A"] != "b" {
+					t.Errorf("value did not match, want: %+v, got %+v", want, got)
+					return
+				}
+				// A Decoder and Encoder per goroutine.
+				dec := NewDecoder(bytes.NewReader(data))
+				if err := dec.Decode(&got); err != nil {
+					t.Errorf("decode returned an error: %v", err)
+				}
+				if err := NewEncoder(&bytes.Buffer{}).Encode(got); err != nil {
+					t.Errorf("encode returned an error: %v", err)
+				}
+			}
+		}(i)
+	}
+	// Registering conversions resets the shared field information while
+	// the other goroutines use it.
+	for j := 0; j < 20; j++ {
+		RegisterDecoder(func(s string) (concurrencyLevel, error) {
+			n, err := strconv.Atoi(strings.TrimPrefix(s, "L"))
+			return concurrencyLevel(n), err
+		})
+		RegisterEncoder(func(l concurrencyLevel) (string, error) {
+			return "L" + strconv.Itoa(int(l)), nil
+		})
+	}
+	wg.Wait()
+}
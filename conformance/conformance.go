@@ -0,0 +1,215 @@
+// Package conformance contains a corpus of EnvironmentFile inputs with their
+// expected results in the strict dialect of the envfile package.
+//
+// The corpus is the reference for the grammar described in the envfile
+// package documentation. Other implementations can run it to check that they
+// read the same variables from the same input, and new features of the
+// dialect are added to it together with their implementation.
+package conformance
+
+// Var is a variable assignment.
+type Var struct {
+	Key   string
+	Value string
+}
+
+// Case is an input with its expected result.
+type Case struct {
+	Name  string
+	Input string
+	// Valid reports whether Input matches the strict grammar.
+	Valid bool
+	// Vars are the assignments in Input in order of appearance. It is
+	// empty for invalid input.
+	Vars []Var
+}
+
+// Cases is the conformance corpus.
+var Cases = []Case{
+	{
+		Name:  "empty input",
+		Input: "",
+		Valid: true,
+	},
+	{
+		Name:  "blank lines and comments",
+		Input: "\n   \n# comment\n\t# indented comment\n#KEY=value\n",
+		Valid: true,
+	},
+	{
+		Name:  "bare value",
+		Input: "KEY=value\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value"}},
+	},
+	{
+		Name:  "last line without line ending",
+		Input: "A=1\nB=2",
+		Valid: true,
+		Vars:  []Var{{"A", "1"}, {"B", "2"}},
+	},
+	{
+		Name:  "crlf line endings",
+		Input: "A=1\r\nB=2\r\n",
+		Valid: true,
+		Vars:  []Var{{"A", "1"}, {"B", "2"}},
+	},
+	{
+		Name:  "empty value",
+		Input: "KEY=\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", ""}},
+	},
+	{
+		Name:  "empty value with trailing blanks",
+		Input: "KEY= \t\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", ""}},
+	},
+	{
+		Name:  "indented assignment",
+		Input: "  KEY=value\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value"}},
+	},
+	{
+		Name:  "trailing blanks are not part of a bare value",
+		Input: "KEY=value \t\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value"}},
+	},
+	{
+		Name:  "bare value with inner whitespace",
+		Input: "KEY=two words\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "two words"}},
+	},
+	{
+		Name:  "no inline comments",
+		Input: "KEY=value # comment\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value # comment"}},
+	},
+	{
+		Name:  "no line continuations",
+		Input: "KEY=first \\\nNEXT=second\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "first \\"}, {"NEXT", "second"}},
+	},
+	{
+		Name:  "equal signs in value",
+		Input: "KEY=a=b=c\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "a=b=c"}},
+	},
+	{
+		Name:  "export keyword",
+		Input: "export KEY=value\nexport\tOTHER=1\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value"}, {"OTHER", "1"}},
+	},
+	{
+		Name:  "export as name",
+		Input: "export=value\nexporter=1\n",
+		Valid: true,
+		Vars:  []Var{{"export", "value"}, {"exporter", "1"}},
+	},
+	{
+		Name:  "names are case sensitive and not restricted to identifiers",
+		Input: "key=1\nKey.Name=2\n1ST=3\n",
+		Valid: true,
+		Vars:  []Var{{"key", "1"}, {"Key.Name", "2"}, {"1ST", "3"}},
+	},
+	{
+		Name:  "names with letters of a single script",
+		Input: "GRÖSSE=1\nΌΝΟΜΑ_1=2\n",
+		Valid: true,
+		Vars:  []Var{{"GRÖSSE", "1"}, {"ΌΝΟΜΑ_1", "2"}},
+	},
+	{
+		Name:  "single quoted value is literal",
+		Input: "KEY='  $HOME \\n \"x\" '\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "  $HOME \\n \"x\" "}},
+	},
+	{
+		Name:  "double quoted value with escapes",
+		Input: "KEY=\"a\\\\b\\\"c\\nd\\re\\tf\\$g\\xh\"\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "a\\b\"c\nd\re\tf$g\\xh"}},
+	},
+	{
+		Name:  "quoted value with trailing blanks",
+		Input: "KEY=\"value\" \n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "value"}},
+	},
+	{
+		Name:  "empty quoted values",
+		Input: "A=''\nB=\"\"\n",
+		Valid: true,
+		Vars:  []Var{{"A", ""}, {"B", ""}},
+	},
+	{
+		Name:  "quotes inside bare value",
+		Input: "KEY=it's \"fine\"\n",
+		Valid: true,
+		Vars:  []Var{{"KEY", "it's \"fine\""}},
+	},
+	{
+		Name:  "missing separator",
+		Input: "KEY\n",
+	},
+	{
+		Name:  "empty name",
+		Input: "=value\n",
+	},
+	{
+		Name:  "blank before separator",
+		Input: "KEY =value\n",
+	},
+	{
+		Name:  "blank after separator",
+		Input: "KEY= value\n",
+	},
+	{
+		Name:  "blanks around separator",
+		Input: "KEY = value\n",
+	},
+	{
+		Name:  "whitespace in name",
+		Input: "MY KEY=value\n",
+	},
+	{
+		Name:  "unterminated single quote",
+		Input: "KEY='value\n",
+	},
+	{
+		Name:  "unterminated double quote",
+		Input: "KEY=\"value\n",
+	},
+	{
+		Name:  "text after quoted value",
+		Input: "KEY=\"value\"rest\n",
+	},
+	{
+		Name:  "multi-line quoted value",
+		Input: "KEY=\"first\nsecond\"\n",
+	},
+	{
+		Name:  "zero-width space in name",
+		Input: "KEY\u200b=value\n",
+	},
+	{
+		Name:  "name mixing scripts",
+		Input: "K\u0415Y=value\n",
+	},
+	{
+		Name:  "name that is not valid UTF-8",
+		Input: "K\xffY=value\n",
+	},
+	{
+		Name:  "invalid line after valid lines",
+		Input: "A=1\nB=2\nC\n",
+	},
+}
@@ -0,0 +1,22 @@
+package envfile
+
+import (
+	"strings"
+)
+
+// DecryptFunc returns the plaintext of the encrypted value of the variable
+// key. The ciphertext is the text between the brackets of an ENC[...] value.
+type DecryptFunc func(key, ciphertext string) (string, error)
+
+// EncryptFunc returns the ciphertext of the value of the variable key, it is
+// written enclosed in ENC[...].
+type EncryptFunc func(key, plaintext string) (string, error)
+
+// encryptedValue returns the ciphertext of a value of the form ENC[...] and
+// whether the value has that form.
+func encryptedValue(value string) (string, bool) {
+	if !strings.HasPrefix(value, "ENC[") || !strings.HasSuffix(value, "]") {
+		return "", false
+	}
+	return value[len("ENC[") : len(value)-1], true
+}
@@ -0,0 +1,64 @@
+package envfile
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// rot13 is a reversible stand-in for real encryption in tests.
+func rot13(key, s string) (string, error) {
+	if s == "fail" {
+		return "", errors.New("bad ciphertext")
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, s), nil
+}
+
+func TestEncryptedValues(t *testing.T) {
+	type config struct {
+		User     string
+		Password string `env:",secret"`
+		Token    string `env:",secret,omitempty"`
+	}
+	in := config{User: "admin", Password: "$ecret"}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetEncrypt(rot13)
+	if err := enc.Encode(in); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	if want := "USER=admin\nPASSWORD=ENC[$rperg]\n"; buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+
+	dec := NewDecoder(strings.NewReader(buf.String() + "TOKEN=${PASSWORD}\n"))
+	dec.SetDecrypt(rot13)
+	dec.SetExpand(true)
+	var got config
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if want := (config{"admin", "$ecret", "$ecret"}); got != want {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+
+	if err := Unmarshal(buf.Bytes(), &got); err != nil || got.Password != "ENC[$rperg]" {
+		t.Errorf("value without decrypt function was not kept, got %q, %v", got.Password, err)
+	}
+
+	dec = NewDecoder(strings.NewReader("USER=x\nPASSWORD=ENC[fail]\n"))
+	dec.SetDecrypt(rot13)
+	err := dec.Decode(&got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "PASSWORD" {
+		t.Errorf("error did not match, want: ErrorValueParsing for PASSWORD, got %v", err)
+	}
+}
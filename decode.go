@@ -0,0 +1,492 @@
+package envfile
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Decoder reads and decodes EnvironmentFile data from an input stream. It
+// is not safe for concurrent use, see the package documentation.
+type Decoder struct {
+	r io.Reader
+	// data is read instead of r when borrow is set, see UnmarshalNoCopy.
+	data        []byte
+	borrow      bool
+	lr          *lineReader
+	prefix      string
+	stripPrefix bool
+	envOverride bool
+	fillEmpty   bool
+	expand      bool
+	dialect     Dialect
+	profile     string
+	lookup      LookupFunc
+	env         Environment
+	decrypt     DecryptFunc
+	normalize   func(key string) string
+	lossy       bool
+	legacy      bool
+	comments    string
+	warnings    []error
+
+	// set is called with the name and value of every assigned variable.
+	set func(key, value string)
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// SetPrefix makes the Decoder only consider variables whose name starts with
+// prefix. All other lines, including lines that can not be parsed, are
+// ignored. This allows decoding from a shared environment containing many
+// unrelated variables.
+func (dec *Decoder) SetPrefix(prefix string) {
+	dec.prefix = prefix
+}
+
+// SetStripPrefix controls whether the prefix set by SetPrefix is removed from
+// variable names before they are matched with the struct fields. This allows
+// a struct with bare names like HOST and PORT to be decoded from MYAPP_HOST
+// and MYAPP_PORT, and to be reused with different prefixes.
+func (dec *Decoder) SetStripPrefix(on bool) {
+	dec.stripPrefix = on
+}
+
+// SetEnvOverride controls whether variables set in the process environment
+// take precedence over the values in the input. When enabled, every field
+// whose variable is also present in os.Environ, or in the Environment set
+// with SetEnvironment, is assigned the environment value after the input has
+// been decoded. The prefix set by SetPrefix applies to the environment
+// variables as well.
+func (dec *Decoder) SetEnvOverride(on bool) {
+	dec.envOverride = on
+}
+
+// SetFillEmpty controls whether only fields holding their zero value are
+// assigned. Fields that already have a value before decoding are left
+// untouched, so a struct populated from flags can be backfilled from a file
+// without overwriting the explicit settings.
+//
+// Values are decoded into a copy of the target and the zero fields are
+// assigned after decoding succeeded, the target is not modified when an error
+// is returned.
+func (dec *Decoder) SetFillEmpty(on bool) {
+	dec.fillEmpty = on
+}
+
+// SetExpand controls whether ${VAR} and $VAR references in values are
+// replaced by the value of the referenced variable. Unset variables are
+// replaced by an empty string.
+//
+// By default references are resolved using the variables set earlier in the
+// input, followed by the process environment. Use SetLookup to resolve them
+// from another source.
+func (dec *Decoder) SetExpand(on bool) {
+	dec.expand = on
+}
+
+// SetStrict controls whether lines are parsed according to the strict grammar
+// described in the package documentation. Lines that do not match, such as
+// assignments with whitespace around the '=' separator like "KEY = value",
+// are then rejected with a ErrorLineParsing. By default such whitespace is
+// removed, which is convenient for hand-written files but not understood by
+// all other implementations.
+//
+// Strict mode also limits bool values to those accepted by strconv.ParseBool.
+// By default yes/no, on/off and enabled/disabled are accepted as well, in any
+// case, as commonly found in files written for shell scripts.
+//
+// SetStrict(true) is the same as SetDialect(DialectStrict) and SetStrict(false)
+// as SetDialect(DialectLenient).
+func (dec *Decoder) SetStrict(on bool) {
+	dec.dialect = DialectLenient
+	if on {
+		dec.dialect = DialectStrict
+	}
+}
+
+// SetDialect selects the rules used to parse lines, see Dialect. Lines that do
+// not match are rejected with a ErrorLineParsing.
+//
+// With DialectShell the decoded values are guaranteed to be the values bash
+// assigns when the file is sourced, for files that are used both ways. Only
+// assignments of literal values are accepted, lines with references like
+// $HOME, command substitutions or multiple commands are rejected. Values are
+// therefore never expanded, even when SetExpand is enabled, and a "\$" escape
+// results in a literal '$'.
+func (dec *Decoder) SetDialect(d Dialect) {
+	dec.dialect = d
+}
+
+// SetProfile selects the profile whose blocks are applied. A profile block
+// starts with a comment naming one or more comma-separated profiles and ends
+// with an end comment:
+//
+//	HOST=localhost
+//	# [profile:production,staging]
+//	HOST=db.internal
+//	# [end]
+//
+// Lines outside blocks always apply, lines inside a block only when one of its
+// profiles is the active profile. By default, or with an empty name, no
+// profile is active and all blocks are skipped, so a block at the end of the
+// file can not silently override the values above it. Blocks can not be
+// nested, a ErrorLineParsing is returned for a block that starts inside
+// another block, for an end without a block and for a block that is not
+// ended.
+func (dec *Decoder) SetProfile(name string) {
+	dec.profile = name
+}
+
+// SetDecrypt sets a function that decrypts values of the form ENC[...], as
+// written by tools like SOPS, before they are assigned. The text between the
+// brackets is passed to fn together with the variable name, errors returned
+// by fn are returned as a ErrorValueParsing. Decrypted values are not
+// expanded by SetExpand, but can be referenced by other values. By default,
+// or with a nil fn, such values are assigned as is.
+func (dec *Decoder) SetDecrypt(fn DecryptFunc) {
+	dec.decrypt = fn
+}
+
+// SetLossy controls whether lines that can not be parsed are skipped instead
+// of aborting the decoding with a ErrorLineParsing. The skipped lines are
+// reported by Warnings, so tools can make the most of messy files and still
+// point out the problems.
+func (dec *Decoder) SetLossy(on bool) {
+	dec.lossy = on
+}
+
+// Warnings returns a ErrorLineParsing for every line that was skipped in
+// lossy mode by the calls to Decode so far, in order of their lines.
+func (dec *Decoder) Warnings() []error {
+	return dec.warnings
+}
+
+// SetNormalize sets a function that is applied to every variable name before
+// it is matched with the struct fields, for example norm.NFC.String from
+// golang.org/x/text/unicode/norm so names written in a decomposed form still
+// match. Use a nil fn to match names as written.
+func (dec *Decoder) SetNormalize(fn func(key string) string) {
+	dec.normalize = fn
+}
+
+// SetLegacyCharset controls whether input that is not valid UTF-8 is decoded
+// as Windows-1252 and converted to UTF-8 before it is parsed. Windows-1252 is
+// a superset of the printable characters of Latin-1 (ISO 8859-1) and the
+// charset of most files written by older Windows programs, whose accented
+// characters otherwise end up as invalid UTF-8 in the decoded values.
+//
+// The detection works per line: lines that are valid UTF-8, including all
+// ASCII lines, are used as they are.
+func (dec *Decoder) SetLegacyCharset(on bool) {
+	dec.legacy = on
+}
+
+// SetCommentChars sets additional characters that start a comment when they
+// are the first non-blank character of a line, like the ';' used by
+// INI-flavored files that some daemons read. A '#' always starts a comment.
+// Like '#', the characters do not start a comment after the '='.
+func (dec *Decoder) SetCommentChars(chars string) {
+	dec.comments = chars
+}
+
+// SetLookup sets the function used to resolve references when expansion is
+// enabled with SetExpand. Values returned by fn are expanded as well, a
+// ErrorExpansion is returned when references form a cycle or are nested too
+// deep. Setting a nil fn restores the default lookup.
+func (dec *Decoder) SetLookup(fn LookupFunc) {
+	dec.lookup = fn
+}
+
+// SetEnvironment sets the Environment used instead of the process
+// environment by SetEnvOverride, and to resolve references when expansion is
+// enabled and no lookup function is set. A nil env selects the process
+// environment, the default.
+func (dec *Decoder) SetEnvironment(env Environment) {
+	dec.env = env
+}
+
+// Decode reads the EnvironmentFile encoded input and stores the result in the
+// value pointed to by v.
+//
+// See the documentation for Unmarshal for details about the conversion.
+func (dec *Decoder) Decode(v interface{}) error {
+	return dec.DecodeContext(context.Background(), v)
+}
+
+// DecodeContext is like Decode but stops reading the input when ctx is done,
+// returning the context error. The context is checked between lines, a Read
+// call on the underlying reader that blocks is not interrupted.
+func (dec *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
+	if err := checkTarget(v); err != nil {
+		return err
+	}
+	var err error
+	if dec.fillEmpty {
+		err = dec.decodeFillEmpty(ctx, v)
+	} else {
+		err = dec.decode(ctx, v)
+	}
+	if err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+// decodeFillEmpty decodes into a copy of the struct pointed to by v and only
+// copies back the fields that were zero before decoding.
+func (dec *Decoder) decodeFillEmpty(ctx context.Context, v interface{}) error {
+	orig, err := targetStruct(v)
+	if err != nil {
+		return err
+	}
+	tmp := reflect.New(orig.Type())
+	tmp.Elem().Set(orig)
+	copyNested(tmp.Elem())
+	if err := dec.decode(ctx, tmp.Interface()); err != nil {
+		return err
+	}
+	for _, f := range typeFields(orig.Type()) {
+		src, err := tmp.Elem().FieldByIndexErr(f.Index)
+		if err != nil {
+			continue
+		}
+		field, err := orig.FieldByIndexErr(f.Index)
+		if err != nil {
+			if src.IsZero() {
+				continue
+			}
+			field = fieldByIndexAlloc(orig, f.Index)
+		}
+		if field.IsZero() && field.CanSet() {
+			field.Set(src)
+		}
+	}
+	return nil
+}
+
+// copyNested replaces the pointers to nested structs in struct value v by
+// pointers to copies, so v can be modified without affecting the original.
+func copyNested(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		fv := v.Field(i)
+		switch {
+		case !fv.CanSet():
+		case nestedStruct(fv.Type()):
+			copyNested(fv)
+		case nestedPointer(fv.Type()) && !fv.IsNil():
+			p := reflect.New(fv.Type().Elem())
+			p.Elem().Set(fv.Elem())
+			fv.Set(p)
+			copyNested(p.Elem())
+		}
+	}
+}
+
+// decode reads the input and stores the result in the value pointed to by v.
+func (dec *Decoder) decode(ctx context.Context, v interface{}) error {
+	earlier := make(map[string]string)
+	exp := newExpander(dec.lookup, environment(dec.env), earlier)
+	profiles := profileFilter{active: dec.profile}
+	lr := dec.lineReader()
+	for lr.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		count := lr.Line()
+		skip, err := profiles.line(count, lr.Text())
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		l, ok := dec.parseLine(lr.Text())
+		if l == nil {
+			continue
+		}
+		key := l.Key
+		if dec.normalize != nil {
+			key = dec.normalize(key)
+		}
+		if !ok {
+			if !strings.HasPrefix(key, dec.prefix) {
+				continue
+			}
+			if !dec.lossy {
+				return ErrorLineParsing{count}
+			}
+			dec.warnings = append(dec.warnings, ErrorLineParsing{count})
+			continue
+		}
+		value := l.Value
+		ciphertext, decrypt := encryptedValue(value)
+		if decrypt = decrypt && dec.decrypt != nil; decrypt {
+			var err error
+			if value, err = dec.decrypt(key, ciphertext); err != nil {
+				return ErrorValueParsing{key, err}
+			}
+		}
+		if dec.expand {
+			if l.Quote != '\'' && !decrypt && dec.dialect != DialectShell {
+				var err error
+				if value, err = exp.expand(key, value, l.Literal); err != nil {
+					return err
+				}
+			}
+			earlier[key] = value
+		}
+		if !strings.HasPrefix(key, dec.prefix) {
+			continue
+		}
+		if dec.stripPrefix {
+			key = strings.TrimPrefix(key, dec.prefix)
+		}
+		assigned, err := assign(v, key, value, dec.dialect == DialectStrict)
+		if err != nil {
+			return err
+		}
+		if assigned && dec.set != nil {
+			dec.set(key, value)
+		}
+	}
+	if err := lr.Err(); err != nil {
+		return err
+	}
+	if err := profiles.end(); err != nil {
+		return err
+	}
+	if dec.envOverride {
+		return decodeEnviron(environment(dec.env), v, dec.prefix, dec.stripPrefix, dec.set)
+	}
+	return nil
+}
+
+// parseLine parses a line of input in the dialect selected with SetDialect.
+func (dec *Decoder) parseLine(s string) (*line, bool) {
+	if dec.isComment(strings.TrimSpace(s)) {
+		return nil, true
+	}
+	switch dec.dialect {
+	case DialectStrict:
+		return parseLineStrict(s)
+	case DialectShell:
+		return parseLineShell(s)
+	}
+	return parseLine(s)
+}
+
+// isComment reports whether the trimmed line s is a comment, either starting
+// with '#' or one of the characters set with SetCommentChars.
+func (dec *Decoder) isComment(s string) bool {
+	if s == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '#' || strings.ContainsRune(dec.comments, r)
+}
+
+// lineReader returns the reader for the input of the decoder. The reader is
+// kept, so Decode continues after the lines returned by Token.
+func (dec *Decoder) lineReader() *lineReader {
+	if dec.lr == nil {
+		if dec.borrow {
+			dec.lr = newBorrowingLineReader(dec.data)
+		} else {
+			dec.lr = newLineReader(dec.r)
+		}
+	}
+	dec.lr.legacy = dec.legacy
+	return dec.lr
+}
+
+// targetType returns the struct type that v points to, possibly through
+// multiple pointers. It returns a ErrorInvalidTarget when v is not a non-nil
+// pointer and a ErrorUnsupportedType when it does not point to a struct.
+func targetType(v interface{}) (reflect.Type, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, ErrorInvalidTarget{reflect.TypeOf(v)}
+	}
+	t := rv.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, ErrorUnsupportedType{t.Kind()}
+	}
+	return t, nil
+}
+
+// checkTarget returns an error when the values of v can not be decoded, so it
+// is reported before any input is read, even when there is none.
+func checkTarget(v interface{}) error {
+	t, err := targetType(v)
+	if err != nil {
+		return err
+	}
+	return cachedStruct(t).checkDecode()
+}
+
+// targetStruct returns the struct value pointed to by v. When v points to a
+// pointer to a struct, nil pointers are allocated on the way. It returns the
+// errors of targetType.
+func targetStruct(v interface{}) (reflect.Value, error) {
+	if _, err := targetType(v); err != nil {
+		return reflect.Value{}, err
+	}
+	rv := reflect.ValueOf(v).Elem()
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	return rv, nil
+}
+
+// assign stores the value in all fields of the struct pointed to by v that
+// map to the variable key. It reports whether any field was assigned.
+func assign(v interface{}, key, value string, strict bool) (assigned bool, err error) {
+	rv, err := targetStruct(v)
+	if err != nil {
+		return false, err
+	}
+	si := cachedStruct(rv.Type())
+	if si.err != nil {
+		return false, si.err
+	}
+	return si.assign(rv, key, value, strict)
+}
+
+// assign stores the value in all fields of the struct value rv that map to
+// the variable key. It reports whether any field was assigned.
+func (si *structInfo) assign(rv reflect.Value, key, value string, strict bool) (assigned bool, err error) {
+	for _, i := range si.byName[key] {
+		f := si.fields[i]
+		if f.Opts.OmitEmpty && value == "" {
+			continue
+		}
+		if err := setValue(fieldByIndexAlloc(rv, f.Index), f.Opts, key, value, strict); err != nil {
+			return assigned, err
+		}
+		assigned = true
+	}
+	for _, i := range si.maps {
+		f := si.fields[i]
+		mapKey, ok := f.match(key)
+		if !ok || (f.Opts.OmitEmpty && value == "") {
+			continue
+		}
+		if err := setMapEntry(fieldByIndexAlloc(rv, f.Index), f.Opts, key, mapKey, value, strict); err != nil {
+			return assigned, err
+		}
+		assigned = true
+	}
+	return assigned, nil
+}
@@ -0,0 +1,399 @@
+package envfile
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var decoderPrefixCases = []struct {
+	Name   string
+	Prefix string
+	Strip  bool
+	Input  string
+	Output interface{}
+	Error  error
+}{
+	{
+		Name:   "unrelated variables are ignored",
+		Prefix: "MYAPP_",
+		Input: `PATH=/usr/bin
+MYAPP_HOST=localhost
+HOST=ignored
+MYAPP_PORT=8080
+`,
+		Output: struct {
+			Host string `env:"MYAPP_HOST"`
+			Port string `env:"MYAPP_PORT"`
+			Path string `env:"PATH"`
+		}{
+			Host: "localhost",
+			Port: "8080",
+		},
+	},
+	{
+		Name:   "unparsable unrelated line is ignored",
+		Prefix: "MYAPP_",
+		Input:  "some garbage\nMYAPP_HOST=localhost\n",
+		Output: struct {
+			Host string `env:"MYAPP_HOST"`
+		}{
+			Host: "localhost",
+		},
+	},
+	{
+		Name:   "unparsable line with prefix",
+		Prefix: "MYAPP_",
+		Input:  "MYAPP_HOST\n",
+		Output: struct {
+			Host string `env:"MYAPP_HOST"`
+		}{},
+		Error: ErrorLineParsing{1},
+	},
+	{
+		Name:   "strip prefix",
+		Prefix: "MYAPP_",
+		Strip:  true,
+		Input: `HOST=ignored
+MYAPP_HOST=localhost
+MYAPP_PORT=8080
+`,
+		Output: struct {
+			Host string
+			Port string
+		}{
+			Host: "localhost",
+			Port: "8080",
+		},
+	},
+	{
+		Name:   "strip other prefix",
+		Prefix: "OTHER_",
+		Strip:  true,
+		Input: `MYAPP_HOST=localhost
+OTHER_HOST=otherhost
+`,
+		Output: struct {
+			Host string
+		}{
+			Host: "otherhost",
+		},
+	},
+}
+
+func TestDecoderPrefix(t *testing.T) {
+	for _, c := range decoderPrefixCases {
+		var got = reflect.New(reflect.TypeOf(c.Output))
+		dec := NewDecoder(strings.NewReader(c.Input))
+		dec.SetPrefix(c.Prefix)
+		dec.SetStripPrefix(c.Strip)
+		err := dec.Decode(got.Interface())
+		if err != c.Error {
+			t.Errorf("[%s] error did not match, wanted error: %v, got %v", c.Name, c.Error, err)
+		}
+		if err != nil {
+			continue
+		}
+		gotValue := reflect.Indirect(got).Interface()
+		if !reflect.DeepEqual(c.Output, gotValue) {
+			t.Errorf("[%s] output does not match\nwant:\n%+v,\tgot\n%+v", c.Name, c.Output, gotValue)
+		}
+	}
+}
+
+func TestDecoderEnvOverride(t *testing.T) {
+	os.Setenv("ENVFILE_TEST_HOST", "envhost")
+	defer os.Unsetenv("ENVFILE_TEST_HOST")
+	os.Setenv("ENVFILE_TEST_EMPTY", "")
+	defer os.Unsetenv("ENVFILE_TEST_EMPTY")
+
+	var got struct {
+		Host  string `env:"HOST"`
+		Port  string `env:"PORT"`
+		Empty string `env:"EMPTY,omitempty"`
+	}
+	dec := NewDecoder(strings.NewReader(`ENVFILE_TEST_HOST=filehost
+ENVFILE_TEST_PORT=8080
+ENVFILE_TEST_EMPTY=notempty
+`))
+	dec.SetPrefix("ENVFILE_TEST_")
+	dec.SetStripPrefix(true)
+	dec.SetEnvOverride(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if got.Host != "envhost" {
+		t.Errorf("environment did not override file, want %q, got %q", "envhost", got.Host)
+	}
+	if got.Port != "8080" {
+		t.Errorf("file value was not kept, want %q, got %q", "8080", got.Port)
+	}
+	if got.Empty != "notempty" {
+		t.Errorf("empty environment value overrode omitempty field, got %q", got.Empty)
+	}
+}
+
+func TestDecoderFillEmpty(t *testing.T) {
+	got := struct {
+		Host string
+		Port string
+		User string
+	}{
+		Host: "flaghost",
+	}
+	dec := NewDecoder(strings.NewReader(`HOST=filehost
+PORT=80
+PORT=8080
+`))
+	dec.SetFillEmpty(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if got.Host != "flaghost" {
+		t.Errorf("populated field was overwritten, got %q", got.Host)
+	}
+	if got.Port != "8080" {
+		t.Errorf("empty field was not filled, want %q, got %q", "8080", got.Port)
+	}
+	if got.User != "" {
+		t.Errorf("unset field was modified, got %q", got.User)
+	}
+}
+
+func TestDecoderFillEmptyPointer(t *testing.T) {
+	type tls struct {
+		Cert string
+		Key  string
+	}
+	orig := &tls{Cert: "flag.pem"}
+	got := struct {
+		TLS   *tls
+		Proxy *tls
+	}{TLS: orig}
+	dec := NewDecoder(strings.NewReader("TLS_CERT=file.pem\nTLS_KEY=file.key\nPROXY_CERT=proxy.pem\n"))
+	dec.SetFillEmpty(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if want := (tls{Cert: "flag.pem", Key: "file.key"}); *got.TLS != want {
+		t.Errorf("nested struct did not match, want %+v, got %+v", want, *got.TLS)
+	}
+	if got.Proxy == nil || got.Proxy.Cert != "proxy.pem" {
+		t.Errorf("nil nested struct was not filled, got %+v", got.Proxy)
+	}
+}
+
+func TestDecoderStrict(t *testing.T) {
+	input := "HOST = localhost\n"
+	var got struct {
+		Host string
+	}
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("lenient decode returned an error: %v", err)
+	}
+	if got.Host != "localhost" {
+		t.Errorf("lenient decode did not match, want %q, got %q", "localhost", got.Host)
+	}
+
+	for _, input := range []string{"HOST = localhost\n", "HOST= localhost\n", "=localhost\n"} {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.SetStrict(true)
+		err := dec.Decode(&got)
+		if want := (ErrorLineParsing{1}); err != want {
+			t.Errorf("[%q] error did not match, want: %v, got %v", input, want, err)
+		}
+	}
+
+	dec := NewDecoder(strings.NewReader("export HOST=localhost\nPORT=\n"))
+	dec.SetStrict(true)
+	var ok struct {
+		Host string
+		Port string
+	}
+	if err := dec.Decode(&ok); err != nil {
+		t.Errorf("strict decode returned an error: %v", err)
+	}
+}
+
+func TestDecoderStrictBool(t *testing.T) {
+	type config struct {
+		Debug bool
+		Flags []bool
+	}
+	var got config
+	if err := Unmarshal([]byte("DEBUG=Yes\nFLAGS=on, OFF, enabled, disabled, no, 1\n"), &got); err != nil {
+		t.Fatalf("lenient decode returned an error: %v", err)
+	}
+	if want := (config{true, []bool{true, false, true, false, false, true}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("lenient decode did not match, want %v, got %v", want, got)
+	}
+
+	dec := NewDecoder(strings.NewReader("DEBUG=yes\nFLAGS=\n"))
+	dec.SetStrict(true)
+	err := dec.Decode(&got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "DEBUG" {
+		t.Errorf("error did not match, want: ErrorValueParsing for DEBUG, got %v", err)
+	}
+}
+
+func TestDecoderNormalize(t *testing.T) {
+	var got struct {
+		Host string
+	}
+	if err := Unmarshal([]byte("K\xffY=1\n"), &got); err != (ErrorLineParsing{1}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{1}, err)
+	}
+	dec := NewDecoder(strings.NewReader("host=localhost\n"))
+	dec.SetNormalize(strings.ToUpper)
+	if err := dec.Decode(&got); err != nil || got.Host != "localhost" {
+		t.Errorf("normalized name did not match, got %q, %v", got.Host, err)
+	}
+}
+
+func TestDecoderLegacyCharset(t *testing.T) {
+	input := "CITY=M\xfcnchen\nPRICE=\x805 \x96 \x93cheap\x94\nNAME=Zo\u00eb\n"
+	var got struct {
+		City  string
+		Price string
+		Name  string
+	}
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetLegacyCharset(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if got.City != "München" || got.Price != "€5 – “cheap”" || got.Name != "Zoë" {
+		t.Errorf("transcoded values did not match, got %q, %q, %q", got.City, got.Price, got.Name)
+	}
+
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if got.City != "M\xfcnchen" {
+		t.Errorf("value without transcoding did not match, got %q", got.City)
+	}
+}
+
+func TestDecoderCommentChars(t *testing.T) {
+	input := "; INI style comment\n  ;indented\nHOST=a;b\n# shell comment\n"
+	var got struct {
+		Host string
+	}
+	if err := Unmarshal([]byte(input), &got); err != (ErrorLineParsing{1}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{1}, err)
+	}
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetCommentChars(";")
+	if err := dec.Decode(&got); err != nil || got.Host != "a;b" {
+		t.Errorf("value did not match, got %q, %v", got.Host, err)
+	}
+	dec = NewDecoder(strings.NewReader(input))
+	dec.SetCommentChars(";")
+	tok, err := dec.Token()
+	if want := (Token{Kind: TokenComment, Line: 1, Text: " INI style comment"}); err != nil || tok != want {
+		t.Errorf("token did not match, want: %+v, got %+v, %v", want, tok, err)
+	}
+}
+
+func TestDecoderLossy(t *testing.T) {
+	input := "HOST=localhost\nthis is not an assignment\nPORT='80\nPORT=8080\n"
+	type config struct {
+		Host string
+		Port int
+	}
+	var got config
+	if err := Unmarshal([]byte(input), &got); err != (ErrorLineParsing{2}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{2}, err)
+	}
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetLossy(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("lossy decode returned an error: %v", err)
+	}
+	if want := (config{"localhost", 8080}); got != want {
+		t.Errorf("output did not match, want %+v, got %+v", want, got)
+	}
+	want := []error{ErrorLineParsing{2}, ErrorLineParsing{3}}
+	if !reflect.DeepEqual(dec.Warnings(), want) {
+		t.Errorf("warnings did not match, want %v, got %v", want, dec.Warnings())
+	}
+}
+
+func TestDecoderProfile(t *testing.T) {
+	input := `HOST=localhost
+PORT=8080
+# [profile:production, staging]
+HOST=db.internal
+# [end]
+#[profile:development]
+DEBUG=true
+# [end]
+`
+	type config struct {
+		Host  string
+		Port  int
+		Debug bool `env:",omitempty"`
+	}
+	cases := []struct {
+		Profile string
+		Output  config
+	}{
+		{"production", config{"db.internal", 8080, false}},
+		{"staging", config{"db.internal", 8080, false}},
+		{"development", config{"localhost", 8080, true}},
+		{"test", config{"localhost", 8080, false}},
+		{"", config{"localhost", 8080, false}},
+	}
+	for _, c := range cases {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.SetProfile(c.Profile)
+		var got config
+		if err := dec.Decode(&got); err != nil {
+			t.Errorf("[%s] decode returned an error: %v", c.Profile, err)
+		} else if got != c.Output {
+			t.Errorf("[%s] output did not match, want %+v, got %+v", c.Profile, c.Output, got)
+		}
+	}
+
+	// Without a profile, a block at the end does not override the values
+	// above it.
+	var got config
+	if err := Unmarshal([]byte("HOST=localhost\n# [profile:production]\nHOST=db.internal\nPORT=5432\n# [end]\nPORT=8080\n"), &got); err != nil {
+		t.Errorf("unmarshal returned an error: %v", err)
+	} else if want := (config{"localhost", 8080, false}); got != want {
+		t.Errorf("output without profile did not match, want %+v, got %+v", want, got)
+	}
+
+	for input, want := range map[string]error{
+		"# [profile:a]\n# [profile:b]\n# [end]\n": ErrorLineParsing{2},
+		"HOST=x\n# [end]\n":                       ErrorLineParsing{2},
+		"# [profile:a]\nHOST=x\n":                 ErrorLineParsing{1},
+	} {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.SetProfile("a")
+		var got config
+		if err := dec.Decode(&got); err != want {
+			t.Errorf("[%q] error did not match, want: %v, got %v", input, want, err)
+		}
+	}
+}
+
+func TestUnmarshalContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var got struct {
+		Host string
+	}
+	err := UnmarshalContext(ctx, []byte("HOST=localhost\n"), &got)
+	if err != context.Canceled {
+		t.Errorf("error did not match, want: %v, got %v", context.Canceled, err)
+	}
+	if got.Host != "" {
+		t.Errorf("value was assigned after the context was canceled, got %q", got.Host)
+	}
+	if err := UnmarshalContext(context.Background(), []byte("HOST=localhost\n"), &got); err != nil {
+		t.Errorf("unmarshal with background context returned an error: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package envfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A Diagnostic is an error or lint finding located in a file, in a form that
+// can be serialized for tools with encoding/json:
+//
+//	{"rule":"syntax","severity":"error","message":"error parsing line 2","file":".env","line":2,"column":1,"endColumn":15}
+//
+// Use ErrorDiagnostics for errors returned by this package, and
+// Finding.Diagnostic for the findings of Lint.
+type Diagnostic struct {
+	// Rule identifies the kind of problem, like the rule IDs of Lint and
+	// the rules listed for ErrorDiagnostics. It is empty for other errors.
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+	// Line and Column are the 1-based position of the problem and
+	// EndColumn the column just after it, all 0 when the position is not
+	// known. Columns count bytes.
+	Line      int `json:"line,omitempty"`
+	Column    int `json:"column,omitempty"`
+	EndColumn int `json:"endColumn,omitempty"`
+
+	// text is the line the problem is on, without line ending.
+	text string
+}
+
+// Rules of the diagnostics for errors, in addition to RuleSyntax.
+const (
+	RuleBinary         = "binary"
+	RuleUnknownKey     = "unknown-key"
+	RuleMissingKey     = "missing-key"
+	RuleValue          = "value"
+	RuleExpansion      = "expansion"
+	RulePolicy         = "policy"
+	RuleInclude        = "include"
+	RuleInvalidKey     = "invalid-key"
+	RuleDuplicateField = "duplicate-field"
+)
+
+// Diagnostic returns the finding as a Diagnostic for the file name.
+func (f Finding) Diagnostic(name string) Diagnostic {
+	d := Diagnostic{Rule: f.Rule, Severity: f.Severity, Message: f.Message, File: name,
+		Line: f.Line, Column: f.Column}
+	if f.EndLine == f.Line {
+		d.EndColumn = f.EndColumn
+	}
+	return d
+}
+
+// ErrorDiagnostics returns the diagnostics for err, which was returned for
+// the input data of the file name, with SeverityError. Like FormatError, the
+// position is derived from the line numbers and variable names in the
+// errors, every error of a ErrorList is a diagnostic and a ErrorFile replaces
+// name by its path.
+//
+// The rule of the diagnostic is RuleSyntax for a ErrorLineParsing and
+// RuleBinary, RuleUnknownKey, RuleMissingKey, RuleValue, RuleExpansion,
+// RulePolicy, RuleInclude, RuleInvalidKey and RuleDuplicateField for the other
+// error types of this package, in that order. The warnings of a lossy
+// Decoder can be converted by passing them as a ErrorList and changing the
+// severity.
+func ErrorDiagnostics(name string, data []byte, err error) []Diagnostic {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case ErrorList:
+		var ds []Diagnostic
+		for _, err := range e {
+			ds = append(ds, ErrorDiagnostics(name, data, err)...)
+		}
+		return ds
+	case ErrorFile:
+		return ErrorDiagnostics(e.Path, data, e.Err)
+	}
+	d := Diagnostic{Severity: SeverityError, Message: err.Error(), File: name}
+	switch e := err.(type) {
+	case ErrorLineParsing:
+		d.Rule = RuleSyntax
+		d.locateLine(data, e.LineNumber)
+	case ErrorBinaryInput:
+		// The line is not shown, it would print the binary data.
+		d.Rule = RuleBinary
+		d.Line = e.LineNumber
+		d.Column = int(e.Offset-lineOffset(data, e.LineNumber)) + 1
+		d.EndColumn = d.Column + 1
+	case ErrorUnknownKey:
+		d.Rule = RuleUnknownKey
+		d.locateKey(data, e.LineNumber, e.Key, false)
+	case ErrorMissingKey:
+		d.Rule = RuleMissingKey
+	case ErrorValueParsing:
+		d.Rule = RuleValue
+		d.locateKey(data, 0, e.Key, true)
+	case ErrorExpansion:
+		d.Rule = RuleExpansion
+		d.locateKey(data, 0, e.Key, true)
+	case ErrorPolicy:
+		d.Rule = RulePolicy
+		d.locateKey(data, e.Line, e.Key, false)
+	case ErrorInclude:
+		d.Rule = RuleInclude
+		d.File, d.Line = e.Path, e.Line
+	case ErrorInvalidKey:
+		d.Rule = RuleInvalidKey
+	case ErrorDuplicateKey:
+		d.Rule = RuleDuplicateField
+	}
+	return []Diagnostic{d}
+}
+
+// FormatError renders err as a diagnostic for people, with the name of the
+// file, the position of the problem, the line it is on and a caret under the
+// problem:
+//
+//	.env:3:1: error parsing line 3
+//	  3 | HOST localhost
+//	    | ^^^^^^^^^^^^^^
+//
+// The position is derived from the line numbers and variable names in the
+// errors returned by this package, data is the input that caused err. The
+// errors of a ErrorList are rendered one after another, a ErrorFile replaces
+// name by its path. Errors that can not be located are rendered as "name:
+// message".
+func FormatError(name string, data []byte, err error) string {
+	var b strings.Builder
+	for i, d := range ErrorDiagnostics(name, data, err) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		d.write(&b)
+	}
+	return b.String()
+}
+
+// locateLine locates the diagnostic at the text on line n.
+func (d *Diagnostic) locateLine(data []byte, n int) {
+	text, ok := lineText(data, n)
+	if !ok {
+		return
+	}
+	trimmed := strings.TrimLeft(text, " \t")
+	d.Line, d.text = n, text
+	d.Column = len(text) - len(trimmed) + 1
+	d.EndColumn = d.Column + len(strings.TrimRight(trimmed, " \t"))
+}
+
+// locateKey locates the diagnostic at the name, or the value when value is
+// set, of the assignment of key on line n. When n is 0 the last assignment of
+// key is used, which is the one that set the value.
+func (d *Diagnostic) locateKey(data []byte, n int, key string, value bool) {
+	if n == 0 {
+		positions, _ := Positions(data)
+		for _, p := range positions {
+			if p.Key == key {
+				n = p.Line
+			}
+		}
+	}
+	text, ok := lineText(data, n)
+	if !ok {
+		return
+	}
+	l := newLintLine(n, text)
+	if !l.Assign || l.Key != key {
+		d.locateLine(data, n)
+		return
+	}
+	d.Line, d.text = n, text
+	d.Column, d.EndColumn = l.KeyStart+1, l.KeyEnd+1
+	if value {
+		d.Column, d.EndColumn = l.ValueStart+1, l.ValueEnd+1
+	}
+}
+
+// write writes the rendered diagnostic to b.
+func (d Diagnostic) write(b *strings.Builder) {
+	switch {
+	case d.File == "" && d.Line == 0:
+		fmt.Fprintf(b, "%s\n", d.Message)
+		return
+	case d.Line == 0:
+		fmt.Fprintf(b, "%s: %s\n", d.File, d.Message)
+		return
+	case d.File == "":
+		fmt.Fprintf(b, "%d:%d: %s\n", d.Line, d.Column, d.Message)
+	default:
+		fmt.Fprintf(b, "%s:%d:%d: %s\n", d.File, d.Line, d.Column, d.Message)
+	}
+	if d.text == "" {
+		return
+	}
+	number := fmt.Sprint(d.Line)
+	fmt.Fprintf(b, "  %s | %s\n", number, d.text)
+	fmt.Fprintf(b, "  %s | ", strings.Repeat(" ", len(number)))
+	// Keep the tabs before the column, so the caret lines up.
+	for _, r := range d.text[:d.Column-1] {
+		if r == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	width := utf8.RuneCountInString(d.text[d.Column-1 : d.EndColumn-1])
+	b.WriteString(strings.Repeat("^", max(width, 1)))
+	b.WriteByte('\n')
+}
+
+// lineText returns line n of data without line ending.
+func lineText(data []byte, n int) (string, bool) {
+	if n < 1 {
+		return "", false
+	}
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		if lr.Line() == n {
+			return lr.Text(), true
+		}
+	}
+	return "", false
+}
+
+// lineOffset returns the byte offset of the start of line n of data.
+func lineOffset(data []byte, n int) int64 {
+	offset := int64(0)
+	for i := 1; i < n; i++ {
+		j := bytes.IndexByte(data[offset:], '\n')
+		if j < 0 {
+			break
+		}
+		offset += int64(j) + 1
+	}
+	return offset
+}
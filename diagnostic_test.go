@@ -0,0 +1,89 @@
+package envfile
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFormatError(t *testing.T) {
+	data := []byte("# Config.\nHOST localhost\n\tPORT=http\nNAME=Zoë\n")
+	cases := []struct {
+		Name   string
+		File   string
+		Err    error
+		Output string
+	}{
+		{
+			Name: "line parsing",
+			File: ".env",
+			Err:  ErrorLineParsing{2},
+			Output: ".env:2:1: error parsing line 2\n" +
+				"  2 | HOST localhost\n" +
+				"    | ^^^^^^^^^^^^^^\n",
+		},
+		{
+			Name: "value parsing",
+			File: ".env",
+			Err:  ErrorValueParsing{"PORT", errors.New("invalid syntax")},
+			Output: ".env:3:7: error parsing value of PORT: invalid syntax\n" +
+				"  3 | \tPORT=http\n" +
+				"    | \t     ^^^^\n",
+		},
+		{
+			Name: "file and list",
+			Err: ErrorFile{"app.env", ErrorList{
+				ErrorPolicy{4, "NAME", "is forbidden"},
+				ErrorMissingKey{"TOKEN"},
+			}},
+			Output: "app.env:4:1: line 4: variable \"NAME\" is forbidden\n" +
+				"  4 | NAME=Zoë\n" +
+				"    | ^^^^\n" +
+				"\n" +
+				"app.env: missing variable \"TOKEN\"\n",
+		},
+		{
+			Name:   "binary input",
+			File:   "bin",
+			Err:    ErrorBinaryInput{13, 2},
+			Output: "bin:2:4: input is binary: control character at offset 13 on line 2\n",
+		},
+		{
+			Name:   "unknown position",
+			Err:    errors.New("failed"),
+			Output: "failed\n",
+		},
+	}
+	for _, c := range cases {
+		if got := FormatError(c.File, data, c.Err); got != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%s\ngot:\n%s", c.Name, c.Output, got)
+		}
+	}
+}
+
+func TestErrorDiagnostics(t *testing.T) {
+	data := []byte("HOST=localhost\nPORT=http\n")
+	err := ErrorList{ErrorValueParsing{"PORT", errors.New("invalid syntax")}, ErrorMissingKey{"TOKEN"}}
+	got, jerr := json.Marshal(ErrorDiagnostics(".env", data, err))
+	if jerr != nil {
+		t.Fatalf("marshal returned an error: %v", jerr)
+	}
+	want := `[{"rule":"value","severity":"error","message":"error parsing value of PORT: invalid syntax","file":".env","line":2,"column":6,"endColumn":10},` +
+		`{"rule":"missing-key","severity":"error","message":"missing variable \"TOKEN\"","file":".env"}]`
+	if string(got) != want {
+		t.Errorf("output did not match\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	findings, _ := Lint([]byte("a=1\n"), nil)
+	d := findings[0].Diagnostic(".env")
+	wantD := Diagnostic{Rule: RuleKeyCase, Severity: SeverityInfo, Message: `variable name "a" is not upper case`,
+		File: ".env", Line: 1, Column: 1, EndColumn: 2}
+	if !reflect.DeepEqual(d, wantD) {
+		t.Errorf("finding diagnostic did not match\nwant:\n%+v\ngot:\n%+v", wantD, d)
+	}
+	var back []Diagnostic
+	if err := json.Unmarshal([]byte(`[{"severity":"warning"}]`), &back); err != nil || back[0].Severity != SeverityWarning {
+		t.Errorf("severity did not match, want: %v, got %v, %v", SeverityWarning, back, err)
+	}
+}
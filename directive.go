@@ -0,0 +1,100 @@
+package envfile
+
+import (
+	"bytes"
+	"strings"
+)
+
+// directivePrefix starts a directive comment. Like Go directives there is no
+// space after the comment character.
+const directivePrefix = "#envfile:"
+
+// Names of the directives that are understood by Document.Validate.
+const (
+	// DirectiveRequired lists variables that must be set, as in
+	// "#envfile:required HOST PORT".
+	DirectiveRequired = "required"
+	// DirectiveDisableLine excludes the variable below it from checks.
+	DirectiveDisableLine = "disable-line"
+)
+
+// A Directive is a comment line of the form "#envfile:name args" that
+// configures linters and validators from inside the file, for example:
+//
+//	#envfile:required DATABASE_URL
+//	#envfile:disable-line
+//	LEGACY_setting=1
+//
+// Directives are ordinary comments to other implementations.
+type Directive struct {
+	// Line is the 1-based line number of the directive.
+	Line int
+	Name string
+	// Args are the whitespace-separated arguments after the name.
+	Args []string
+	// Key is the variable set on the first line below the directive when
+	// only comment lines are in between, and empty otherwise. It is the
+	// variable that line scoped directives like "disable-line" apply to,
+	// KeyLine is the line number of its assignment.
+	Key     string
+	KeyLine int
+}
+
+// ParseDirective parses a single line as a directive. It reports false when
+// the line is not a directive comment. The line numbers and Key of the
+// returned Directive are not set.
+func ParseDirective(s string) (Directive, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, directivePrefix) {
+		return Directive{}, false
+	}
+	fields := strings.Fields(s[len(directivePrefix):])
+	if len(fields) == 0 {
+		return Directive{}, false
+	}
+	return Directive{Name: fields[0], Args: fields[1:]}, true
+}
+
+// Directives returns the directive comments in EnvironmentFile data in order
+// of their lines. Lines that can not be parsed are skipped.
+func Directives(data []byte) []Directive {
+	var lines []docLine
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		dl := docLine{raw: lr.Text()}
+		if l, ok := parseLine(dl.raw); ok && l != nil {
+			dl.assign, dl.key = true, l.Key
+		}
+		lines = append(lines, dl)
+	}
+	return directives(lines)
+}
+
+// Directives returns the directive comments of the document in order of
+// their lines.
+func (d *Document) Directives() []Directive {
+	return directives(d.lines)
+}
+
+// directives returns the directives in lines.
+func directives(lines []docLine) []Directive {
+	var ds []Directive
+	for i, l := range lines {
+		dir, ok := ParseDirective(l.raw)
+		if !ok {
+			continue
+		}
+		dir.Line = i + 1
+		for j := i + 1; j < len(lines); j++ {
+			if lines[j].assign {
+				dir.Key, dir.KeyLine = lines[j].key, j+1
+				break
+			}
+			if !isCommentLine(lines[j].raw) {
+				break
+			}
+		}
+		ds = append(ds, dir)
+	}
+	return ds
+}
@@ -0,0 +1,28 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDirectives(t *testing.T) {
+	input := "#envfile:required HOST PORT\n\n#envfile:disable-line\n# Comment.\nHOST=localhost\n# envfile:ignored\n#envfile:\n#envfile:disable-line\n\nPORT=80\n"
+	want := []Directive{
+		{Line: 1, Name: "required", Args: []string{"HOST", "PORT"}},
+		{Line: 3, Name: "disable-line", Args: []string{}, Key: "HOST", KeyLine: 5},
+		{Line: 8, Name: "disable-line", Args: []string{}},
+	}
+	if got := Directives([]byte(input)); !reflect.DeepEqual(got, want) {
+		t.Errorf("directives did not match\nwant:\n%+v\ngot:\n%+v", want, got)
+	}
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if got := d.Directives(); !reflect.DeepEqual(got, want) {
+		t.Errorf("document directives did not match\nwant:\n%+v\ngot:\n%+v", want, got)
+	}
+	if _, ok := ParseDirective("HOST=localhost"); ok {
+		t.Errorf("assignment was parsed as a directive")
+	}
+}
@@ -0,0 +1,77 @@
+package envfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DiscoverPathEnv is the environment variable that overrides the search of
+// Discover with an explicit path.
+const DiscoverPathEnv = "ENVFILE_PATH"
+
+// ErrorNotFound is returned by Discover when none of the searched locations
+// contains the file.
+type ErrorNotFound struct {
+	Name     string
+	Searched []string
+}
+
+// Error implements the error interface.
+func (e ErrorNotFound) Error() string {
+	return fmt.Sprintf("%s not found in %d locations", e.Name, len(e.Searched))
+}
+
+// Discover searches the standard locations for the EnvironmentFile called
+// name, for example ".env", and returns the path of the first one that
+// exists. The locations are searched in the following order:
+//
+//  1. the path in the environment variable ENVFILE_PATH, when set
+//  2. the working directory and its parents, up to and including the first
+//     directory that contains a .git entry or is the home directory
+//  3. the directory app in the user configuration directory, for example
+//     $XDG_CONFIG_HOME/app on Unix, unless app is empty
+//
+// When ENVFILE_PATH is set but the file does not exist a ErrorFile is
+// returned without searching further. When the file is not found in any
+// location a ErrorNotFound listing the searched paths is returned.
+func Discover(app, name string) (string, error) {
+	if path, ok := os.LookupEnv(DiscoverPathEnv); ok && path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", ErrorFile{path, err}
+		}
+		return path, nil
+	}
+	var searched []string
+	found := func(path string) bool {
+		searched = append(searched, path)
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir()
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		if path := filepath.Join(dir, name); found(path) {
+			return path, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || dir == home {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if app != "" {
+		if config, err := os.UserConfigDir(); err == nil {
+			if path := filepath.Join(config, app, name); found(path) {
+				return path, nil
+			}
+		}
+	}
+	return "", ErrorNotFound{name, searched}
+}
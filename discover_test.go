@@ -0,0 +1,66 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "cmd", "app")
+	config := filepath.Join(root, "config")
+	for _, dir := range []string{filepath.Join(repo, ".git"), sub, filepath.Join(config, "myapp")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(path string) {
+		if err := os.WriteFile(path, []byte("A=1\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file above the boundary must not be found.
+	write(filepath.Join(root, ".env"))
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("HOME", root)
+	t.Setenv(DiscoverPathEnv, "")
+
+	_, err := Discover("myapp", ".env")
+	want := ErrorNotFound{".env", []string{
+		filepath.Join(sub, ".env"),
+		filepath.Join(repo, "cmd", ".env"),
+		filepath.Join(repo, ".env"),
+		filepath.Join(config, "myapp", ".env"),
+	}}
+	if !reflect.DeepEqual(want, err) {
+		t.Errorf("error did not match\nwant:\n%v\ngot:\n%v", want, err)
+	}
+
+	write(filepath.Join(config, "myapp", ".env"))
+	if got, err := Discover("myapp", ".env"); err != nil || got != filepath.Join(config, "myapp", ".env") {
+		t.Errorf("config directory file was not found, got %q, %v", got, err)
+	}
+	write(filepath.Join(repo, ".env"))
+	if got, err := Discover("myapp", ".env"); err != nil || got != filepath.Join(repo, ".env") {
+		t.Errorf("parent directory file was not found, got %q, %v", got, err)
+	}
+
+	override := filepath.Join(root, "override.env")
+	t.Setenv(DiscoverPathEnv, override)
+	if _, err := Discover("myapp", ".env"); err == nil {
+		t.Errorf("missing override file did not return an error")
+	}
+	write(override)
+	if got, err := Discover("myapp", ".env"); err != nil || got != override {
+		t.Errorf("override file was not chosen, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,819 @@
+package envfile
+
+import (
+	"bytes"
+	"errors"
+	"iter"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/basvdlei/envfile/internal/atomicfile"
+	"github.com/basvdlei/envfile/internal/tag"
+)
+
+// A Document is EnvironmentFile data that keeps its layout. Comments, blank
+// lines, the order of the variables and variables that are not modified are
+// written back unchanged, so programs can edit files maintained by people.
+type Document struct {
+	lines []docLine
+	// newline is the line ending used by the document.
+	newline string
+	// noEOL is set when the last line has no line ending.
+	noEOL bool
+	// sections is the pattern set with SetSectionPattern.
+	sections *regexp.Regexp
+}
+
+// docLine is a single line of a Document.
+type docLine struct {
+	// raw is the text of the line without line ending.
+	raw string
+	// assign is set when the line is a variable assignment.
+	assign bool
+	key    string
+	value  string
+	// quote is the quote character the value was enclosed in, or 0 for
+	// bare values.
+	quote byte
+	// literal holds the offsets of the escaped '$' characters in value.
+	literal []int
+}
+
+// ParseDocument parses EnvironmentFile data into a Document. It returns a
+// ErrorLineParsing when a line is not an empty line, a comment or a valid
+// assignment.
+func ParseDocument(data []byte) (*Document, error) {
+	d := &Document{newline: "\n"}
+	if bytes.Contains(data, []byte("\r\n")) {
+		d.newline = "\r\n"
+	}
+	if len(data) == 0 {
+		return d, nil
+	}
+	text := string(data)
+	if strings.HasSuffix(text, "\n") {
+		text = text[:len(text)-1]
+	} else {
+		d.noEOL = true
+	}
+	for i, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		dl := docLine{raw: raw}
+		l, ok := parseLine(raw)
+		if !ok {
+			return nil, ErrorLineParsing{i + 1}
+		}
+		if l != nil {
+			dl.assign = true
+			dl.key = l.Key
+			dl.value = l.Value
+			dl.quote = l.Quote
+			dl.literal = l.Literal
+		}
+		d.lines = append(d.lines, dl)
+	}
+	return d, nil
+}
+
+// clone returns a copy of the document that can be modified independently.
+func (d *Document) clone() *Document {
+	c := *d
+	c.lines = append([]docLine(nil), d.lines...)
+	return &c
+}
+
+// lookup returns the index of the line that sets key. When a variable is set
+// multiple times the last line is returned, matching the decoding functions.
+func (d *Document) lookup(key string) int {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if d.lines[i].assign && d.lines[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value of the variable key and whether it is set.
+func (d *Document) Get(key string) (string, bool) {
+	if i := d.lookup(key); i >= 0 {
+		return d.lines[i].value, true
+	}
+	return "", false
+}
+
+// Keys returns the names of the variables in the order they first appear.
+func (d *Document) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range d.lines {
+		if l.assign && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// An Entry is a variable assignment in a Document.
+type Entry struct {
+	Value string
+	// Line is the number of the line in the current content of the
+	// document, starting at 1.
+	Line int
+	// Comment is the comment block above the assignment, like Comment
+	// returns it.
+	Comment string
+	// Quote is the quote character the value is enclosed in, or 0 for
+	// bare values.
+	Quote byte
+	// Export is set when the assignment starts with "export".
+	Export bool
+}
+
+// All returns an iterator over the variable assignments of the document in
+// file order, yielding the name of the variable and its Entry. A variable
+// that is set on multiple lines is yielded for every line. The document must
+// not be modified during iteration.
+func (d *Document) All() iter.Seq2[string, Entry] {
+	return func(yield func(string, Entry) bool) {
+		for i, l := range d.lines {
+			if !l.assign {
+				continue
+			}
+			if !yield(l.key, d.entry(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Unset removes all lines that set the variable key, together with the
+// comment blocks directly above them.
+func (d *Document) Unset(key string) {
+	for i := d.lookup(key); i >= 0; i = d.lookup(key) {
+		d.replaceLines(d.commentStart(i), i+1, nil)
+	}
+}
+
+// Sort reorders the variables of the document so that less(a, b) reports
+// whether variable a comes before variable b. A nil less sorts them by name.
+// Variables that are equal according to less keep their relative order.
+//
+// Every variable moves together with the comment block directly above it.
+// The part of the document before the first section and every section are
+// sorted separately, their variables are written consecutively after the
+// other lines of that part, like the header, the section comment line and
+// comments that are separated from variables by a blank line. Blank lines
+// between variables are removed, blank lines at the end of a part are kept.
+func (d *Document) Sort(less func(a, b string) bool) {
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	var lines []docLine
+	start := 0
+	for i := 1; i <= len(d.lines); i++ {
+		if i == len(d.lines) {
+			lines = append(lines, d.sortPart(start, i, less)...)
+		} else if _, ok := d.sectionName(i); ok {
+			lines = append(lines, d.sortPart(start, i, less)...)
+			start = i
+		}
+	}
+	d.lines = lines
+}
+
+// sortPart returns the lines from start up to end with the variables sorted
+// as described for Sort.
+func (d *Document) sortPart(start, end int, less func(a, b string) bool) []docLine {
+	tail := end
+	for tail > start && isBlankLine(d.lines[tail-1].raw) {
+		tail--
+	}
+	var other, pending []docLine
+	var blocks [][]docLine
+	for i := start; i < tail; i++ {
+		l := d.lines[i]
+		switch {
+		case l.assign:
+			blocks = append(blocks, append(pending, l))
+			pending = nil
+		case d.isComment(i):
+			pending = append(pending, l)
+		case isBlankLine(l.raw) && len(blocks) > 0:
+			if len(pending) > 0 {
+				other = append(append(other, pending...), docLine{})
+				pending = nil
+			}
+		default:
+			other = append(append(other, pending...), l)
+			pending = nil
+		}
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return less(blocks[i][len(blocks[i])-1].key, blocks[j][len(blocks[j])-1].key)
+	})
+	lines := other
+	for _, b := range blocks {
+		lines = append(lines, b...)
+	}
+	lines = append(lines, pending...)
+	return append(lines, d.lines[tail:end]...)
+}
+
+// entry returns the Entry of the assignment on line i.
+func (d *Document) entry(i int) Entry {
+	l := d.lines[i]
+	_, export := cutExport(strings.TrimSpace(l.raw))
+	return Entry{
+		Value:   l.value,
+		Line:    i + 1,
+		Comment: commentText(d.lines[d.commentStart(i):i]),
+		Quote:   l.quote,
+		Export:  export,
+	}
+}
+
+// Set sets the value of the variable key. The line that sets the variable is
+// replaced, keeping a leading "export" keyword and the quoting style of the
+// value when the new value can be written in it, or a new line is appended
+// when the variable is not set yet. Other values are quoted as needed.
+// Nothing is changed when the variable already has the value.
+//
+// It returns a ErrorInvalidKey and leaves the document unchanged when key is
+// not a valid variable name.
+func (d *Document) Set(key, value string) error {
+	if err := tag.CheckName(key); err != nil {
+		return ErrorInvalidKey{key, err}
+	}
+	i := d.lookup(key)
+	if i >= 0 && d.lines[i].value == value {
+		return nil
+	}
+	if i < 0 {
+		d.lines = append(d.lines, newDocLine(key, value))
+		return nil
+	}
+	d.replaceValue(i, value)
+	return nil
+}
+
+// replaceValue replaces the assignment on line i by one setting value, in the
+// quoting style of the original line when possible.
+func (d *Document) replaceValue(i int, value string) {
+	l := d.lines[i]
+	v, q := quoteStyle(value, l.quote)
+	raw := l.key + "=" + v
+	if _, ok := cutExport(strings.TrimSpace(l.raw)); ok {
+		raw = "export " + raw
+	}
+	d.lines[i] = docLine{raw: raw, assign: true, key: l.key, value: value, quote: q}
+}
+
+// Redact replaces the values of the variables for which match returns true by
+// Redacted, on all lines that set them, or the values of all variables when
+// match is nil. Empty values are kept, so it stays visible which variables are
+// not set. Comments and the layout are kept, so the redacted document can be
+// shared in place of the original, for example in bug reports.
+func (d *Document) Redact(match func(key string) bool) {
+	for i, l := range d.lines {
+		if l.assign && l.value != "" && (match == nil || match(l.key)) {
+			d.replaceValue(i, Redacted)
+		}
+	}
+}
+
+// newDocLine returns the line for a new variable assignment.
+func newDocLine(key, value string) docLine {
+	v, q := quoteStyle(value, 0)
+	return docLine{raw: key + "=" + v, assign: true, key: key, value: value, quote: q}
+}
+
+// quoteStyle returns value written in the style of quote character q, or 0
+// for bare values, and the quote character that was used. Values that can
+// not be written in the style are enclosed in double quotes when needed.
+func quoteStyle(value string, q byte) (string, byte) {
+	switch {
+	case q == '"':
+		return quote(value), '"'
+	case q == '\'' && !strings.ContainsAny(value, "'\n\r"):
+		return "'" + value + "'", '\''
+	case needsQuoting(value):
+		return quote(value), '"'
+	}
+	return value, 0
+}
+
+// Rename renames the variable old to new on all lines that set it. The
+// references to old in the values of other variables, like "${OLD}" and
+// "$OLD", are renamed as well, except in single-quoted values which are not
+// expanded. Nothing is changed when old is not set.
+//
+// It returns a ErrorInvalidKey when new is not a valid variable name, is
+// already set, or can not be referenced while references to old exist.
+func (d *Document) Rename(old, new string) error {
+	if old == new || d.lookup(old) < 0 {
+		return nil
+	}
+	if err := tag.CheckName(new); err != nil {
+		return ErrorInvalidKey{new, err}
+	}
+	if d.lookup(new) >= 0 {
+		return ErrorInvalidKey{new, errors.New("variable is already set")}
+	}
+	raws := make([]string, len(d.lines))
+	for i, l := range d.lines {
+		raws[i] = l.raw
+		if !l.assign {
+			continue
+		}
+		start := len(l.raw) - len(strings.TrimLeft(l.raw, " \t"))
+		rest, _ := cutExport(l.raw[start:])
+		start = len(l.raw) - len(rest)
+		eq := start + strings.IndexByte(rest, '=')
+		key := l.raw[:eq]
+		if l.key == old {
+			key = l.raw[:start] + new + l.raw[start+len(old):eq]
+		}
+		value := l.raw[eq:]
+		if l.quote != '\'' {
+			v, ok := renameReferences(value, old, new, l.quote == '"')
+			if !ok {
+				return ErrorInvalidKey{new, errors.New("name can not be referenced")}
+			}
+			value = v
+		}
+		raws[i] = key + value
+	}
+	for i, raw := range raws {
+		if raw == d.lines[i].raw {
+			continue
+		}
+		l, _ := parseLine(raw)
+		d.lines[i] = docLine{raw: raw, assign: true, key: l.Key, value: l.Value, quote: l.Quote, literal: l.Literal}
+	}
+	return nil
+}
+
+// renameReferences replaces the references to variable old in s by
+// references to new. When escapes is set, s is a double-quoted value in which
+// a backslash escapes the next character, so "\$OLD" is not a reference. It
+// reports false when s references old but new can not be referenced.
+func renameReferences(s, old, new string, escapes bool) (string, bool) {
+	if !strings.Contains(s, "$") {
+		return s, true
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if escapes && s[i] == '\\' && i+1 < len(s) {
+			b.WriteString(s[i : i+2])
+			i++
+			continue
+		}
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		name, w := referenceName(s[i+1:])
+		if w == 0 || name != old {
+			b.WriteByte(s[i])
+			continue
+		}
+		if !isName(new) {
+			return s, false
+		}
+		if s[i+1] == '{' || i+1+w < len(s) && isNameByte(s[i+1+w], false) {
+			b.WriteString("${" + new + "}")
+		} else {
+			b.WriteString("$" + new)
+		}
+		i += w
+	}
+	return b.String(), true
+}
+
+// Comment returns the comment block directly above the line that sets the
+// variable key, without blank lines in between. The comment characters and a
+// single space following them are removed from the lines, which are joined
+// by newlines. It returns an empty string when the variable is not set or has
+// no comment.
+func (d *Document) Comment(key string) string {
+	i := d.lookup(key)
+	if i < 0 {
+		return ""
+	}
+	return commentText(d.lines[d.commentStart(i):i])
+}
+
+// SetComment replaces the comment block above the line that sets the
+// variable key by text, where every line of text becomes a comment line. An
+// empty text removes the comment. It does nothing when the variable is not
+// set, and returns a ErrorInvalidKey when key is not a valid variable name.
+func (d *Document) SetComment(key, text string) error {
+	if err := tag.CheckName(key); err != nil {
+		return ErrorInvalidKey{key, err}
+	}
+	i := d.lookup(key)
+	if i < 0 {
+		return nil
+	}
+	d.replaceLines(d.commentStart(i), i, commentLines(text))
+	return nil
+}
+
+// Header returns the comment block at the start of the document, in the same
+// form as Comment. A comment block is only a header when it is followed by a
+// blank line, or ends the document, otherwise it is the comment of the
+// variable that follows it.
+func (d *Document) Header() string {
+	return commentText(d.lines[:d.headerEnd()])
+}
+
+// SetHeader replaces the header of the document by text, which is separated
+// from the rest of the document by a blank line. An empty text removes the
+// header.
+func (d *Document) SetHeader(text string) {
+	end := d.headerEnd()
+	lines := commentLines(text)
+	switch {
+	case end > 0 && len(lines) == 0 && end < len(d.lines):
+		// Also remove the blank line that separated the header.
+		end++
+	case end == 0 && len(lines) > 0 && len(d.lines) > 0:
+		lines = append(lines, docLine{})
+	}
+	d.replaceLines(0, end, lines)
+}
+
+// commentStart returns the index of the first line of the comment block that
+// ends before line i, or i when there is none. Section comment lines are not
+// part of comment blocks.
+func (d *Document) commentStart(i int) int {
+	start := i
+	for start > 0 && d.isComment(start-1) {
+		start--
+	}
+	return start
+}
+
+// isComment reports whether line i is a comment line that does not start a
+// section.
+func (d *Document) isComment(i int) bool {
+	if !isCommentLine(d.lines[i].raw) {
+		return false
+	}
+	_, ok := d.sectionName(i)
+	return !ok
+}
+
+// headerEnd returns the index of the line after the header, 0 when the
+// document has no header.
+func (d *Document) headerEnd() int {
+	end := 0
+	for end < len(d.lines) && d.isComment(end) {
+		end++
+	}
+	if end < len(d.lines) && !isBlankLine(d.lines[end].raw) {
+		return 0
+	}
+	return end
+}
+
+// replaceLines replaces the lines from start up to end by lines.
+func (d *Document) replaceLines(start, end int, lines []docLine) {
+	d.lines = append(d.lines[:start:start], append(lines, d.lines[end:]...)...)
+}
+
+// isBlankLine reports whether raw is an empty line.
+func isBlankLine(raw string) bool {
+	return strings.TrimSpace(raw) == ""
+}
+
+// isCommentLine reports whether raw is a comment line.
+func isCommentLine(raw string) bool {
+	return strings.HasPrefix(strings.TrimSpace(raw), "#")
+}
+
+// commentText returns the text of the comment lines.
+func commentText(lines []docLine) string {
+	text := make([]string, len(lines))
+	for i, l := range lines {
+		s := strings.TrimPrefix(strings.TrimSpace(l.raw), "#")
+		text[i] = strings.TrimPrefix(s, " ")
+	}
+	return strings.Join(text, "\n")
+}
+
+// commentLines returns the comment lines for text.
+func commentLines(text string) []docLine {
+	if text == "" {
+		return nil
+	}
+	var lines []docLine
+	for _, s := range strings.Split(text, "\n") {
+		raw := "#"
+		if s = strings.TrimRight(s, "\r"); s != "" {
+			raw = "# " + s
+		}
+		lines = append(lines, docLine{raw: raw})
+	}
+	return lines
+}
+
+// MergeAction is the resolution of a conflict in Document.Merge.
+type MergeAction int
+
+// Merge actions.
+const (
+	// MergeReplace replaces the value by the value of the merged
+	// document. This is the default.
+	MergeReplace MergeAction = iota
+	// MergeKeep keeps the value of the document.
+	MergeKeep
+	// MergeRename adds the variable of the merged document under another
+	// name, keeping the value of the document.
+	MergeRename
+)
+
+// MergeFunc resolves a conflict in Document.Merge for variable key, which is
+// set to different values in the document (ours) and in the merged document
+// (theirs). For MergeRename it also returns the new name of the variable of
+// the merged document.
+type MergeFunc func(key string, ours, theirs Entry) (action MergeAction, name string)
+
+// Merge adds the variables of other to the document. Variables that are not
+// set in the document yet are added with their comment and line as they
+// appear in other, at the end of the section of the same name when the
+// document has one and otherwise at the end of the document. Variables that
+// are set to different values in both documents are resolved by calling
+// resolve, a nil resolve replaces the values. The layout of the document is
+// kept and the header of other is ignored.
+//
+// It returns a ErrorInvalidKey when a variable is renamed to an invalid name
+// or to a variable that is already set, the variables merged before are kept.
+func (d *Document) Merge(other *Document, resolve MergeFunc) error {
+	for _, key := range other.Keys() {
+		i := other.lookup(key)
+		lines := append(append([]docLine(nil), other.lines[other.commentStart(i):i]...), other.lines[i])
+		j := d.lookup(key)
+		if j >= 0 {
+			if d.lines[j].value == other.lines[i].value {
+				continue
+			}
+			action, name := MergeReplace, ""
+			if resolve != nil {
+				action, name = resolve(key, d.entry(j), other.entry(i))
+			}
+			switch action {
+			case MergeKeep:
+				continue
+			case MergeReplace:
+				if err := d.Set(key, other.lines[i].value); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tag.CheckName(name); err != nil {
+				return ErrorInvalidKey{name, err}
+			}
+			if d.lookup(name) >= 0 {
+				return ErrorInvalidKey{name, errors.New("variable is already set")}
+			}
+			value := other.lines[i].value
+			v, q := quoteStyle(value, other.lines[i].quote)
+			lines[len(lines)-1] = docLine{raw: name + "=" + v, assign: true, key: name, value: value, quote: q}
+		}
+		if name, ok := other.sectionOf(i); ok {
+			if s := d.Section(name); s != nil && s.insert(lines) {
+				continue
+			}
+		}
+		d.lines = append(d.lines, lines...)
+	}
+	return nil
+}
+
+// DefaultSectionPattern matches the comment lines that start a section by
+// default, like "# --- Database ---".
+var DefaultSectionPattern = regexp.MustCompile(`^#\s*-{3,}\s*(.*?)\s*-{3,}\s*$`)
+
+// SetSectionPattern sets the pattern of the comment lines that start a
+// section. The first submatch of the pattern, or the whole match when it has
+// no submatches, is the name of the section. The trimmed line, including the
+// comment character, is matched. A nil pattern restores
+// DefaultSectionPattern.
+func (d *Document) SetSectionPattern(re *regexp.Regexp) {
+	d.sections = re
+}
+
+// A Section is a part of a Document that starts with a section comment line
+// and ends before the next one or at the end of the document.
+type Section struct {
+	// Name is the name of the section.
+	Name string
+	doc  *Document
+}
+
+// Sections returns the sections of the document in order of appearance.
+// Variables before the first section comment are not part of any section.
+func (d *Document) Sections() []*Section {
+	var sections []*Section
+	for i := range d.lines {
+		if name, ok := d.sectionName(i); ok {
+			sections = append(sections, &Section{Name: name, doc: d})
+		}
+	}
+	return sections
+}
+
+// Section returns the first section with the name, or nil when the document
+// has no such section.
+func (d *Document) Section(name string) *Section {
+	for _, s := range d.Sections() {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddSection appends a section comment line for a new section with the name,
+// preceded by a blank line unless the document is empty, and returns the
+// section. The comment line is written in the form of DefaultSectionPattern.
+func (d *Document) AddSection(name string) *Section {
+	if len(d.lines) > 0 {
+		d.lines = append(d.lines, docLine{})
+	}
+	d.lines = append(d.lines, docLine{raw: "# --- " + name + " ---"})
+	return &Section{Name: name, doc: d}
+}
+
+// sectionName returns the name of the section started by line i and whether
+// it starts a section.
+func (d *Document) sectionName(i int) (string, bool) {
+	if d.lines[i].assign || !isCommentLine(d.lines[i].raw) {
+		return "", false
+	}
+	re := d.sections
+	if re == nil {
+		re = DefaultSectionPattern
+	}
+	m := re.FindStringSubmatch(strings.TrimSpace(d.lines[i].raw))
+	switch {
+	case m == nil:
+		return "", false
+	case len(m) > 1:
+		return m[1], true
+	}
+	return m[0], true
+}
+
+// bounds returns the index of the section comment line and the index of the
+// line after the section. The start is -1 when the section no longer exists.
+func (s *Section) bounds() (start, end int) {
+	start = -1
+	for i := range s.doc.lines {
+		name, ok := s.doc.sectionName(i)
+		switch {
+		case !ok:
+		case start >= 0:
+			return start, i
+		case name == s.Name:
+			start = i
+		}
+	}
+	return start, len(s.doc.lines)
+}
+
+// Keys returns the names of the variables set in the section in the order
+// they first appear.
+func (s *Section) Keys() []string {
+	start, end := s.bounds()
+	if start < 0 {
+		return nil
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range s.doc.lines[start:end] {
+		if l.assign && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Get returns the value of the variable key and whether it is set in the
+// section.
+func (s *Section) Get(key string) (string, bool) {
+	start, end := s.bounds()
+	if i := s.doc.lookup(key); i > start && i < end {
+		return s.doc.lines[i].value, true
+	}
+	return "", false
+}
+
+// Set sets the value of the variable key like Document.Set. When the variable
+// is not set in the document yet, it is inserted after the last variable of
+// the section instead of at the end of the document. When the section no
+// longer exists, it is appended like Document.Set does. It returns a
+// ErrorInvalidKey when key is not a valid variable name.
+func (s *Section) Set(key, value string) error {
+	if err := tag.CheckName(key); err != nil {
+		return ErrorInvalidKey{key, err}
+	}
+	if s.doc.lookup(key) >= 0 || !s.insert([]docLine{newDocLine(key, value)}) {
+		return s.doc.Set(key, value)
+	}
+	return nil
+}
+
+// insert inserts the lines after the last variable of the section. It
+// reports false when the section no longer exists.
+func (s *Section) insert(lines []docLine) bool {
+	start, end := s.bounds()
+	if start < 0 {
+		return false
+	}
+	pos := start + 1
+	for i := start + 1; i < end; i++ {
+		if s.doc.lines[i].assign {
+			pos = i + 1
+		}
+	}
+	s.doc.replaceLines(pos, pos, lines)
+	return true
+}
+
+// sectionOf returns the name of the section line i is part of and whether it
+// is part of a section.
+func (d *Document) sectionOf(i int) (string, bool) {
+	for ; i >= 0; i-- {
+		if name, ok := d.sectionName(i); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Bytes returns the EnvironmentFile encoding of the document.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, l := range d.lines {
+		buf.WriteString(l.raw)
+		if i < len(d.lines)-1 || !d.noEOL {
+			buf.WriteString(d.newline)
+		}
+	}
+	return buf.Bytes()
+}
+
+// UpdateFile updates the EnvironmentFile at path with the encoding of v. Only
+// the variables of v are changed, comments, blank lines, the order of the
+// lines and other variables are preserved. Variables that are not in the file
+// yet are appended at the end. The file is created when it does not exist.
+//
+// See the documentation for Marshal for details about the conversion.
+func UpdateFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d, err := ParseDocument(data)
+	if err != nil {
+		return ErrorFile{path, err}
+	}
+	vars, err := encodeVars(v, ZeroByTag)
+	if err != nil {
+		return err
+	}
+	if err := checkKeys(vars, false); err != nil {
+		return err
+	}
+	for _, p := range vars {
+		d.Set(p.Key, p.Value)
+	}
+	return d.WriteFile(path)
+}
+
+// WriteFile writes the EnvironmentFile encoding of v to the file at path. The
+// data is written to a temporary file in the same directory that is renamed
+// over path, so readers never see a partially written file and a failure
+// while writing leaves the existing file intact. An existing file keeps its
+// permissions, a new file is only readable by its owner.
+//
+// See the documentation for Marshal for details about the conversion.
+func WriteFile(path string, v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// WriteFile writes the document to the file at path, replacing it atomically
+// like the WriteFile function.
+func (d *Document) WriteFile(path string) error {
+	return atomicfile.WriteFile(path, d.Bytes(), 0600)
+}
@@ -0,0 +1,384 @@
+package envfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	input := "# Application settings\nHOST=localhost\n\nexport PORT=80\nOTHER=kept # not a comment\nHOST=later\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if got := string(d.Bytes()); got != input {
+		t.Errorf("unmodified document did not match\nwant:\n%q,\tgot\n%q", input, got)
+	}
+	if v, ok := d.Get("HOST"); !ok || v != "later" {
+		t.Errorf("get did not return the last value, got %q, %v", v, ok)
+	}
+	if want, got := []string{"HOST", "PORT", "OTHER"}, d.Keys(); !reflect.DeepEqual(want, got) {
+		t.Errorf("keys did not match, want %v, got %v", want, got)
+	}
+	d.Set("PORT", "8080")
+	d.Set("OTHER", "kept # not a comment")
+	d.Set("NEW", "two words")
+	want := "# Application settings\nHOST=localhost\n\nexport PORT=8080\nOTHER=kept # not a comment\nHOST=later\nNEW=\"two words\"\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("modified document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	for name, set := range map[string]func(key string) error{
+		"Set":         func(key string) error { return d.Set(key, "x") },
+		"SetComment":  func(key string) error { return d.SetComment(key, "x") },
+		"Section.Set": func(key string) error { return d.AddSection("New").Set(key, "x") },
+	} {
+		for _, key := range []string{"A B", "A=B", "", "A\nB"} {
+			if err := set(key); !errors.As(err, new(ErrorInvalidKey)) {
+				t.Errorf("[%s] error for %q did not match, want: ErrorInvalidKey, got %v", name, key, err)
+			}
+		}
+	}
+}
+
+func TestDocumentLineEndings(t *testing.T) {
+	input := "A=1\r\nB=2"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	d.Set("A", "3")
+	if want, got := "A=3\r\nB=2", string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
+
+func TestDocumentQuoteStyle(t *testing.T) {
+	input := "SINGLE='a'\nDOUBLE=\"b\"\nBARE=c\nFALLBACK='d'\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	d.Set("SINGLE", "two words")
+	d.Set("DOUBLE", "plain")
+	d.Set("BARE", "two words")
+	d.Set("FALLBACK", "it's")
+	want := "SINGLE='two words'\nDOUBLE=\"plain\"\nBARE=\"two words\"\nFALLBACK=\"it's\"\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
+
+func TestDocumentRename(t *testing.T) {
+	input := "# The host.\nexport DB_HOST=db\nURL=postgres://$DB_HOST:5432\nDSN=\"host=${DB_HOST}\"\nRAW='$DB_HOST'\nESCAPED=\"\\$DB_HOST\"\nSUFFIX=$DB_HOST_X\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if err := d.Rename("DB_HOST", "DATABASE_HOST"); err != nil {
+		t.Fatalf("rename returned an error: %v", err)
+	}
+	want := "# The host.\nexport DATABASE_HOST=db\nURL=postgres://$DATABASE_HOST:5432\nDSN=\"host=${DATABASE_HOST}\"\nRAW='$DB_HOST'\nESCAPED=\"\\$DB_HOST\"\nSUFFIX=$DB_HOST_X\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	if v, ok := d.Get("DATABASE_HOST"); !ok || v != "db" {
+		t.Errorf("renamed variable did not match, got %q, %v", v, ok)
+	}
+	if v, _ := d.Get("URL"); v != "postgres://$DATABASE_HOST:5432" {
+		t.Errorf("referencing value was not updated, got %q", v)
+	}
+
+	for _, c := range []struct {
+		Name string
+		New  string
+	}{
+		{"invalid name", "MY HOST"},
+		{"already set", "URL"},
+		{"can not be referenced", "DATABASE.HOST"},
+	} {
+		err := d.Rename("DATABASE_HOST", c.New)
+		if _, ok := err.(ErrorInvalidKey); !ok {
+			t.Errorf("[%s] error did not match, want: ErrorInvalidKey, got %v", c.Name, err)
+		}
+	}
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("failed rename modified the document\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
+
+func TestDocumentComments(t *testing.T) {
+	input := "# Generated file\n#\n# Edit with care.\n\n# The host to listen on.\nHOST=localhost\n\nPORT=80\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if want, got := "Generated file\n\nEdit with care.", d.Header(); got != want {
+		t.Errorf("header did not match, want %q, got %q", want, got)
+	}
+	if want, got := "The host to listen on.", d.Comment("HOST"); got != want {
+		t.Errorf("comment did not match, want %q, got %q", want, got)
+	}
+	if got := d.Comment("PORT"); got != "" {
+		t.Errorf("comment of uncommented variable is not empty, got %q", got)
+	}
+	d.SetComment("HOST", "Host name\nor address.")
+	d.SetComment("PORT", "Port number.")
+	d.SetComment("MISSING", "ignored")
+	d.SetHeader("Maintained by hand.")
+	want := "# Maintained by hand.\n\n# Host name\n# or address.\nHOST=localhost\n\n# Port number.\nPORT=80\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	d.SetComment("HOST", "")
+	d.SetHeader("")
+	want = "HOST=localhost\n\n# Port number.\nPORT=80\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	// A comment directly above the first variable is not a header.
+	d, err = ParseDocument([]byte("# The host.\nHOST=localhost\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if got := d.Header(); got != "" {
+		t.Errorf("header is not empty, got %q", got)
+	}
+	d.SetHeader("Header.")
+	want = "# Header.\n\n# The host.\nHOST=localhost\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
+
+func TestDocumentSections(t *testing.T) {
+	input := "NAME=app\n\n# --- Database ---\n# The host.\nDB_HOST=db\nDB_PORT=5432\n\n# --- Cache ---\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	var names []string
+	for _, s := range d.Sections() {
+		names = append(names, s.Name)
+	}
+	if want := []string{"Database", "Cache"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("sections did not match, want %v, got %v", want, names)
+	}
+	db := d.Section("Database")
+	if want, got := []string{"DB_HOST", "DB_PORT"}, db.Keys(); !reflect.DeepEqual(want, got) {
+		t.Errorf("keys did not match, want %v, got %v", want, got)
+	}
+	if _, ok := db.Get("NAME"); ok {
+		t.Errorf("variable outside the section was returned")
+	}
+	if got := d.Comment("DB_HOST"); got != "The host." {
+		t.Errorf("comment included the section line, got %q", got)
+	}
+	db.Set("DB_USER", "app")
+	db.Set("NAME", "other")
+	d.Section("Cache").Set("CACHE_SIZE", "10")
+	d.AddSection("Logging").Set("LOG_LEVEL", "info")
+	if d.Section("Missing") != nil {
+		t.Errorf("missing section was returned")
+	}
+	want := "NAME=other\n\n# --- Database ---\n# The host.\nDB_HOST=db\nDB_PORT=5432\nDB_USER=app\n\n# --- Cache ---\nCACHE_SIZE=10\n\n# --- Logging ---\nLOG_LEVEL=info\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	d, err = ParseDocument([]byte("## Database\nHOST=db\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	d.SetSectionPattern(regexp.MustCompile(`^## (.+)$`))
+	if s := d.Section("Database"); s == nil || !reflect.DeepEqual(s.Keys(), []string{"HOST"}) {
+		t.Errorf("section with custom pattern was not found")
+	}
+}
+
+func TestDocumentAll(t *testing.T) {
+	input := "# The host.\nexport HOST=localhost\n\nPORT='80'\nHOST=later\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	var keys []string
+	var entries []Entry
+	for key, e := range d.All() {
+		keys = append(keys, key)
+		entries = append(entries, e)
+	}
+	if want := []string{"HOST", "PORT", "HOST"}; !reflect.DeepEqual(want, keys) {
+		t.Errorf("keys did not match, want %v, got %v", want, keys)
+	}
+	want := []Entry{
+		{Value: "localhost", Line: 2, Comment: "The host.", Export: true},
+		{Value: "80", Line: 4, Quote: '\''},
+		{Value: "later", Line: 5},
+	}
+	if !reflect.DeepEqual(want, entries) {
+		t.Errorf("entries did not match\nwant:\n%+v\ngot:\n%+v", want, entries)
+	}
+	for range d.All() {
+		break
+	}
+}
+
+func TestDocumentMerge(t *testing.T) {
+	ours, err := ParseDocument([]byte("# App.\nNAME=app\nHOST=localhost\n\n# --- Database ---\nDB_HOST=db\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	theirs, err := ParseDocument([]byte("NAME=other\nHOST=remote\nPORT='80'\n# --- Database ---\n# The user.\nDB_USER=app\nDB_HOST=db2\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	var conflicts []string
+	err = ours.Merge(theirs, func(key string, o, t Entry) (MergeAction, string) {
+		conflicts = append(conflicts, key+":"+o.Value+":"+t.Value)
+		switch key {
+		case "NAME":
+			return MergeKeep, ""
+		case "DB_HOST":
+			return MergeRename, "DB_HOST_NEW"
+		}
+		return MergeReplace, ""
+	})
+	if err != nil {
+		t.Fatalf("merge returned an error: %v", err)
+	}
+	if want := []string{"NAME:app:other", "HOST:localhost:remote", "DB_HOST:db:db2"}; !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("conflicts did not match, want %v, got %v", want, conflicts)
+	}
+	want := "# App.\nNAME=app\nHOST=remote\n\n# --- Database ---\nDB_HOST=db\nPORT='80'\n# The user.\nDB_USER=app\nDB_HOST_NEW=db2\n"
+	if got := string(ours.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	err = ours.Merge(theirs, func(string, Entry, Entry) (MergeAction, string) {
+		return MergeRename, "DB_USER"
+	})
+	if _, ok := err.(ErrorInvalidKey); !ok {
+		t.Errorf("error did not match, want: ErrorInvalidKey, got %v", err)
+	}
+}
+
+func TestDocumentSort(t *testing.T) {
+	input := "# Header.\n\n# The port.\nPORT=80\nHOST=localhost\n\n# Name.\nNAME=app\n\n# --- Database ---\nDB_USER=app\n# The host.\nDB_HOST=db\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	d.Sort(nil)
+	want := "# Header.\n\nHOST=localhost\n# Name.\nNAME=app\n# The port.\nPORT=80\n\n# --- Database ---\n# The host.\nDB_HOST=db\nDB_USER=app\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("sorted document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	d.Sort(func(a, b string) bool { return a > b })
+	want = "# Header.\n\n# The port.\nPORT=80\n# Name.\nNAME=app\nHOST=localhost\n\n# --- Database ---\nDB_USER=app\n# The host.\nDB_HOST=db\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("sorted document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
+
+func TestDocumentRedact(t *testing.T) {
+	input := "# Credentials.\nexport DB_PASSWORD='s3cret'\nDB_USER=app\nAPI_TOKEN=\n\nAPI_TOKEN=\"abc\"\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	d.Redact(func(key string) bool { return key != "DB_USER" })
+	want := "# Credentials.\nexport DB_PASSWORD='********'\nDB_USER=app\nAPI_TOKEN=\n\nAPI_TOKEN=\"********\"\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("redacted document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	d.Redact(nil)
+	if v, _ := d.Get("DB_USER"); v != Redacted {
+		t.Errorf("value did not match, want: %q, got %q", Redacted, v)
+	}
+}
+
+func TestParseDocumentError(t *testing.T) {
+	_, err := ParseDocument([]byte("A=1\nINVALID\n"))
+	if err != (ErrorLineParsing{2}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{2}, err)
+	}
+}
+
+func TestUpdateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	input := "# Database\nDB_HOST=old\nUNKNOWN=value\n\n# Other\nNAME=app\n"
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v := struct {
+		Name     string
+		Database struct {
+			Host string
+			Port string
+		} `env:"DB"`
+		Optional string `env:",omitempty"`
+	}{
+		Name: "app",
+	}
+	v.Database.Host = "new"
+	v.Database.Port = "5432"
+	if err := UpdateFile(path, v); err != nil {
+		t.Fatalf("update file returned an error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Database\nDB_HOST=new\nUNKNOWN=value\n\n# Other\nNAME=app\nDB_PORT=5432\n"
+	if string(got) != want {
+		t.Errorf("file did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	created := filepath.Join(dir, "new.env")
+	if err := UpdateFile(created, v); err != nil {
+		t.Fatalf("update of missing file returned an error: %v", err)
+	}
+	if got, _ := os.ReadFile(created); string(got) != "NAME=app\nDB_HOST=new\nDB_PORT=5432\n" {
+		t.Errorf("created file did not match, got %q", got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	v := struct {
+		Name string
+		Port int
+	}{"app", 8080}
+	if err := WriteFile(path, v); err != nil {
+		t.Fatalf("write file returned an error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NAME=app\nPORT=8080\n"; string(got) != want {
+		t.Errorf("output did not match, want: %q, got %q", want, got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := os.FileMode(0600), info.Mode().Perm(); want != got {
+		t.Errorf("permissions did not match, want: %v, got %v", want, got)
+	}
+
+	if err := WriteFile(path, struct{ Bad chan int }{}); err == nil {
+		t.Errorf("unsupported type did not return an error")
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "NAME=app\nPORT=8080\n" {
+		t.Errorf("file was modified on error: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,409 @@
+package envfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/basvdlei/envfile/internal/tag"
+)
+
+// An Encoder writes EnvironmentFile encoded values to an output stream. It is
+// not safe for concurrent use, see the package documentation.
+type Encoder struct {
+	w         io.Writer
+	sortKeys  bool
+	less      func(a, b string) bool
+	groups    bool
+	quoting   Quoting
+	zero      ZeroPolicy
+	export    bool
+	sanitize  bool
+	transform TransformFunc
+	encrypt   EncryptFunc
+
+	redact        bool
+	redactPattern *regexp.Regexp
+}
+
+// TransformFunc returns the value to write for the variable key in place of
+// value.
+type TransformFunc func(key, value string) (string, error)
+
+// ZeroPolicy decides which fields holding a zero or empty value are written
+// by the Encoder.
+type ZeroPolicy int
+
+// Zero value policies.
+const (
+	// ZeroByTag omits fields with the "omitempty" option when their value
+	// is empty. This is the default.
+	ZeroByTag ZeroPolicy = iota
+	// ZeroOmit omits all fields holding their zero value or an empty
+	// value, so no empty assignments are written.
+	ZeroOmit
+	// ZeroEmit writes all fields, including fields with the "omitempty"
+	// option, so every variable is present in the output.
+	ZeroEmit
+)
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetSortKeys controls whether variables are written sorted by name instead of
+// in the order of the struct fields. Sorted output is stable when fields are
+// reordered, which keeps diffs of generated files small.
+func (enc *Encoder) SetSortKeys(on bool) {
+	enc.sortKeys = on
+}
+
+// SetLess sets a comparator that decides the order in which variables are
+// written, less reports whether variable a must be written before variable b.
+// When set, it takes precedence over the "order" option and SetSortKeys.
+// Variables that are equal according to less keep their relative order.
+func (enc *Encoder) SetLess(less func(a, b string) bool) {
+	enc.less = less
+}
+
+// SetGroupHeaders controls whether the variables of each nested struct are
+// written as a group, preceded by a blank line and a comment with the name of
+// the struct field:
+//
+//	NAME=app
+//
+//	# Database
+//	DB_HOST=localhost
+//	DB_PORT=5432
+//
+// Top level variables are written first, followed by the groups in the order
+// of the struct fields. Ordering options apply within each group.
+func (enc *Encoder) SetGroupHeaders(on bool) {
+	enc.groups = on
+}
+
+// SetQuoting sets the policy that decides which values are enclosed in double
+// quotes. Fields with the "quote" option are always quoted, regardless of the
+// policy.
+func (enc *Encoder) SetQuoting(q Quoting) {
+	enc.quoting = q
+}
+
+// SetZeroPolicy sets the policy that decides whether fields holding a zero or
+// empty value are written as an empty assignment or omitted.
+func (enc *Encoder) SetZeroPolicy(p ZeroPolicy) {
+	enc.zero = p
+}
+
+// SetRedact controls whether the non-empty values of fields with the "secret"
+// option are replaced by Redacted, so the output can be logged safely.
+func (enc *Encoder) SetRedact(on bool) {
+	enc.redact = on
+}
+
+// SetRedactPattern sets a regular expression, all values matching re are
+// replaced by Redacted regardless of their field options. Use a nil re to
+// disable pattern based redaction.
+func (enc *Encoder) SetRedactPattern(re *regexp.Regexp) {
+	enc.redactPattern = re
+}
+
+// SetExport controls whether every assignment is prefixed with "export ", so
+// the output can be sourced by a shell and the variables are passed to child
+// processes. The decoding functions ignore the prefix.
+func (enc *Encoder) SetExport(on bool) {
+	enc.export = on
+}
+
+// SetTransform sets a function that is applied to every value just before it
+// is quoted and written, for example to encrypt or tokenize values without
+// changing the struct definitions. Redacted values are not passed to fn. When
+// fn returns an error, Encode returns it and nothing is written. Use a nil fn
+// to disable the transformation.
+func (enc *Encoder) SetTransform(fn TransformFunc) {
+	enc.transform = fn
+}
+
+// SetEncrypt sets a function that encrypts the non-empty values of fields
+// with the "secret" option, the ciphertext is written as ENC[ciphertext] so it
+// can be decrypted with Decoder.SetDecrypt. It is applied after the function
+// set with SetTransform, redacted values are not passed to fn. When fn returns
+// an error, Encode returns it and nothing is written. Use a nil fn to write
+// the values in plain text.
+func (enc *Encoder) SetEncrypt(fn EncryptFunc) {
+	enc.encrypt = fn
+}
+
+// SetSanitizeKeys controls whether invalid characters in variable names,
+// whitespace, '=' and a leading '#', are replaced by '_'. By default Encode
+// returns a ErrorInvalidKey for such names, as they can not be read back.
+func (enc *Encoder) SetSanitizeKeys(on bool) {
+	enc.sanitize = on
+}
+
+// Encode writes the EnvironmentFile encoding of v to the stream. Nothing is
+// written when an error is returned.
+//
+// See the documentation for Marshal for details about the conversion.
+func (enc *Encoder) Encode(v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := enc.encode(buf, v); err != nil {
+		return err
+	}
+	_, err := enc.w.Write(buf.Bytes())
+	return err
+}
+
+// encode writes the EnvironmentFile encoding of v to buf.
+func (enc *Encoder) encode(buf *bytes.Buffer, v interface{}) error {
+	vars, err := encodeVars(v, enc.zero)
+	if err != nil {
+		return err
+	}
+	if err := checkKeys(vars, enc.sanitize); err != nil {
+		return err
+	}
+	groupIndex := make(map[string]int)
+	groupIndex[""] = 0
+	for _, v := range vars {
+		if _, ok := groupIndex[v.Group]; !ok {
+			groupIndex[v.Group] = len(groupIndex)
+		}
+	}
+	sort.SliceStable(vars, func(i, j int) bool {
+		if enc.groups && vars[i].Group != vars[j].Group {
+			return groupIndex[vars[i].Group] < groupIndex[vars[j].Group]
+		}
+		if enc.less != nil {
+			return enc.less(vars[i].Key, vars[j].Key)
+		}
+		if vars[i].Order != vars[j].Order {
+			return vars[i].Order < vars[j].Order
+		}
+		return enc.sortKeys && vars[i].Key < vars[j].Key
+	})
+	group := ""
+	for _, p := range vars {
+		if enc.redacted(p) {
+			p.Value = Redacted
+		} else {
+			if enc.transform != nil {
+				if p.Value, err = enc.transform(p.Key, p.Value); err != nil {
+					return err
+				}
+			}
+			if enc.encrypt != nil && p.Secret && p.Value != "" {
+				if p.Value, err = enc.encrypt(p.Key, p.Value); err != nil {
+					return err
+				}
+				p.Value = "ENC[" + p.Value + "]"
+			}
+		}
+		if enc.groups && p.Group != group {
+			group = p.Group
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			fmt.Fprintf(buf, "# %s\n", group)
+		}
+		if enc.export {
+			buf.WriteString("export ")
+		}
+		fmt.Fprintf(buf, "%s=%s\n", p.Key, enc.quoteValue(p))
+	}
+	return nil
+}
+
+// maxPooledBuffer is the capacity above which buffers are not returned to the
+// pool, so a single large encoding does not pin its memory.
+const maxPooledBuffer = 64 << 10
+
+// bufferPool holds the *bytes.Buffer values used for encoding.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// redacted reports whether the value of v must be masked.
+func (enc *Encoder) redacted(v encVar) bool {
+	if v.Value == "" {
+		return false
+	}
+	if enc.redact && v.Secret {
+		return true
+	}
+	return enc.redactPattern != nil && enc.redactPattern.MatchString(v.Value)
+}
+
+// quoteValue returns the value of v quoted according to the quoting policy.
+func (enc *Encoder) quoteValue(v encVar) string {
+	switch {
+	case v.Quote || enc.quoting == QuoteAlways:
+		return quote(v.Value)
+	case enc.quoting == QuoteAsNeeded && needsQuoting(v.Value):
+		return quote(v.Value)
+	case !bareValue(v.Value):
+		return quote(v.Value)
+	}
+	return v.Value
+}
+
+// appendPlain is the fast path of Marshal for structs with only plain string
+// fields, it appends the encoding of v to dst. The output is sized up front
+// and written without intermediate allocations. It reports false when v must
+// be handled by the Encoder.
+func appendPlain(dst []byte, v interface{}) ([]byte, bool) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	si := cachedStruct(t)
+	if !si.plain {
+		return nil, false
+	}
+	return si.appendPlain(dst, reflect.ValueOf(v))
+}
+
+// appendPlain appends the encoding of the struct value val to dst. It must
+// only be used when si.plain is set. It reports false when one of the values
+// has to be quoted.
+func (si *structInfo) appendPlain(dst []byte, val reflect.Value) ([]byte, bool) {
+	size := 0
+	for _, f := range si.fields {
+		value := val.FieldByIndex(f.Index).String()
+		if f.Opts.OmitEmpty && value == "" {
+			continue
+		}
+		if !bareValue(value) {
+			return nil, false
+		}
+		size += len(f.Name) + len(value) + 2
+	}
+	b := dst
+	if cap(b)-len(b) < size {
+		b = make([]byte, len(dst), len(dst)+size)
+		copy(b, dst)
+	}
+	for _, f := range si.fields {
+		value := val.FieldByIndex(f.Index).String()
+		if f.Opts.OmitEmpty && value == "" {
+			continue
+		}
+		b = append(b, f.Name...)
+		b = append(b, '=')
+		b = append(b, value...)
+		b = append(b, '\n')
+	}
+	return b, true
+}
+
+// encVar is a variable to be written by the Encoder.
+type encVar struct {
+	Key    string
+	Value  string
+	Order  int
+	Group  string
+	Quote  bool
+	Secret bool
+}
+
+// encodeVars returns the variables of v in the order of the struct fields.
+// Variables are omitted according to the zero value policy.
+func encodeVars(v interface{}, zero ZeroPolicy) ([]encVar, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, nil
+	}
+	if k := t.Kind(); k != reflect.Struct {
+		return nil, ErrorUnsupportedType{k}
+	}
+	// Copy the value so it is addressable and pointer receiver
+	// implementations of encoding.TextMarshaler can be used.
+	val := reflect.New(t).Elem()
+	val.Set(reflect.ValueOf(v))
+	si := cachedStruct(t)
+	if si.err != nil {
+		return nil, si.err
+	}
+	var vars []encVar
+	for _, f := range si.fields {
+		fv, err := val.FieldByIndexErr(f.Index)
+		if err != nil {
+			// The field is part of a nil nested struct.
+			continue
+		}
+		if f.Map {
+			keys, values, err := formatMap(fv, f.Opts)
+			if err != nil {
+				return nil, err
+			}
+			for i, k := range keys {
+				empty := values[i] == ""
+				vars = appendVar(vars, f, f.Name+"_"+k, values[i], zero, empty, empty)
+			}
+			continue
+		}
+		value, err := formatValue(fv, f.Opts)
+		if err != nil {
+			return nil, err
+		}
+		empty := emptyValue(fv, value)
+		vars = appendVar(vars, f, f.Name, value, zero, empty, empty || fv.IsZero())
+	}
+	return vars, nil
+}
+
+// checkKeys returns a ErrorInvalidKey for the first variable with a name that
+// can not be read back. When sanitize is set, invalid characters are replaced
+// first.
+func checkKeys(vars []encVar, sanitize bool) error {
+	for i := range vars {
+		if sanitize {
+			vars[i].Key = tag.SanitizeName(vars[i].Key)
+		}
+		if err := tag.CheckName(vars[i].Key); err != nil {
+			return ErrorInvalidKey{vars[i].Key, err}
+		}
+	}
+	return nil
+}
+
+// appendVar appends the variable of field f to vars unless it is omitted
+// according to the zero value policy. The empty argument reports whether the
+// value is empty for the "omitempty" option, isZero whether it is empty or
+// the zero value of its type.
+func appendVar(vars []encVar, f field, key, value string, zero ZeroPolicy, empty, isZero bool) []encVar {
+	switch {
+	case zero == ZeroByTag && f.Opts.OmitEmpty && empty:
+		return vars
+	case zero == ZeroOmit && isZero:
+		return vars
+	}
+	return append(vars, encVar{
+		Key:    key,
+		Value:  value,
+		Order:  f.Opts.Order,
+		Group:  f.Group,
+		Quote:  f.Opts.Quote,
+		Secret: f.Opts.Secret,
+	})
+}
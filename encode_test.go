@@ -0,0 +1,265 @@
+package envfile
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestEncoderSortKeys(t *testing.T) {
+	v := struct {
+		Zoo    string
+		Alpha  string
+		Nested struct {
+			Beta string
+		}
+	}{
+		Zoo:   "z",
+		Alpha: "a",
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetSortKeys(true)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want := "ALPHA=a\nNESTED_BETA=\nZOO=z\n"
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+}
+
+type orderedConfig struct {
+	Zoo   string
+	Host  string `env:"HOST,order=-1"`
+	Alpha string
+	Last  string `env:"LAST,order=1"`
+}
+
+func TestEncoderOrder(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Sort   bool
+		Less   func(a, b string) bool
+		Output string
+	}{
+		{
+			Name:   "order option",
+			Output: "HOST=\nZOO=\nALPHA=\nLAST=\n",
+		},
+		{
+			Name:   "order option with sorted keys",
+			Sort:   true,
+			Output: "HOST=\nALPHA=\nZOO=\nLAST=\n",
+		},
+		{
+			Name: "comparator",
+			Sort: true,
+			Less: func(a, b string) bool {
+				return a > b
+			},
+			Output: "ZOO=\nLAST=\nHOST=\nALPHA=\n",
+		},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetSortKeys(c.Sort)
+		enc.SetLess(c.Less)
+		if err := enc.Encode(orderedConfig{}); err != nil {
+			t.Fatalf("[%s] encode returned an error: %v", c.Name, err)
+		}
+		if buf.String() != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%q,\tgot\n%q", c.Name, c.Output, buf.String())
+		}
+	}
+}
+
+func TestEncoderGroupHeaders(t *testing.T) {
+	type replica struct {
+		Host string
+	}
+	v := struct {
+		Database struct {
+			Host    string
+			Port    string
+			Replica replica
+		} `env:"DB"`
+		Name string
+		embedded
+		Cache struct {
+			Host string `env:",omitempty"`
+		}
+	}{
+		Name: "app",
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetGroupHeaders(true)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want := `NAME=app
+HOST=
+
+# Database
+DB_HOST=
+DB_PORT=
+
+# Database.Replica
+DB_REPLICA_HOST=
+`
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%s\ngot:\n%s", want, buf.String())
+	}
+}
+
+func TestEncoderZeroPolicy(t *testing.T) {
+	v := struct {
+		Name     string
+		Empty    string
+		Optional string `env:",omitempty"`
+	}{
+		Name: "app",
+	}
+	cases := []struct {
+		Name   string
+		Policy ZeroPolicy
+		Output string
+	}{
+		{"by tag", ZeroByTag, "NAME=app\nEMPTY=\n"},
+		{"omit", ZeroOmit, "NAME=app\n"},
+		{"emit", ZeroEmit, "NAME=app\nEMPTY=\nOPTIONAL=\n"},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetZeroPolicy(c.Policy)
+		if err := enc.Encode(v); err != nil {
+			t.Fatalf("[%s] encode returned an error: %v", c.Name, err)
+		}
+		if buf.String() != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%q,\tgot\n%q", c.Name, c.Output, buf.String())
+		}
+	}
+}
+
+type redactConfig struct {
+	User     string
+	Password string `env:"PASSWORD,secret"`
+	Empty    string `env:"EMPTY,secret"`
+	Token    string
+}
+
+func TestMarshalRedacted(t *testing.T) {
+	v := redactConfig{
+		User:     "admin",
+		Password: "hunter2",
+		Token:    "ghp_abcdef",
+	}
+	got, err := MarshalRedacted(v)
+	if err != nil {
+		t.Fatalf("marshal redacted returned an error: %v", err)
+	}
+	want := "USER=admin\nPASSWORD=********\nEMPTY=\nTOKEN=ghp_abcdef\n"
+	if string(got) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetRedactPattern(regexp.MustCompile(`^ghp_`))
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want = "USER=admin\nPASSWORD=hunter2\nEMPTY=\nTOKEN=********\n"
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+}
+
+func TestEncoderExport(t *testing.T) {
+	v := struct {
+		Name    string
+		Message string
+	}{
+		Name:    "app",
+		Message: "hello world",
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetExport(true)
+	enc.SetQuoting(QuoteAsNeeded)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want := "export NAME=app\nexport MESSAGE=\"hello world\"\n"
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+}
+
+func TestEncoderTransform(t *testing.T) {
+	v := struct {
+		Name     string
+		Password string `env:"PASSWORD,secret"`
+	}{
+		Name:     "app",
+		Password: "hunter2",
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetRedact(true)
+	enc.SetTransform(func(key, value string) (string, error) {
+		return key + ":" + value, nil
+	})
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want := "NAME=NAME:app\nPASSWORD=" + Redacted + "\n"
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+
+	buf.Reset()
+	errTransform := errors.New("transform failed")
+	enc.SetTransform(func(key, value string) (string, error) {
+		if key == "PASSWORD" {
+			return "", errTransform
+		}
+		return value, nil
+	})
+	enc.SetRedact(false)
+	if err := enc.Encode(v); err != errTransform {
+		t.Errorf("error did not match, want: %v, got %v", errTransform, err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("output written on error: %q", buf.String())
+	}
+}
+
+func TestEncoderInvalidKeys(t *testing.T) {
+	v := struct {
+		Spaced string            `env:"MY VAR"`
+		Labels map[string]string `env:"LABEL"`
+	}{
+		Spaced: "a",
+		Labels: map[string]string{"x=y": "b"},
+	}
+	_, err := Marshal(v)
+	if e, ok := err.(ErrorInvalidKey); !ok || e.Key != "MY VAR" {
+		t.Errorf("error did not match, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetSanitizeKeys(true)
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	want := "MY_VAR=a\nLABEL_x_y=b\n"
+	if buf.String() != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, buf.String())
+	}
+}
@@ -3,14 +3,70 @@
 //
 // This package is using the same 'style' as the the standard library
 // encoding/json package for (un)marshaling EnvironmentFile (dot env) syntax.
+//
+// # Syntax
+//
+// The strict dialect, as accepted by Valid and a Decoder with SetStrict, is
+// defined by the following grammar in EBNF. Lines end with "\n" or "\r\n",
+// the last line does not need to end with either.
+//
+//	file       = { line } .
+//	line       = [ blanks ] [ comment | assignment ] [ blanks ] .
+//	blanks     = blank { blank } .
+//	blank      = " " | "\t" .
+//	comment    = "#" { char } .
+//	assignment = [ "export" blanks ] name "=" [ value ] .
+//	name       = namestart { namechar } .
+//	namestart  = namechar - "#" .
+//	namechar   = char - whitespace - "=" .
+//	value      = bare | single | double .
+//	bare       = (char - blank - "'" - `"`) { char } .
+//	single     = "'" { char - "'" } "'" .
+//	double     = `"` { (char - `"` - `\`) | `\` char } `"` .
+//
+// Here char is any character except the line ending and whitespace is any
+// Unicode white space character. Names must be valid UTF-8 and must not
+// contain invisible format characters, like zero-width spaces, or letters of
+// different scripts, like a Cyrillic 'А' among Latin letters, so a name can
+// not look like another name. Trailing blanks are not part of a bare value.
+// Within double quotes the escape sequences \\, \", \n, \r, \t and \$ are
+// replaced by the character they represent, other backslashes are kept.
+// There are no inline comments and no line continuations: a '#' after the '='
+// and a trailing backslash are part of the value.
+//
+// Files that are also sourced by shell scripts can be decoded with the shell
+// dialect of Decoder.SetDialect instead, which follows the quoting, escaping
+// and comment rules of bash and rejects lines it would evaluate differently.
+//
+// By default the decoding functions also accept whitespace around the name and
+// the '=', and names that do not match the grammar as long as they are valid
+// UTF-8. The conformance package holds a corpus of inputs with their expected
+// results.
+//
+// # Concurrency
+//
+// The functions of the package, like Marshal and Unmarshal, are safe for
+// concurrent use, also on values of the same type: the field information of
+// struct types is computed once and shared. RegisterDecoder, RegisterEncoder
+// and SetLegacyNames may be called while other goroutines encode or decode,
+// values that are being converted at that moment may still use the previous
+// conversions. They are meant to be called during initialization.
+//
+// A Decoder, Encoder or Document holds the state of a single stream or file
+// and must only be used by one goroutine at a time. Use a Decoder or Encoder
+// per goroutine, and a SyncDocument for a Document that is shared.
 package envfile
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/basvdlei/envfile/internal/tag"
 )
 
 // ErrorUnsupportedType is returned when the value is or contains unsupported
@@ -34,6 +90,165 @@ func (e ErrorLineParsing) Error() string {
 	return fmt.Sprintf("error parsing line %d", e.LineNumber)
 }
 
+// ErrorBinaryInput is returned when the input contains a NUL byte or another
+// control character that does not occur in text files, which usually means
+// the wrong file was passed. Offset is the byte offset of the character in
+// the input and LineNumber the line it is on.
+type ErrorBinaryInput struct {
+	Offset     int64
+	LineNumber int
+}
+
+// Error implements the error interface.
+func (e ErrorBinaryInput) Error() string {
+	return fmt.Sprintf("input is binary: control character at offset %d on line %d", e.Offset, e.LineNumber)
+}
+
+// ErrorUnknownKey is returned when a variable does not map to a field.
+type ErrorUnknownKey struct {
+	LineNumber int
+	Key        string
+}
+
+// Error implements the error interface.
+func (e ErrorUnknownKey) Error() string {
+	return fmt.Sprintf("unknown variable %q on line %d", e.Key, e.LineNumber)
+}
+
+// ErrorMissingKey is returned when a required variable is not set.
+type ErrorMissingKey struct {
+	Key string
+}
+
+// Error implements the error interface.
+func (e ErrorMissingKey) Error() string {
+	return fmt.Sprintf("missing variable %q", e.Key)
+}
+
+// ErrorValueParsing is returned when a variable value can not be converted to
+// the type of its field.
+type ErrorValueParsing struct {
+	Key string
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrorValueParsing) Error() string {
+	return fmt.Sprintf("error parsing value of %s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrorValueParsing) Unwrap() error {
+	return e.Err
+}
+
+// ErrorFile is returned when reading or decoding a file failed. It adds the
+// path of the file to the underlying error.
+type ErrorFile struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e ErrorFile) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrorFile) Unwrap() error {
+	return e.Err
+}
+
+// ErrorInvalidKey is returned when a variable name can not be written because
+// it would not be read back as the same variable.
+type ErrorInvalidKey struct {
+	Key string
+	Err error
+}
+
+// Error implements the error interface.
+func (e ErrorInvalidKey) Error() string {
+	return fmt.Sprintf("invalid variable name %q: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrorInvalidKey) Unwrap() error {
+	return e.Err
+}
+
+// ErrorInvalidValue is returned when a variable value can not be stored in an
+// environment, because it contains a NUL byte.
+type ErrorInvalidValue struct {
+	Key string
+}
+
+// Error implements the error interface.
+func (e ErrorInvalidValue) Error() string {
+	return fmt.Sprintf("value of %s contains a NUL byte", e.Key)
+}
+
+// ErrorInvalidTarget is returned when the value passed to a decoding function
+// is not a non-nil pointer. Type is the type of the value, nil when the value
+// itself is nil.
+type ErrorInvalidTarget struct {
+	Type reflect.Type
+}
+
+// Error implements the error interface.
+func (e ErrorInvalidTarget) Error() string {
+	if e.Type == nil {
+		return "invalid target nil"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return fmt.Sprintf("invalid target of non-pointer type %v", e.Type)
+	}
+	return fmt.Sprintf("invalid target nil %v", e.Type)
+}
+
+// ErrorDuplicateKey is returned when two fields of a struct map to the same
+// variable, either by their tags or by their derived names.
+type ErrorDuplicateKey struct {
+	Key string
+	// Field and Other are the Go field paths, such as "Database.Host",
+	// of the fields in declaration order.
+	Field string
+	Other string
+}
+
+// Error implements the error interface.
+func (e ErrorDuplicateKey) Error() string {
+	return fmt.Sprintf("variable %q is used by both field %s and field %s", e.Key, e.Field, e.Other)
+}
+
+// ErrorSliceSeparator is returned when an element of a slice contains the
+// comma that separates the elements, so it would be read back as multiple
+// elements.
+type ErrorSliceSeparator struct {
+	Element string
+}
+
+// Error implements the error interface.
+func (e ErrorSliceSeparator) Error() string {
+	return fmt.Sprintf("slice element %q contains the separator %q", e.Element, sliceSeparator)
+}
+
+// ErrorList is returned when multiple errors occurred.
+type ErrorList []error
+
+// Error implements the error interface.
+func (e ErrorList) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors in the list.
+func (e ErrorList) Unwrap() []error {
+	return e
+}
+
 // Marshal returns the EnvironmentFile encoding of v.
 //
 // The "omitempty" option specifies that the field should be omitted from the
@@ -41,124 +256,281 @@ func (e ErrorLineParsing) Error() string {
 //
 // Examples of struct field tags:
 //
-//   // Field appears in EnvironmentFile as variable "MY_NAME".
-//   Field string `env:"MY_NAME"`
+//	// Field appears in EnvironmentFile as variable "MY_NAME".
+//	Field string `env:"MY_NAME"`
+//
+//	// Field appears in EnvironmentFile as variable "FIELD".
+//	Field string`
+//
+//	// Field appears in EnvironmentFile as variable "MAX_RETRIES", the words
+//	// of untagged field names are separated by underscores.
+//	MaxRetries string
+//
+//	// Field appears in EnvironmentFile as variable "MYNAME" and
+//	// the field is omitted from the object if its value is empty.
+//	Field string `env:"MYNAME,omitempty"`
+//
+//	// Field appears in EnvironmentFile as variable "FIELD" (the default), but
+//	// the field is skipped if empty.
+//	// Note the leading comma.
+//	Field int `env:",omitempty"`
+//
+//	// Field appears in EnvironmentFile as variable "LAST" after all fields
+//	// without or with a lower order. The order defaults to 0.
+//	Field string `env:"LAST,order=10"`
+//
+//	// Field appears in EnvironmentFile as variable "MESSAGE" with its value
+//	// enclosed in double quotes.
+//	Field string `env:"MESSAGE,quote"`
+//
+//	// Field appears in EnvironmentFile as variable "PASSWORD" and its value is
+//	// masked by MarshalRedacted.
+//	Field string `env:"PASSWORD,secret"`
+//
+//	// Field appears in EnvironmentFile as variable "API_KEY" with its bytes
+//	// written as hexadecimal. The "base64" option uses standard base64
+//	// encoding instead. Both can be used on string and []byte fields.
+//	Field []byte `env:"API_KEY,hex"`
+//
+//	// Field appears in EnvironmentFile as variable "MAX_UPLOAD" with a size
+//	// like "10Mi" or "512MB", stored as the number of bytes. Binary units
+//	// (Ki, Mi, Gi, ...) count in powers of 1024, decimal units (KB, MB,
+//	// GB, ...) in powers of 1000. The value is written with the unit that
+//	// divides it exactly into the smallest number. Can be used on integer
+//	// fields.
+//	Field int64 `env:"MAX_UPLOAD,bytes"`
+//
+//	// Field appears in EnvironmentFile as variable "TIMEOUT" where a bare
+//	// number like "30" counts seconds, other values are parsed by
+//	// time.ParseDuration. It is written as a number of seconds when it is a
+//	// whole number of them. Any unit accepted by time.ParseDuration can be
+//	// used. Can only be used on time.Duration fields.
+//	Field time.Duration `env:"TIMEOUT,unit=s"`
+//
+//	// Field appears in EnvironmentFile as variable "COLOR" with one of the
+//	// names "red", "green" or "blue", stored as the integer after the name.
+//	// Other values are rejected, and Marshal returns an error when the field
+//	// holds a value without a name. Can be used on integer fields, including
+//	// iota-based constants.
+//	Field Color `env:"COLOR,enum=red:1|green:2|blue:3"`
+//
+//	// Field appears in EnvironmentFile as variable "VALUE". Unmarshal
+//	// stores an int64, float64 or bool when the value is a number or a
+//	// bool, instead of the string stored without the option. Can only be
+//	// used on empty interface fields.
+//	Field any `env:"VALUE,infer"`
+//
+// Unexported fields are always ignored, even when they have an env tag.
+// Pointers to nested structs are followed, the fields of a nil nested struct
+// are omitted.
+//
+// Fields of nested structs appear with the variable name of the struct field
+// and an underscore as prefix. Fields of embedded structs are promoted unless
+// a name is given in the tag:
+//
+//	// Field appears in EnvironmentFile as variables "DB_HOST" and "DB_PORT".
+//	Database struct {
+//	  Host string
+//	  Port string
+//	} `env:"DB"`
 //
-//   // Field appears in EnvironmentFile as variable "FIELD".
-//   Field string`
+// Entries of map fields with string or integer keys appear with the variable
+// name of the field and an underscore as prefix, written in order of their
+// keys so the output is the same for equal maps:
 //
-//   // Field appears in EnvironmentFile as variable "MYNAME" and
-//   // the field is omitted from the object if its value is empty.
-//   Field string `env:"MYNAME,omitempty"`
+//	// Field appears in EnvironmentFile as variables "LABELS_A" and "LABELS_B".
+//	Labels map[string]string
 //
-//   // Field appears in EnvironmentFile as variable "FIELD" (the default), but
-//   // the field is skipped if empty.
-//   // Note the leading comma.
-//   Field int `env:",omitempty"`
+//	// Field appears in EnvironmentFile as variables "WEIGHT_1" and "WEIGHT_2".
+//	// Variables whose name after the prefix is not a number are not part of
+//	// the map.
+//	Weight map[int]int
 //
-// Only string fields are supported and it will return a ErrorUnsupportedType
-// when fields with other types are not explicitly ignored.
+// Maps with slice values hold multiple values per key. Marshal writes them as
+// comma-separated values, while Unmarshal also collects the values of repeated
+// variables, and of variables with a numeric suffix like "HEADER_ACCEPT_2",
+// into the slice of the entry:
+//
+//	// Field holds ["text/html", "application/json"] under key "ACCEPT" for
+//	// both "HEADER_ACCEPT=text/html,application/json" and the variables
+//	// "HEADER_ACCEPT=text/html" and "HEADER_ACCEPT_2=application/json".
+//	Header map[string][]string
+//
+// String, bool, integer, floating point, time.Duration and *time.Location
+// fields, slices of those written as comma-separated values, fields with types
+// implementing encoding.TextMarshaler, fields with a type registered using
+// RegisterEncoder and empty interface fields holding any of those are
+// supported. Types implementing flag.Value are supported as well, their
+// String and Set methods are used when the type implements neither
+// encoding.TextMarshaler nor encoding.TextUnmarshaler. It will return a
+// ErrorUnsupportedType when fields with other types are not explicitly
+// ignored, a ErrorDuplicateKey when two fields map to the same variable and a
+// ErrorSliceSeparator when an element of a slice contains a comma.
 func Marshal(v interface{}) ([]byte, error) {
-	var buf bytes.Buffer
-	t := reflect.TypeOf(v)
-	if t == nil {
-		return []byte{}, nil
+	b, err := AppendMarshal(nil, v)
+	if err != nil {
+		return []byte{}, err
 	}
-	if k := t.Kind(); k != reflect.Struct {
-		return []byte{}, ErrorUnsupportedType{k}
+	return b, nil
+}
+
+// AppendMarshal appends the EnvironmentFile encoding of v to dst and returns
+// the extended buffer. Reusing dst between calls avoids allocating a new
+// output buffer for every call. On error dst is returned unchanged.
+//
+// See the documentation for Marshal for details about the conversion.
+func AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	if b, ok := appendPlain(dst, v); ok {
+		return b, nil
 	}
-	val := reflect.ValueOf(v)
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		keyname, opts := parseFieldOpts(field)
-		if opts.Skip {
-			continue
-		}
-		switch t.Field(i).Type.Kind() {
-		case reflect.String:
-			if !(opts.OmitEmpty && val.Field(i).String() == "") {
-				fmt.Fprintf(&buf, "%s=%s\n", keyname, val.Field(i))
-			}
-		default:
-			return []byte{}, ErrorUnsupportedType{t.Field(i).Type.Kind()}
-		}
+	return appendEncoded(dst, NewEncoder(nil), v)
+}
+
+// appendEncoded appends the encoding of v by enc to dst, using a pooled
+// buffer for the intermediate output.
+func appendEncoded(dst []byte, enc *Encoder, v interface{}) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := enc.encode(buf, v); err != nil {
+		return dst, err
 	}
-	return buf.Bytes(), nil
+	return append(dst, buf.Bytes()...), nil
+}
+
+// Redacted is the mask that replaces sensitive values in redacted output.
+const Redacted = "********"
+
+// MarshalRedacted is like Marshal but replaces the non-empty values of fields
+// with the "secret" option by Redacted while keeping their variable names, so
+// the effective configuration can be logged safely.
+func MarshalRedacted(v interface{}) ([]byte, error) {
+	enc := NewEncoder(nil)
+	enc.SetRedact(true)
+	b, err := appendEncoded(nil, enc, v)
+	if err != nil {
+		return []byte{}, err
+	}
+	return b, nil
 }
 
 // Unmarshal parses the EnvironmentFile encoded data and stores the result in
 // the value pointed to by v.
+//
+// Values are stored in the same field types as Marshal supports, using
+// encoding.TextUnmarshaler and RegisterDecoder instead of their encoding
+// counterparts. Empty interface fields hold the value as a string. Number
+// values are parsed by the strconv package, durations by time.ParseDuration
+// and time zones by time.LoadLocation. Bool values are parsed by
+// strconv.ParseBool, yes/no, on/off and enabled/disabled are accepted as well
+// unless Decoder.SetStrict is used. Conversion errors, like unknown time
+// zones, are returned as a
+// ErrorValueParsing. Leading and trailing whitespace is removed from bare
+// values. Values can be enclosed in single quotes, which are taken literally,
+// or in double quotes, where the escape sequences \\, \", \n, \r, \t and \$
+// are replaced. Nested structs and map fields are decoded using the same
+// variable names as Marshal, a map field is allocated when a variable with its
+// prefix is found and values of maps with slice values are appended to the
+// existing entry. Nil pointers to nested structs are allocated when one of
+// their fields is assigned, and v itself may point to a nil pointer to a
+// struct. Like Marshal, a ErrorDuplicateKey is returned when two
+// fields map to the same variable.
+//
+// The target is checked before any data is parsed: a ErrorInvalidTarget is
+// returned when v is not a non-nil pointer and a ErrorUnsupportedType when it
+// does not point to a struct or the struct has fields of unsupported types.
+//
+// After all values are stored, the Validate method is called on the value and
+// on all nested structs that implement Validator. Their errors are returned as
+// a ErrorList.
 func Unmarshal(data []byte, v interface{}) error {
-	r := bytes.NewReader(data)
-	scanner := bufio.NewScanner(r)
-	count := 0
-	for scanner.Scan() {
-		count++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		kv := strings.SplitN(line, "=", 2)
-		if len(kv) != 2 {
-			return ErrorLineParsing{count}
-		}
-		rv := reflect.ValueOf(v)
-		if rv.Kind() != reflect.Ptr || rv.IsNil() {
-			return ErrorUnsupportedType{rv.Kind()}
-		}
-		t := reflect.TypeOf(v).Elem()
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			keyname, opts := parseFieldOpts(field)
-			if opts.Skip {
-				continue
-			}
-			if strings.TrimSpace(kv[0]) == keyname {
-				field := rv.Elem().Field(i)
-				switch field.Kind() {
-				case reflect.String:
-					if !(opts.OmitEmpty && kv[1] == "") {
-						field.SetString(strings.TrimSpace(kv[1]))
-					}
-				default:
-					return ErrorUnsupportedType{field.Kind()}
-				}
-			}
-		}
+	return NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// UnmarshalContext is like Unmarshal but stops decoding when ctx is done and
+// returns the context error.
+func UnmarshalContext(ctx context.Context, data []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(data)).DecodeContext(ctx, v)
+}
+
+// UnmarshalNoCopy is like Unmarshal but avoids copying values: the strings
+// stored in v, and passed to registered decoders and UnmarshalText methods,
+// borrow the memory of data instead of being copied from it. Values enclosed
+// in double quotes that contain escape sequences are still copied.
+//
+// Because the values share memory with data, data must not be modified for as
+// long as v, or any string taken from it, is in use. This makes it suited for
+// decoding large inputs that are discarded after the values are used.
+func UnmarshalNoCopy(data []byte, v interface{}) error {
+	dec := &Decoder{data: data, borrow: true}
+	return dec.Decode(v)
+}
+
+// line is a variable assignment parsed from a line of EnvironmentFile data.
+type line struct {
+	Key   string
+	Value string
+	// Quote is the quote character the value was enclosed in, or 0 for
+	// bare values.
+	Quote byte
+	// Literal holds the offsets in Value of the '$' characters that were
+	// escaped and are not expanded.
+	Literal []int
+}
+
+// parseLine splits a line into its variable name and value. It returns nil
+// for empty and comment lines and false when the line is not a valid
+// assignment, in which case Key holds the text before the first '='. A
+// leading "export" keyword, as used in shell scripts, is ignored.
+//
+// The parsing is lenient: whitespace around the variable name and around
+// bare values is removed, so "KEY = value" assigns "value" to KEY.
+func parseLine(s string) (l *line, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "#") {
+		return nil, true
+	}
+	s, _ = cutExport(s)
+	kv := strings.SplitN(s, "=", 2)
+	l = &line{Key: strings.TrimSpace(kv[0])}
+	if len(kv) != 2 || !utf8.ValidString(l.Key) {
+		return l, false
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+	l.Value, l.Literal, l.Quote, ok = unquoteLiteral(kv[1])
+	return l, ok
+}
+
+// cutExport removes a leading "export" keyword followed by whitespace from s
+// and reports whether it was found.
+func cutExport(s string) (string, bool) {
+	if strings.HasPrefix(s, "export") && len(s) > 6 && (s[6] == ' ' || s[6] == '\t') {
+		return strings.TrimLeft(s[6:], " \t"), true
 	}
-	return nil
+	return s, false
 }
 
 // envOptions contains the options set in the field.
-type envOptions struct {
-	Skip      bool
-	OmitEmpty bool
+type envOptions = tag.Options
+
+// legacyNames is set by SetLegacyNames.
+var legacyNames atomic.Bool
+
+// SetLegacyNames controls whether the variable names of untagged fields are
+// derived like earlier versions of this package did, by upper-casing the
+// field name without separating its words: HTTPPort becomes HTTPPORT instead
+// of HTTP_PORT. It affects all encoding and decoding and should be called
+// before any of it, for example in an init function.
+func SetLegacyNames(on bool) {
+	legacyNames.Store(on)
+	resetStructCache()
 }
 
 // parseFieldOpts will convert a StructType field tag to an environment name.
+// Malformed tags are not reported, use the envvet analyzer to find them.
 func parseFieldOpts(field reflect.StructField) (name string, opts envOptions) {
-	tag := field.Tag.Get("env")
-	options := strings.Split(tag, ",")
-	if len(options) > 1 {
-		for _, v := range options[1:] {
-			switch v {
-			case "omitempty":
-				opts.OmitEmpty = true
-			}
-		}
-	}
-	switch options[0] {
-	case "-":
-		opts.Skip = true
-	case "":
-		// TODO filter out 'bad' characters
-		name = strings.ToUpper(field.Name)
-	default:
-		// TODO: Make sure the specified variable name is valid.
-		name = options[0]
+	envTag := field.Tag.Get("env")
+	name, opts, _ = tag.Parse(field.Name, envTag)
+	if legacyNames.Load() && !opts.Skip && strings.Split(envTag, ",")[0] == "" {
+		name = tag.LegacyKeyName(field.Name)
 	}
 	return
 }
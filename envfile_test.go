@@ -2,8 +2,11 @@ package envfile
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 )
 
 var marshalCases = []struct {
@@ -37,17 +40,37 @@ var marshalCases = []struct {
 		}{
 			Test: "abc123  ",
 		},
-		Output: []byte("TEST=abc123  \n"),
+		Output: []byte("TEST=\"abc123  \"\n"),
 	},
 	{
 		Name: "tagged unsupported field in struct",
 		Input: struct {
-			Test int `env:"TEST"`
+			Test chan int `env:"TEST"`
 		}{
-			Test: 1,
+			Test: make(chan int),
 		},
 		Output: []byte(""),
-		Error:  ErrorUnsupportedType{reflect.Int},
+		Error:  ErrorUnsupportedType{reflect.Chan},
+	},
+	{
+		Name: "numbers, booleans, durations and slices",
+		Input: struct {
+			Count   int
+			Ratio   float64
+			Enabled bool
+			Timeout time.Duration
+			Hosts   []string
+			Ports   []uint16
+			Zero    int `env:",omitempty"`
+		}{
+			Count:   -3,
+			Ratio:   0.5,
+			Enabled: true,
+			Timeout: 90 * time.Second,
+			Hosts:   []string{"a", "b"},
+			Ports:   []uint16{80, 443},
+		},
+		Output: []byte("COUNT=-3\nRATIO=0.5\nENABLED=true\nTIMEOUT=1m30s\nHOSTS=a,b\nPORTS=80,443\n"),
 	},
 	{
 		Name: "single tagged string field with omitempty",
@@ -69,6 +92,51 @@ var marshalCases = []struct {
 		},
 		Output: []byte("BLA=Blabla\n"),
 	},
+	{
+		Name: "nested struct",
+		Input: struct {
+			Name     string
+			Database struct {
+				Host string
+				Port string `env:"PORTNUMBER"`
+			} `env:"DB"`
+			Cache struct {
+				Host string
+			}
+		}{
+			Name: "app",
+			Database: struct {
+				Host string
+				Port string `env:"PORTNUMBER"`
+			}{
+				Host: "localhost",
+				Port: "5432",
+			},
+		},
+		Output: []byte("NAME=app\nDB_HOST=localhost\nDB_PORTNUMBER=5432\nCACHE_HOST=\n"),
+	},
+	{
+		Name: "embedded struct",
+		Input: struct {
+			embedded
+			Named embedded `env:"NAMED"`
+		}{
+			embedded: embedded{Host: "promoted"},
+			Named:    embedded{Host: "prefixed"},
+		},
+		Output: []byte("HOST=promoted\nNAMED_HOST=prefixed\n"),
+	},
+	{
+		Name: "map field",
+		Input: struct {
+			Labels map[string]string
+			Name   string
+		}{
+			Labels: map[string]string{"zone": "b", "TIER": "web", "app": "x"},
+			Name:   "app",
+		},
+		Output: []byte("LABELS_TIER=web\nLABELS_app=x\nLABELS_zone=b\nNAME=app\n"),
+	},
 	{
 		Name:   "marshal a nil value",
 		Input:  nil,
@@ -85,6 +153,45 @@ var marshalCases = []struct {
 		Output: []byte(""),
 		Error:  ErrorUnsupportedType{reflect.String},
 	},
+	{
+		Name: "nil nested struct pointer is omitted",
+		Input: struct {
+			Name string
+			TLS  *struct {
+				Cert string
+			}
+		}{Name: "app"},
+		Output: []byte("NAME=app\n"),
+	},
+	{
+		Name: "nested struct pointer",
+		Input: struct {
+			Name string
+			TLS  *struct {
+				Cert string
+			}
+		}{Name: "app", TLS: &struct{ Cert string }{"a.pem"}},
+		Output: []byte("NAME=app\nTLS_CERT=a.pem\n"),
+	},
+	{
+		Name: "unexported fields are skipped",
+		Input: struct {
+			Name   string
+			secret string `env:"SECRET"`
+			count  chan int
+			embedded
+		}{Name: "app", secret: "s", embedded: embedded{Host: "localhost"}},
+		Output: []byte("NAME=app\nHOST=localhost\n"),
+	},
+	{
+		Name: "tagged and derived name map to the same variable",
+		Input: struct {
+			MaxRetries string
+			Retries    string `env:"MAX_RETRIES"`
+		}{},
+		Output: []byte(""),
+		Error:  ErrorDuplicateKey{"MAX_RETRIES", "MaxRetries", "Retries"},
+	},
 }
 
 func TestMarshal(t *testing.T) {
@@ -101,6 +208,10 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+type embedded struct {
+	Host string
+}
+
 var unmarshalCases = []struct {
 	Name   string
 	Input  []byte
@@ -209,6 +320,26 @@ BAR_WHERE=bar123
 		}{},
 		Error: ErrorLineParsing{2},
 	},
+	{
+		Name:  "export prefix",
+		Input: []byte("export TEST=abc123\nexport\tEXPORTED=yes\n"),
+		Output: struct {
+			Test     string `env:"TEST"`
+			Exported string `env:"EXPORTED"`
+		}{
+			Test:     "abc123",
+			Exported: "yes",
+		},
+	},
+	{
+		Name:  "variable named export",
+		Input: []byte("export=abc123\n"),
+		Output: struct {
+			Export string `env:"export"`
+		}{
+			Export: "abc123",
+		},
+	},
 	{
 		Name:  "lines with equal in value",
 		Input: []byte("TEST=abc123=123=a\n"),
@@ -226,7 +357,66 @@ BAR_WHERE=bar123
 		}{
 			Test: 1,
 		},
-		Error: ErrorUnsupportedType{reflect.Int},
+	},
+	{
+		Name:  "target struct contains tagged chan value",
+		Input: []byte("TEST=1\n"),
+		Output: struct {
+			Test chan int `env:"TEST"`
+		}{},
+		Error: ErrorUnsupportedType{reflect.Chan},
+	},
+	{
+		Name:  "nested struct pointer is allocated",
+		Input: []byte("NAME=app\nTLS_CERT=a.pem\n"),
+		Output: struct {
+			Name string
+			TLS  *struct {
+				Cert string
+			}
+			Proxy *struct {
+				Host string
+			}
+		}{Name: "app", TLS: &struct{ Cert string }{"a.pem"}},
+	},
+	{
+		Name:  "unexported fields are skipped",
+		Input: []byte("NAME=app\nSECRET=s\nHOST=localhost\n"),
+		Output: struct {
+			Name   string
+			secret string `env:"SECRET"`
+			count  chan int
+			embedded
+		}{Name: "app", embedded: embedded{Host: "localhost"}},
+	},
+	{
+		Name:  "promoted field maps to the same variable",
+		Input: []byte("HOST=localhost\n"),
+		Output: struct {
+			embedded
+			Host string
+		}{},
+		Error: ErrorDuplicateKey{"HOST", "embedded.Host", "Host"},
+	},
+	{
+		Name:  "numbers, booleans, durations and slices",
+		Input: []byte("COUNT=-3\nRATIO=0.5\nENABLED=1\nTIMEOUT=1m30s\nHOSTS=a, b\nPORTS=80,443\nEMPTY=\n"),
+		Output: struct {
+			Count   int
+			Ratio   float64
+			Enabled bool
+			Timeout time.Duration
+			Hosts   []string
+			Ports   []uint16
+			Empty   []string
+		}{
+			Count:   -3,
+			Ratio:   0.5,
+			Enabled: true,
+			Timeout: 90 * time.Second,
+			Hosts:   []string{"a", "b"},
+			Ports:   []uint16{80, 443},
+		},
 	},
 	{
 		Name:  "target struct contains ignored int value",
@@ -244,6 +434,40 @@ BAR_WHERE=bar123
 		}{},
 		Error: nil,
 	},
+	{
+		Name: "nested and embedded structs",
+		Input: []byte(`HOST=promoted
+DB_HOST=localhost
+DB_PORT=5432
+`),
+		Output: struct {
+			embedded
+			Database struct {
+				Host string
+				Port string
+			} `env:"DB"`
+		}{
+			embedded: embedded{Host: "promoted"},
+			Database: struct {
+				Host string
+				Port string
+			}{
+				Host: "localhost",
+				Port: "5432",
+			},
+		},
+	},
+	{
+		Name:  "map field",
+		Input: []byte("NAME=app\nLABELS_TIER=web\nLABELS_app=x\nLABELS_=ignored\n"),
+		Output: struct {
+			Name   string
+			Labels map[string]string `env:",omitempty"`
+		}{
+			Name:   "app",
+			Labels: map[string]string{"TIER": "web", "app": "x"},
+		},
+	},
 	{
 		Name:  "target struct contains omitempty string field",
 		Input: []byte("TEST=\n"),
@@ -275,6 +499,380 @@ func TestUnmarshall(t *testing.T) {
 	}
 }
 
+type recursive struct {
+	Name string
+	Next *recursive
+}
+
+func TestUnmarshalPointerTarget(t *testing.T) {
+	var got *recursive
+	if err := Unmarshal([]byte("NAME=first\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if got == nil || got.Name != "first" || got.Next != nil {
+		t.Errorf("output does not match, got %+v", got)
+	}
+	var empty *recursive
+	if err := Unmarshal([]byte(""), &empty); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if empty != nil {
+		t.Errorf("pointer was allocated without input, got %+v", empty)
+	}
+}
+
+func TestEncodingOptions(t *testing.T) {
+	type config struct {
+		Key   []byte `env:"KEY,hex"`
+		Token string `env:"TOKEN,base64"`
+		Empty []byte `env:"EMPTY,hex"`
+	}
+	in := config{Key: []byte{0xde, 0xad, 0xbe, 0xef}, Token: "secret"}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "KEY=deadbeef\nTOKEN=c2VjcmV0\nEMPTY=\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	var got config
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(in, got) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", in, got)
+	}
+	err = Unmarshal([]byte("KEY=xyz\n"), &got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "KEY" {
+		t.Errorf("error did not match, want: ErrorValueParsing for KEY, got %v", err)
+	}
+	var bad struct {
+		Count int `env:"COUNT,hex"`
+	}
+	if err := Unmarshal([]byte(""), &bad); err != (ErrorUnsupportedType{reflect.Int}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.Int}, err)
+	}
+}
+
+func TestSizeOption(t *testing.T) {
+	type config struct {
+		Upload int64  `env:"MAX_UPLOAD,bytes"`
+		Memory uint32 `env:"MEMORY,bytes"`
+		Odd    int    `env:"ODD,bytes"`
+	}
+	in := config{Upload: 10 << 20, Memory: 512e6, Odd: 1023}
+	out, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "MAX_UPLOAD=10Mi\nMEMORY=512MB\nODD=1023\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	var got config
+	if err := Unmarshal(out, &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(in, got) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", in, got)
+	}
+	for in, want := range map[string]int64{
+		"1024":  1024,
+		"2k":    2000,
+		"2KiB":  2048,
+		"1.5Gi": 3 << 29,
+		"3 mb":  3e6,
+		"100B":  100,
+		"0":     0,
+		"-1Ki":  -1024,
+	} {
+		var c config
+		if err := Unmarshal([]byte("MAX_UPLOAD="+in+"\nMEMORY=0\nODD=0\n"), &c); err != nil {
+			t.Errorf("[%s] unmarshal returned an error: %v", in, err)
+		} else if c.Upload != want {
+			t.Errorf("[%s] size did not match, want: %d, got %d", in, want, c.Upload)
+		}
+	}
+	for _, in := range []string{"MAX_UPLOAD=10Xi", "MAX_UPLOAD=0.5", "MEMORY=8Gi", "MEMORY=-1"} {
+		var c config
+		err := Unmarshal([]byte(in+"\n"), &c)
+		if _, ok := err.(ErrorValueParsing); !ok {
+			t.Errorf("[%s] error did not match, want: ErrorValueParsing, got %v", in, err)
+		}
+	}
+}
+
+func TestUnitOption(t *testing.T) {
+	type config struct {
+		Timeout time.Duration `env:"TIMEOUT,unit=s"`
+		Delay   time.Duration `env:"DELAY,unit=ms"`
+	}
+	var got config
+	if err := Unmarshal([]byte("TIMEOUT=30\nDELAY=1.5s\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if want := (config{30 * time.Second, 1500 * time.Millisecond}); got != want {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	got.Timeout += 500 * time.Millisecond
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "TIMEOUT=30.5s\nDELAY=1500\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	err = Unmarshal([]byte("TIMEOUT=soon\nDELAY=1\n"), &got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "TIMEOUT" {
+		t.Errorf("error did not match, want: ErrorValueParsing for TIMEOUT, got %v", err)
+	}
+	var bad struct {
+		Timeout int64 `env:"TIMEOUT,unit=s"`
+	}
+	if err := Unmarshal([]byte(""), &bad); err != (ErrorUnsupportedType{reflect.Int64}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.Int64}, err)
+	}
+}
+
+func TestEnumOption(t *testing.T) {
+	type color uint8
+	const (
+		red color = iota
+		green
+		blue
+	)
+	type config struct {
+		Color color `env:"COLOR,enum=red:0|green:1|blue:2"`
+		Level int   `env:"LEVEL,enum=debug:-4|info:0|warn:4"`
+	}
+	var got config
+	if err := Unmarshal([]byte("COLOR=blue\nLEVEL=debug\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if want := (config{blue, -4}); got != want {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	got.Color = green
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "COLOR=green\nLEVEL=debug\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	for _, in := range []string{"COLOR=2\nLEVEL=info\n", "COLOR=Blue\nLEVEL=info\n"} {
+		err = Unmarshal([]byte(in), &got)
+		if e, ok := err.(ErrorValueParsing); !ok || e.Key != "COLOR" {
+			t.Errorf("[%q] error did not match, want: ErrorValueParsing for COLOR, got %v", in, err)
+		}
+	}
+	if _, err := Marshal(config{Color: 7}); err == nil {
+		t.Errorf("marshal of a value without a name did not return an error")
+	}
+}
+
+func TestInterfaceField(t *testing.T) {
+	type config struct {
+		Raw    any
+		Values []any       `env:",omitempty"`
+		Count  interface{} `env:",infer"`
+		Ratio  any         `env:",infer"`
+		Debug  any         `env:",infer"`
+		Name   any         `env:",infer"`
+	}
+	var got config
+	input := "RAW=42\nVALUES=a, 1\nCOUNT=42\nRATIO=0.5\nDEBUG=true\nNAME=inf\n"
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	want := config{"42", []any{"a", "1"}, int64(42), 0.5, true, "inf"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output does not match\nwant:\n%#v,\tgot\n%#v", want, got)
+	}
+	out, err := Marshal(config{Raw: time.Minute, Count: 3, Ratio: nil, Debug: false, Name: "x"})
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "RAW=1m0s\nCOUNT=3\nRATIO=\nDEBUG=false\nNAME=x\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	if _, err := Marshal(config{Raw: make(chan int)}); err != (ErrorUnsupportedType{reflect.Chan}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.Chan}, err)
+	}
+}
+
+// level implements flag.Value but not encoding.TextMarshaler.
+type level int
+
+func (l *level) Set(s string) error {
+	switch s {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func (l *level) String() string {
+	return [...]string{"", "low", "high"}[*l]
+}
+
+func TestFlagValueField(t *testing.T) {
+	type config struct {
+		Level  level
+		Levels []level
+	}
+	var got config
+	if err := Unmarshal([]byte("LEVEL=high\nLEVELS=low, high\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if want := (config{2, []level{1, 2}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "LEVEL=high\nLEVELS=low,high\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	err = Unmarshal([]byte("LEVEL=medium\nLEVELS=\n"), &got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "LEVEL" {
+		t.Errorf("error did not match, want: ErrorValueParsing for LEVEL, got %v", err)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	type config struct {
+		TZ    *time.Location
+		Other *time.Location `env:",omitempty"`
+	}
+	var got config
+	if err := Unmarshal([]byte("TZ=Europe/Amsterdam\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if got.TZ == nil || got.TZ.String() != "Europe/Amsterdam" {
+		t.Errorf("location did not match, got %v", got.TZ)
+	}
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "TZ=Europe/Amsterdam\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	err = Unmarshal([]byte("TZ=Mars/Olympus_Mons\n"), &got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "TZ" {
+		t.Errorf("error did not match, want: ErrorValueParsing for TZ, got %v", err)
+	}
+}
+
+func TestTextMarshalerPointer(t *testing.T) {
+	type config struct {
+		Start *time.Time
+		End   *time.Time
+	}
+	out, err := Marshal(config{})
+	if err != nil {
+		t.Fatalf("marshal of nil pointers returned an error: %v", err)
+	}
+	if want := "START=\nEND=\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := config{End: &start}
+	if err := Unmarshal([]byte("START=2024-01-02T03:04:05Z\nEND=\n"), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if got.Start == nil || !got.Start.Equal(start) || got.End != nil {
+		t.Errorf("output did not match, got %v and %v", got.Start, got.End)
+	}
+	out, err = Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "START=2024-01-02T03:04:05Z\nEND=\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	err = Unmarshal([]byte("START=yesterday\n"), &got)
+	if e, ok := err.(ErrorValueParsing); !ok || e.Key != "START" {
+		t.Errorf("error did not match, want: ErrorValueParsing for START, got %v", err)
+	}
+}
+
+func TestMarshalMapDeterministic(t *testing.T) {
+	v := struct {
+		Labels map[string]string
+	}{
+		Labels: make(map[string]string),
+	}
+	for _, k := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+		v.Labels[k] = k
+	}
+	want, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		if got, _ := Marshal(v); !bytes.Equal(want, got) {
+			t.Fatalf("output differs between calls\nfirst:\n%q,\tgot\n%q", want, got)
+		}
+	}
+}
+
+func TestMultiValuedMap(t *testing.T) {
+	type config struct {
+		Header map[string][]string
+	}
+	input := "HEADER_ACCEPT=text/html\nHEADER_X_FORWARDED_FOR=a,b\nHEADER_ACCEPT_2=application/json\nHEADER_ACCEPT=*/*\n"
+	var got config
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	want := config{Header: map[string][]string{
+		"ACCEPT":          {"text/html", "application/json", "*/*"},
+		"X_FORWARDED_FOR": {"a", "b"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	out, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "HEADER_ACCEPT=text/html,application/json,*/*\nHEADER_X_FORWARDED_FOR=a,b\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+}
+
+func TestIntegerKeyedMap(t *testing.T) {
+	type config struct {
+		Weight map[int]int
+		Level  map[uint8]string
+	}
+	input := "WEIGHT_10=1\nWEIGHT_2=30\nWEIGHT_-1=5\nWEIGHT_X=7\nLEVEL_3=high\nLEVEL_300=overflow\n"
+	var got config
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	want := config{
+		Weight: map[int]int{-1: 5, 2: 30, 10: 1},
+		Level:  map[uint8]string{3: "high"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	out, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "WEIGHT_-1=5\nWEIGHT_2=30\nWEIGHT_10=1\nLEVEL_3=high\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+}
+
 func TestUnmarshalIntoNil(t *testing.T) {
 	err := Unmarshal([]byte("TEST=123"), nil)
 	if err == nil {
@@ -282,6 +880,48 @@ func TestUnmarshalIntoNil(t *testing.T) {
 	}
 }
 
+// version implements encoding.TextMarshaler but not
+// encoding.TextUnmarshaler.
+type version struct {
+	major, minor int
+}
+
+func (v version) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(v.major) + "." + strconv.Itoa(v.minor)), nil
+}
+
+func TestUnmarshalInvalidTarget(t *testing.T) {
+	type config struct {
+		Name string
+	}
+	var nilConfig *config
+	cases := []struct {
+		Name   string
+		Target interface{}
+		Error  error
+	}{
+		{"nil", nil, ErrorInvalidTarget{}},
+		{"non-pointer", config{}, ErrorInvalidTarget{reflect.TypeOf(config{})}},
+		{"nil pointer", nilConfig, ErrorInvalidTarget{reflect.TypeOf(nilConfig)}},
+		{"pointer to non-struct", new(string), ErrorUnsupportedType{reflect.String}},
+		{"unsupported field", &struct{ C chan int }{}, ErrorUnsupportedType{reflect.Chan}},
+		{"marshal only field", &struct{ V version }{}, ErrorUnsupportedType{reflect.Struct}},
+		{"marshal only slice", &struct{ V []version }{}, ErrorUnsupportedType{reflect.Slice}},
+		{"duplicate key", &struct {
+			A string `env:"X"`
+			B string `env:"X"`
+		}{}, ErrorDuplicateKey{"X", "A", "B"}},
+	}
+	for _, c := range cases {
+		// The target is checked even without any input.
+		for _, input := range []string{"", "# comment\n", "NAME=app\n"} {
+			if err := Unmarshal([]byte(input), c.Target); err != c.Error {
+				t.Errorf("[%s] error did not match for %q, want: %v, got %v", c.Name, input, c.Error, err)
+			}
+		}
+	}
+}
+
 func TestErrors(t *testing.T) {
 	var (
 		err  error
@@ -297,4 +937,211 @@ func TestErrors(t *testing.T) {
 	if err.Error() != want {
 		t.Errorf("error did not match, want %q, got %q", want, err.Error())
 	}
+	err = ErrorInvalidTarget{reflect.TypeOf("")}
+	want = "invalid target of non-pointer type string"
+	if err.Error() != want {
+		t.Errorf("error did not match, want %q, got %q", want, err.Error())
+	}
+	err = ErrorDuplicateKey{"DB_HOST", "DBHost", "Database.Host"}
+	want = `variable "DB_HOST" is used by both field DBHost and field Database.Host`
+	if err.Error() != want {
+		t.Errorf("error did not match, want %q, got %q", want, err.Error())
+	}
+}
+
+type benchmarkConfig struct {
+	Name     string
+	Host     string `env:"HOST"`
+	Port     string `env:"PORT"`
+	Optional string `env:"OPTIONAL,omitempty"`
+	Database struct {
+		Host     string
+		User     string
+		Password string
+	} `env:"DB"`
+}
+
+func newBenchmarkConfig() benchmarkConfig {
+	var v benchmarkConfig
+	v.Name = "app"
+	v.Host = "localhost"
+	v.Port = "8080"
+	v.Database.Host = "db.example.com"
+	v.Database.User = "app"
+	v.Database.Password = "secret"
+	return v
+}
+
+func TestMarshalPlain(t *testing.T) {
+	v := newBenchmarkConfig()
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(v); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), got) {
+		t.Errorf("fast path output did not match encoder\nwant:\n%q,\tgot\n%q", buf.Bytes(), got)
+	}
+	// Box the value once, so only the allocations of Marshal are counted.
+	var iv interface{} = v
+	if n := testing.AllocsPerRun(10, func() { Marshal(iv) }); n > 1 {
+		t.Errorf("marshal of a string-only struct allocated %v times, want 1", n)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	values := []string{
+		`'single`,
+		`"x" y`,
+		`"quoted"`,
+		`'quoted'`,
+		" leading space",
+		"trailing tab\t",
+		"line\nbreak",
+		"carriage\rreturn",
+		"\u00a0nbsp",
+		"plain value # not a comment",
+		"",
+	}
+	for _, value := range values {
+		// The first struct is encoded by the fast path of Marshal, the
+		// second by the Encoder.
+		plain := struct{ Value string }{value}
+		typed := struct {
+			Value string
+			Port  int
+		}{value, 80}
+		for _, v := range []interface{}{plain, typed} {
+			data, err := Marshal(v)
+			if err != nil {
+				t.Fatalf("[%q] marshal returned an error: %v", value, err)
+			}
+			var got struct{ Value string }
+			if err := Unmarshal(data, &got); err != nil {
+				t.Errorf("[%q] unmarshal of %q returned an error: %v", value, data, err)
+				continue
+			}
+			if got.Value != value {
+				t.Errorf("[%q] value did not match, want: %q, got %q", value, value, got.Value)
+			}
+		}
+	}
+}
+
+func TestMarshalSliceRoundTrip(t *testing.T) {
+	type config struct {
+		Names []string
+	}
+	in := config{[]string{"a", "b c", "d"}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	var got config
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal of %q returned an error: %v", data, err)
+	}
+	if !reflect.DeepEqual(in, got) {
+		t.Errorf("round trip did not match, want: %q, got %q", in.Names, got.Names)
+	}
+
+	_, err = Marshal(config{[]string{"a,b", "c"}})
+	if want := (ErrorSliceSeparator{"a,b"}); err != want {
+		t.Errorf("error did not match, want: %v, got %v", want, err)
+	}
+	_, err = Marshal(struct{ Hosts map[string][]string }{map[string][]string{"A": {"x,y"}}})
+	if want := (ErrorSliceSeparator{"x,y"}); err != want {
+		t.Errorf("error for map entry did not match, want: %v, got %v", want, err)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	v := newBenchmarkConfig()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncoder(b *testing.B) {
+	v := newBenchmarkConfig()
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := NewEncoder(&buf).Encode(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAppendMarshal(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Input interface{}
+	}{
+		{Name: "plain struct", Input: newBenchmarkConfig()},
+		{Name: "ordered struct", Input: orderedConfig{Zoo: "z"}},
+	}
+	for _, c := range cases {
+		want, err := Marshal(c.Input)
+		if err != nil {
+			t.Fatalf("[%s] marshal returned an error: %v", c.Name, err)
+		}
+		dst := []byte("# header\n")
+		got, err := AppendMarshal(dst, c.Input)
+		if err != nil {
+			t.Fatalf("[%s] append marshal returned an error: %v", c.Name, err)
+		}
+		if string(got) != "# header\n"+string(want) {
+			t.Errorf("[%s] output did not match\nwant:\n%q,\tgot\n%q", c.Name, "# header\n"+string(want), got)
+		}
+	}
+	dst := []byte("KEEP=1\n")
+	got, err := AppendMarshal(dst, "blablabla")
+	if err != (ErrorUnsupportedType{reflect.String}) || string(got) != string(dst) {
+		t.Errorf("error case did not return dst unchanged, got %q, %v", got, err)
+	}
+}
+
+func BenchmarkAppendMarshal(b *testing.B) {
+	v := orderedConfig{Zoo: "z", Host: "localhost", Alpha: "a", Last: "l"}
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = AppendMarshal(buf[:0], v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestKeyNameDerivation(t *testing.T) {
+	v := struct {
+		HTTPPort   string
+		MaxRetries string
+		Tagged     string `env:"MY_TAG"`
+	}{}
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "HTTP_PORT=\nMAX_RETRIES=\nMY_TAG=\n"; string(got) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	SetLegacyNames(true)
+	defer SetLegacyNames(false)
+	got, err = Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "HTTPPORT=\nMAXRETRIES=\nMY_TAG=\n"; string(got) != want {
+		t.Errorf("legacy output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
 }
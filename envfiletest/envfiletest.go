@@ -0,0 +1,106 @@
+// Package envfiletest implements utility routines for testing code that uses
+// the envfile package.
+package envfiletest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/basvdlei/envfile"
+)
+
+var update = flag.Bool("envfile.update", false, "update envfile golden files")
+
+// AssertGolden marshals v and compares the output with the contents of the
+// golden file at path. When the test binary is run with the -envfile.update
+// flag the golden file is (re)written instead.
+func AssertGolden(t testing.TB, path string, v interface{}) {
+	t.Helper()
+	got, err := envfile.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("marshal output does not match golden file %s\nwant:\n%s\ngot:\n%s",
+			path, want, got)
+	}
+}
+
+// AssertRoundTrip marshals v, unmarshals the result into a new value of the
+// same type and checks that it is equal to v. The value v can be a struct or
+// a pointer to a struct.
+func AssertRoundTrip(t testing.TB, v interface{}) {
+	t.Helper()
+	data, err := envfile.Marshal(reflect.Indirect(reflect.ValueOf(v)).Interface())
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	got := reflect.New(reflect.Indirect(reflect.ValueOf(v)).Type())
+	if err := envfile.Unmarshal(data, got.Interface()); err != nil {
+		t.Fatalf("unmarshal %T: %v", v, err)
+	}
+	want := reflect.Indirect(reflect.ValueOf(v)).Interface()
+	if !reflect.DeepEqual(want, got.Elem().Interface()) {
+		t.Errorf("round trip of %T does not match\nwant:\n%+v\ngot:\n%+v\nencoded:\n%s",
+			v, want, got.Elem().Interface(), data)
+	}
+}
+
+// TempFile writes data to a new file in a temporary directory and returns its
+// path. The file is removed when the test and all its subtests complete.
+func TempFile(t testing.TB, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("write temporary env file: %v", err)
+	}
+	return path
+}
+
+// MarshalTempFile writes the EnvironmentFile encoding of v to a new file in a
+// temporary directory and returns its path. The file is removed when the test
+// and all its subtests complete.
+func MarshalTempFile(t testing.TB, v interface{}) string {
+	t.Helper()
+	data, err := envfile.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	return TempFile(t, data)
+}
+
+// Setenv reads the EnvironmentFile at path and sets every variable in it with
+// t.Setenv, so the previous values are restored when the test and all its
+// subtests complete. Like t.Setenv, it can not be used in parallel tests.
+func Setenv(t testing.TB, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read env file: %v", err)
+	}
+	doc, err := envfile.ParseDocument(data)
+	if err != nil {
+		t.Fatalf("parse env file %s: %v", path, err)
+	}
+	for _, key := range doc.Keys() {
+		value, _ := doc.Get(key)
+		t.Setenv(key, value)
+	}
+}
@@ -0,0 +1,50 @@
+package envfiletest
+
+import (
+	"os"
+	"testing"
+)
+
+type config struct {
+	Name      string
+	MySetting string `env:"MY_SETTING"`
+	Empty     string `env:",omitempty"`
+}
+
+var testConfig = config{
+	Name:      "foo",
+	MySetting: "https://127.0.0.1",
+}
+
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, "testdata/config.env", testConfig)
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	AssertRoundTrip(t, testConfig)
+	AssertRoundTrip(t, &testConfig)
+}
+
+func TestTempFile(t *testing.T) {
+	path := MarshalTempFile(t, testConfig)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME=foo\nMY_SETTING=https://127.0.0.1\n"
+	if string(got) != want {
+		t.Errorf("temporary file did not match, want %q, got %q", want, got)
+	}
+}
+
+func TestSetenv(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		Setenv(t, "testdata/config.env")
+		if got := os.Getenv("MY_SETTING"); got != "https://127.0.0.1" {
+			t.Errorf("variable was not set, got %q", got)
+		}
+	})
+	if _, ok := os.LookupEnv("MY_SETTING"); ok {
+		t.Errorf("variable was not restored after the test")
+	}
+}
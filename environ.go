@@ -0,0 +1,130 @@
+package envfile
+
+import (
+	"reflect"
+	"strings"
+)
+
+// UnmarshalEnviron stores the variables of the process environment in the
+// struct pointed to by v. Only fields whose variable is set are assigned, all
+// other fields are left untouched.
+//
+// Like Unmarshal, it calls the Validate method of all Validator values after
+// the fields are assigned.
+func UnmarshalEnviron(v interface{}) error {
+	return UnmarshalEnvironFrom(ProcessEnvironment, v)
+}
+
+// UnmarshalEnvironFrom is like UnmarshalEnviron but reads the variables from
+// env instead of the process environment.
+func UnmarshalEnvironFrom(env Environment, v interface{}) error {
+	if err := checkTarget(v); err != nil {
+		return err
+	}
+	if err := decodeEnviron(env, v, "", false, nil); err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+// ApplyToProcess sets a process environment variable for every field of the
+// struct v, or of the struct v points to, as Marshal would write them. It is
+// the inverse of UnmarshalEnviron and meant for programs that must prepare
+// their own environment before executing other programs or initializing
+// libraries that read it.
+//
+// Fields with the "omitempty" option holding an empty value are not set and
+// keep their current value. Fields with the "secret" option are set to their
+// real value, the option only masks values in redacted output.
+//
+// All names and values are checked before the first variable is set, so on
+// error the environment is left unchanged. Values containing a NUL byte are
+// rejected with a ErrorInvalidValue.
+func ApplyToProcess(v interface{}) error {
+	return ApplyToEnvironment(ProcessEnvironment, v)
+}
+
+// ApplyToEnvironment is like ApplyToProcess but sets the variables in env
+// instead of the process environment.
+func ApplyToEnvironment(env Environment, v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		v = rv.Elem().Interface()
+	}
+	vars, err := encodeVars(v, ZeroByTag)
+	if err != nil {
+		return err
+	}
+	if err := checkKeys(vars, false); err != nil {
+		return err
+	}
+	for _, p := range vars {
+		if strings.IndexByte(p.Value, 0) >= 0 {
+			return ErrorInvalidValue{p.Key}
+		}
+	}
+	for _, p := range vars {
+		if err := env.Set(p.Key, p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeEnviron assigns the variables that are set in env to the fields of v.
+// Only variables starting with prefix are considered and the prefix is
+// removed before matching when strip is set. When not nil, the set function
+// is called with the name and value of every assigned variable.
+func decodeEnviron(env Environment, v interface{}, prefix string, strip bool, set func(key, value string)) error {
+	rv, err := targetStruct(v)
+	if err != nil {
+		return err
+	}
+	for _, f := range typeFields(rv.Type()) {
+		keyname := f.Name
+		envKey := keyname
+		if strip {
+			envKey = prefix + keyname
+		}
+		if !strings.HasPrefix(envKey, prefix) {
+			continue
+		}
+		if f.Map {
+			if err := decodeEnvironMap(env, v, f.Name+"_", envKey+"_", set); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := env.Lookup(envKey)
+		if !ok {
+			continue
+		}
+		assigned, err := assign(v, keyname, value, false)
+		if err != nil {
+			return err
+		}
+		if assigned && set != nil {
+			set(keyname, value)
+		}
+	}
+	return nil
+}
+
+// decodeEnvironMap assigns all variables in env starting with envPrefix as
+// entries of a map field whose variables start with prefix.
+func decodeEnvironMap(env Environment, v interface{}, prefix, envPrefix string, set func(key, value string)) error {
+	for _, kv := range env.List() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], envPrefix) {
+			continue
+		}
+		keyname := prefix + strings.TrimPrefix(parts[0], envPrefix)
+		assigned, err := assign(v, keyname, parts[1], false)
+		if err != nil {
+			return err
+		}
+		if assigned && set != nil {
+			set(keyname, parts[1])
+		}
+	}
+	return nil
+}
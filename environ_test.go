@@ -0,0 +1,54 @@
+package envfile
+
+import (
+	"os"
+	"testing"
+)
+
+func TestApplyToProcess(t *testing.T) {
+	t.Setenv("ENVFILE_TEST_APPLY_HOST", "envhost")
+	t.Setenv("ENVFILE_TEST_APPLY_PORT", "80")
+	t.Setenv("ENVFILE_TEST_APPLY_TOKEN", "")
+	v := struct {
+		Host  string `env:"ENVFILE_TEST_APPLY_HOST"`
+		Port  string `env:"ENVFILE_TEST_APPLY_PORT,omitempty"`
+		Token string `env:"ENVFILE_TEST_APPLY_TOKEN,secret"`
+	}{
+		Host:  "structhost",
+		Token: "s3cret",
+	}
+	if err := ApplyToProcess(&v); err != nil {
+		t.Fatalf("apply returned an error: %v", err)
+	}
+	for key, want := range map[string]string{
+		"ENVFILE_TEST_APPLY_HOST":  "structhost",
+		"ENVFILE_TEST_APPLY_PORT":  "80",
+		"ENVFILE_TEST_APPLY_TOKEN": "s3cret",
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("[%s] value did not match, want: %q, got %q", key, want, got)
+		}
+	}
+
+	var got struct {
+		Host  string `env:"ENVFILE_TEST_APPLY_HOST"`
+		Token string `env:"ENVFILE_TEST_APPLY_TOKEN"`
+	}
+	if err := UnmarshalEnviron(&got); err != nil {
+		t.Fatalf("unmarshal environ returned an error: %v", err)
+	}
+	if got.Host != v.Host || got.Token != v.Token {
+		t.Errorf("round trip did not match, want: %+v, got %+v", v, got)
+	}
+
+	bad := struct {
+		Host string `env:"ENVFILE_TEST_APPLY_HOST"`
+		Port string `env:"ENVFILE_TEST_APPLY_PORT"`
+	}{"otherhost", "8\x000"}
+	if err := ApplyToProcess(bad); err != (ErrorInvalidValue{"ENVFILE_TEST_APPLY_PORT"}) {
+		t.Errorf("error for value with NUL byte did not match, got %v", err)
+	}
+	if got := os.Getenv("ENVFILE_TEST_APPLY_HOST"); got != "structhost" {
+		t.Errorf("environment was modified on error: %q", got)
+	}
+}
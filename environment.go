@@ -0,0 +1,68 @@
+package envfile
+
+import (
+	"os"
+	"sort"
+)
+
+// An Environment holds environment variables. It is used instead of the
+// process environment by UnmarshalEnvironFrom, UnmarshalLayersFrom,
+// ApplyToEnvironment and by Decoders and Loaders configured with
+// SetEnvironment, so tests and sandboxed runtimes can provide their own
+// variables.
+type Environment interface {
+	// Lookup returns the value of the variable key and whether it is set.
+	Lookup(key string) (value string, ok bool)
+	// Set sets the value of the variable key.
+	Set(key, value string) error
+	// List returns all variables as "key=value" entries, like os.Environ.
+	List() []string
+}
+
+// ProcessEnvironment is the Environment of the current process, backed by
+// the functions of the os package.
+var ProcessEnvironment Environment = processEnvironment{}
+
+type processEnvironment struct{}
+
+func (processEnvironment) Lookup(key string) (string, bool) { return os.LookupEnv(key) }
+func (processEnvironment) Set(key, value string) error      { return os.Setenv(key, value) }
+func (processEnvironment) List() []string                   { return os.Environ() }
+
+// MapEnvironment is an in-memory Environment. List returns the entries
+// sorted by name.
+type MapEnvironment map[string]string
+
+// Lookup returns the value of the variable key and whether it is set.
+func (m MapEnvironment) Lookup(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Set sets the value of the variable key.
+func (m MapEnvironment) Set(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+// List returns all variables as "key=value" entries.
+func (m MapEnvironment) List() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	entries := make([]string, len(keys))
+	for i, k := range keys {
+		entries[i] = k + "=" + m[k]
+	}
+	return entries
+}
+
+// environment returns env, or the process environment when env is nil.
+func environment(env Environment) Environment {
+	if env == nil {
+		return ProcessEnvironment
+	}
+	return env
+}
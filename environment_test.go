@@ -0,0 +1,96 @@
+package envfile
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMapEnvironment(t *testing.T) {
+	env := MapEnvironment{"PORT": "80"}
+	if err := env.Set("HOST", "example.com"); err != nil {
+		t.Fatalf("set returned an error: %v", err)
+	}
+	if v, ok := env.Lookup("HOST"); !ok || v != "example.com" {
+		t.Errorf("lookup did not match, got %q, %v", v, ok)
+	}
+	if _, ok := env.Lookup("USER"); ok {
+		t.Errorf("lookup of unset variable reported it as set")
+	}
+	if want, got := []string{"HOST=example.com", "PORT=80"}, env.List(); !reflect.DeepEqual(want, got) {
+		t.Errorf("list did not match, want: %v, got %v", want, got)
+	}
+}
+
+func TestEnvironmentRoundTrip(t *testing.T) {
+	type config struct {
+		Host   string
+		Port   int               `env:",omitempty"`
+		Labels map[string]string `env:"LABEL"`
+	}
+	env := MapEnvironment{"PORT": "80"}
+	in := config{Host: "example.com", Labels: map[string]string{"TEAM": "ops"}}
+	if err := ApplyToEnvironment(env, in); err != nil {
+		t.Fatalf("apply returned an error: %v", err)
+	}
+	want := MapEnvironment{"HOST": "example.com", "PORT": "80", "LABEL_TEAM": "ops"}
+	if !reflect.DeepEqual(want, env) {
+		t.Errorf("environment did not match, want: %v, got %v", want, env)
+	}
+	var got config
+	if err := UnmarshalEnvironFrom(env, &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	in.Port = 80
+	if !reflect.DeepEqual(in, got) {
+		t.Errorf("output did not match, want: %+v, got %+v", in, got)
+	}
+}
+
+func TestDecoderSetEnvironment(t *testing.T) {
+	env := MapEnvironment{"HOST": "envhost", "DOMAIN": "example.com"}
+	var got struct {
+		Host string
+		URL  string
+	}
+	dec := NewDecoder(strings.NewReader("HOST=filehost\nURL=https://www.${DOMAIN}/\n"))
+	dec.SetEnvironment(env)
+	dec.SetEnvOverride(true)
+	dec.SetExpand(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if got.Host != "envhost" || got.URL != "https://www.example.com/" {
+		t.Errorf("output did not match, got %+v", got)
+	}
+}
+
+func TestLoaderSetEnvironment(t *testing.T) {
+	l := NewLoader(ReaderSource("stdin", strings.NewReader("PORT=8080\n")))
+	l.SetEnvOverride(true)
+	l.SetEnvironment(MapEnvironment{"PORT": "9090"})
+	var got loaderConfig
+	p, err := l.Load(context.Background(), &got)
+	if err != nil {
+		t.Fatalf("load returned an error: %v", err)
+	}
+	if got.Port != 9090 || p["PORT"] != LayerEnvironment {
+		t.Errorf("environment was not applied, got %+v, %v", got, p)
+	}
+}
+
+func TestUnmarshalLayersFrom(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=filehost\nPORT=80\n")
+	var got struct {
+		Host string
+		Port string
+	}
+	p, err := UnmarshalLayersFrom(MapEnvironment{"PORT": "8080"}, nil, &got, paths...)
+	if err != nil {
+		t.Fatalf("unmarshal layers returned an error: %v", err)
+	}
+	if got.Host != "filehost" || got.Port != "8080" || p["PORT"] != LayerEnvironment {
+		t.Errorf("environment was not applied, got %+v, %v", got, p)
+	}
+}
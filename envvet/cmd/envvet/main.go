@@ -0,0 +1,15 @@
+// Command envvet checks env struct field tags.
+//
+// It can be run standalone or as a vet tool:
+//
+//	go vet -vettool=$(which envvet) ./...
+package main
+
+import (
+	"github.com/basvdlei/envfile/envvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(envvet.Analyzer)
+}
@@ -0,0 +1,361 @@
+// Package envvet defines an Analyzer that checks env struct field tags.
+//
+// It reports the problems that the envfile package would otherwise only
+// discover at runtime, or silently ignore:
+//
+//   - malformed tags, such as unknown options or invalid variable names
+//   - conflicting options, such as options on an ignored field
+//   - fields of types that can not be (un)marshaled
+//   - multiple fields mapping to the same variable, including the fields of
+//     nested structs and the fields promoted from embedded structs
+//   - unexported fields with an env tag, which are ignored
+//
+// Only structs that have at least one field with an env tag are checked.
+//
+// Types for which conversion functions are registered at runtime with
+// envfile.RegisterDecoder can not be detected and should be passed with the
+// -types flag as a comma-separated list of package qualified type names, for
+// example "example.com/pkg.Type".
+package envvet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/basvdlei/envfile/envvet/internal/tag"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer checks env struct field tags.
+var Analyzer = &analysis.Analyzer{
+	Name:     "envtag",
+	Doc:      "check that env struct field tags are well formed and usable by envfile",
+	URL:      "https://pkg.go.dev/github.com/basvdlei/envfile/envvet",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// registered is the value of the -types flag.
+var registered string
+
+func init() {
+	Analyzer.Flags.StringVar(&registered, "types", "",
+		"comma-separated list of package qualified types with registered envfile conversions")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{(*ast.StructType)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		checkStruct(pass, n.(*ast.StructType))
+	})
+	return nil, nil
+}
+
+// checkStruct reports the problems in the fields of a single struct type.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	if !hasEnvTag(st) {
+		return
+	}
+	for _, field := range st.Fields.List {
+		envTag, _ := lookupEnvTag(field)
+		names := field.Names
+		if len(names) == 0 {
+			// Embedded field, the variable name is derived from
+			// the type name.
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		for _, ident := range names {
+			if ident == nil {
+				continue
+			}
+			if !ident.IsExported() {
+				if len(field.Names) > 0 && envTag != "" && envTag != "-" {
+					pass.Reportf(ident.Pos(), "struct field %s has env tag but is unexported and ignored", ident.Name)
+				}
+				continue
+			}
+			_, opts, err := tag.Parse(ident.Name, envTag)
+			if err != nil {
+				pass.Reportf(field.Tag.Pos(), "struct field %s has malformed env tag: %v", ident.Name, err)
+			}
+			if opts.Skip {
+				continue
+			}
+			switch typ := pass.TypesInfo.TypeOf(field.Type); {
+			case typ == nil:
+			case opts.Encoding != "":
+				if !encodedType(typ) {
+					pass.Reportf(field.Type.Pos(), "struct field %s has %s option but type %s is not a string or byte slice", ident.Name, opts.Encoding, typ)
+				}
+			case opts.Size, opts.Enum != nil:
+				if !sizeType(typ) {
+					option := "bytes"
+					if opts.Enum != nil {
+						option = "enum"
+					}
+					pass.Reportf(field.Type.Pos(), "struct field %s has %s option but type %s is not an integer", ident.Name, option, typ)
+				}
+			case opts.Infer:
+				if !isAny(typ) {
+					pass.Reportf(field.Type.Pos(), "struct field %s has infer option but type %s is not an empty interface", ident.Name, typ)
+				}
+			case opts.Unit != 0:
+				if !isDuration(typ) {
+					pass.Reportf(field.Type.Pos(), "struct field %s has unit option but type %s is not time.Duration", ident.Name, typ)
+				}
+			case !supported(typ):
+				pass.Reportf(field.Type.Pos(), "struct field %s has unsupported type %s", ident.Name, typ)
+			}
+		}
+	}
+	checkDuplicates(pass, st)
+}
+
+// checkDuplicates reports the variables of struct type st that are used by
+// more than one field. Clashes within a single nested struct are left to the
+// check of that struct.
+func checkDuplicates(pass *analysis.Pass, st *ast.StructType) {
+	s, ok := pass.TypesInfo.TypeOf(st).(*types.Struct)
+	if !ok {
+		return
+	}
+	// The identifiers of the fields in the order of the fields of s.
+	var idents []*ast.Ident
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			idents = append(idents, embeddedName(field.Type))
+		}
+		idents = append(idents, field.Names...)
+	}
+	seen := make(map[string]envVar)
+	for _, v := range appendVars(nil, s, "", "", nil, nil) {
+		prev, ok := seen[v.name]
+		if !ok {
+			seen[v.name] = v
+			continue
+		}
+		if v.index[0] == prev.index[0] && len(v.index) > 1 {
+			continue
+		}
+		pos := st.Pos()
+		if ident := idents[v.index[0]]; ident != nil {
+			pos = ident.Pos()
+		}
+		pass.Reportf(pos, "struct field %s repeats env variable %q also used by field %s", v.path, v.name, prev.path)
+	}
+}
+
+// envVar is a variable of a struct field, found by appendVars.
+type envVar struct {
+	name string
+	// path is the dot separated path of Go field names and index the
+	// field indexes of the field.
+	path  string
+	index []int
+}
+
+// appendVars appends the variables of the fields of struct type s to vars,
+// with the rules of the envfile package: the fields of nested structs are
+// prefixed with the variable name of the struct field followed by an
+// underscore, and the fields of embedded structs without an explicit name in
+// their tag are promoted without a prefix. The parents are the structs s is
+// nested in, pointers to them are ignored to stop at recursive types.
+func appendVars(vars []envVar, s *types.Struct, prefix, path string, index []int, parents []*types.Struct) []envVar {
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		nested, isPointer := nestedStruct(f.Type())
+		if !f.Exported() && !(f.Anonymous() && nested != nil && !isPointer) {
+			continue
+		}
+		envTag := reflect.StructTag(s.Tag(i)).Get("env")
+		name, opts, _ := tag.Parse(f.Name(), envTag)
+		if opts.Skip {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		p := f.Name()
+		if path != "" {
+			p = path + "." + f.Name()
+		}
+		if nested == nil {
+			vars = append(vars, envVar{prefix + name, p, idx})
+			continue
+		}
+		if isPointer && (types.Identical(nested, s) || slices.ContainsFunc(parents, func(t *types.Struct) bool {
+			return types.Identical(nested, t)
+		})) {
+			continue
+		}
+		pre := prefix + name + "_"
+		if f.Anonymous() && strings.Split(envTag, ",")[0] == "" {
+			pre = prefix
+		}
+		vars = appendVars(vars, nested, pre, p, idx, append(parents[:len(parents):len(parents)], s))
+	}
+	return vars
+}
+
+// nestedStruct returns the struct type of a field of type typ whose fields
+// map to variables: a struct, or a pointer to a struct, that is not converted
+// as a single value. It also reports whether typ is a pointer.
+func nestedStruct(typ types.Type) (*types.Struct, bool) {
+	elem, isPointer := typ, false
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		elem, isPointer = p.Elem(), true
+	}
+	s, ok := elem.Underlying().(*types.Struct)
+	if !ok || isRegistered(typ) || isRegistered(elem) || hasMethod(elem, "UnmarshalText") ||
+		hasMethod(elem, "MarshalText") || hasMethod(elem, "Set") && hasMethod(elem, "String") {
+		return nil, false
+	}
+	return s, isPointer
+}
+
+// hasEnvTag reports whether any of the fields have an env tag.
+func hasEnvTag(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := lookupEnvTag(field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupEnvTag returns the env tag of the field and whether it was present.
+func lookupEnvTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	s, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(s).Lookup("env")
+}
+
+// embeddedName returns the identifier of an embedded field type.
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	}
+	return nil
+}
+
+// supported reports whether envfile can (un)marshal fields of type typ.
+func supported(typ types.Type) bool {
+	if isRegistered(typ) {
+		return true
+	}
+	if basic, ok := typ.Underlying().(*types.Basic); ok {
+		if basic.Info()&(types.IsString|types.IsBoolean|types.IsInteger|types.IsFloat) != 0 {
+			return true
+		}
+	}
+	if hasMethod(typ, "UnmarshalText") || hasMethod(typ, "MarshalText") {
+		return true
+	}
+	if hasMethod(typ, "Set") && hasMethod(typ, "String") {
+		// Implements flag.Value.
+		return true
+	}
+	if isAny(typ) {
+		// Holds the value as a string.
+		return true
+	}
+	if s, ok := typ.Underlying().(*types.Slice); ok {
+		// Comma-separated values, byte and nested slices are not.
+		switch elem := s.Elem().Underlying().(type) {
+		case *types.Slice:
+			return false
+		case *types.Basic:
+			if elem.Kind() == types.Byte {
+				return false
+			}
+		}
+		return supported(s.Elem())
+	}
+	if _, ok := typ.Underlying().(*types.Struct); ok {
+		// Nested struct, its fields are checked separately.
+		return true
+	}
+	if p, ok := typ.Underlying().(*types.Pointer); ok {
+		// Pointer to a nested struct, allocated when decoding.
+		if _, ok := p.Elem().Underlying().(*types.Struct); ok {
+			return true
+		}
+	}
+	if m, ok := typ.Underlying().(*types.Map); ok {
+		// Map entries are stored as prefixed variables.
+		if key, ok := m.Key().Underlying().(*types.Basic); ok && key.Info()&(types.IsString|types.IsInteger) != 0 {
+			return supported(m.Elem())
+		}
+	}
+	return false
+}
+
+// encodedType reports whether typ can be used with the "hex" and "base64"
+// options.
+func encodedType(typ types.Type) bool {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		return t.Info()&types.IsString != 0
+	case *types.Slice:
+		elem, ok := t.Elem().Underlying().(*types.Basic)
+		return ok && elem.Kind() == types.Byte
+	}
+	return false
+}
+
+// sizeType reports whether typ can be used with the "bytes" and "enum"
+// options.
+func sizeType(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
+}
+
+// isAny reports whether typ is an empty interface type like any.
+func isAny(typ types.Type) bool {
+	iface, ok := typ.Underlying().(*types.Interface)
+	return ok && iface.NumMethods() == 0
+}
+
+// isDuration reports whether typ is time.Duration, the only type that can be
+// used with the "unit" option.
+func isDuration(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Duration"
+}
+
+// isRegistered reports whether typ is listed in the -types flag.
+func isRegistered(typ types.Type) bool {
+	name := types.TypeString(typ, nil)
+	for _, t := range strings.Split(registered, ",") {
+		if strings.TrimSpace(t) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMethod reports whether typ or a pointer to typ has the method.
+func hasMethod(typ types.Type, method string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, method)
+	_, ok := obj.(*types.Func)
+	return ok
+}
@@ -0,0 +1,15 @@
+package envvet_test
+
+import (
+	"testing"
+
+	"github.com/basvdlei/envfile/envvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := envvet.Analyzer.Flags.Set("types", "a.Registered"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), envvet.Analyzer, "a")
+}
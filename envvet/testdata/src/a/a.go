@@ -0,0 +1,115 @@
+package a
+
+import "time"
+
+type Config struct {
+	Name     string
+	Setting  string `env:"MY_SETTING,omitempty"`
+	Ignored  int    `env:"-"`
+	Count    int    `env:"COUNT"`
+	Unknown  string `env:"UNKNOWN,omitemtpy"` // want `struct field Unknown has malformed env tag: unknown option "omitemtpy"`
+	Invalid  string `env:"MY VAR"`            // want `struct field Invalid has malformed env tag: invalid variable name "MY VAR"`
+	Conflict string `env:"-,omitempty"`       // want `struct field Conflict has malformed env tag: options on ignored field have no effect`
+	Other    string `env:"NAME"`              // want `struct field Other repeats env variable "NAME" also used by field Name`
+	Ordered  string `env:"ORDERED,order=1"`
+	BadOrder string `env:"BAD_ORDER,order=x"` // want `struct field BadOrder has malformed env tag: invalid order "x"`
+	private  int
+	hidden   string `env:"HIDDEN"` // want `struct field hidden has env tag but is unexported and ignored`
+}
+
+type MyString string
+
+type Level int
+
+func (l *Level) Set(s string) error { return nil }
+func (l *Level) String() string     { return "" }
+
+type Color int
+
+type Text struct{}
+
+func (t *Text) UnmarshalText(b []byte) error { return nil }
+
+type Registered struct{}
+
+type Conversions struct {
+	Text       Text                         `env:"TEXT"`
+	Registered Registered                   `env:"REGISTERED"`
+	Nested     struct{}                     `env:"NESTED"`
+	Optional   *struct{}                    `env:"OPTIONAL"`
+	Pointer    *string                      `env:"POINTER"` // want `struct field Pointer has unsupported type \*string`
+	Other      chan int                     `env:"OTHER"`   // want `struct field Other has unsupported type chan int`
+	Labels     map[string]string            `env:"LABELS"`
+	Counts     map[string]int               `env:"COUNTS"`
+	Weights    map[int]int                  `env:"WEIGHTS"`
+	Level      Level                        `env:"LEVEL"`
+	Anything   any                          `env:"ANYTHING,infer"`
+	Stringer   interface{ String() string } `env:"STRINGER"`        // want `struct field Stringer has unsupported type interface\{String\(\) string\}`
+	BadInfer   string                       `env:"BAD_INFER,infer"` // want `struct field BadInfer has infer option but type string is not an empty interface`
+	Ratios     map[float64]int              `env:"RATIOS"`          // want `struct field Ratios has unsupported type map\[float64\]int`
+	Hosts      []string                     `env:"HOSTS"`
+	Raw        []byte                       `env:"RAW"` // want `struct field Raw has unsupported type \[\]byte`
+	Hex        []byte                       `env:"HEX,hex"`
+	Encoded    string                       `env:"ENCODED,base64"`
+	BadHex     int                          `env:"BAD_HEX,hex"`         // want `struct field BadHex has hex option but type int is not a string or byte slice`
+	BothHex    []byte                       `env:"BOTH_HEX,hex,base64"` // want `struct field BothHex has malformed env tag: conflicting options "hex" and "base64"`
+	Channels   map[string]chan int          `env:"CHANNELS"`            // want `struct field Channels has unsupported type map\[string\]chan int`
+	MaxUpload  int64                        `env:"MAX_UPLOAD,bytes"`
+	BadSize    string                       `env:"BAD_SIZE,bytes"` // want `struct field BadSize has bytes option but type string is not an integer`
+	Timeout    time.Duration                `env:"TIMEOUT,unit=s"`
+	BadUnit    int64                        `env:"BAD_UNIT,unit=s"`  // want `struct field BadUnit has unit option but type int64 is not time.Duration`
+	Fortnight  time.Duration                `env:"FORTNIGHT,unit=w"` // want `struct field Fortnight has malformed env tag: invalid unit "w"`
+	Color      Color                        `env:"COLOR,enum=red:1|green:2"`
+	BadEnum    string                       `env:"BAD_ENUM,enum=red:1"`     // want `struct field BadEnum has enum option but type string is not an integer`
+	RepeatEnum Color                        `env:"REPEAT,enum=red:1|red:2"` // want `struct field RepeatEnum has malformed env tag: enum value "red:2" repeats red:1`
+}
+
+type Aliased struct {
+	Value MyString `env:"VALUE"`
+}
+
+// NotEnv has no env tags and is not checked.
+type NotEnv struct {
+	Count int
+	A, B  chan int
+}
+
+type Base struct {
+	Host string
+	Port int
+}
+
+// Server clashes with a field promoted from an embedded struct.
+type Server struct {
+	Base
+	Host string `env:"HOST"` // want `struct field Host repeats env variable "HOST" also used by field Base.Host`
+}
+
+// Shadow embeds the struct after the field it clashes with.
+type Shadow struct {
+	Host  string `env:"HOST"`
+	*Base        // want `struct field Base.Host repeats env variable "HOST" also used by field Host`
+}
+
+// Prefixed embeds the struct with a name, so its fields are prefixed.
+type Prefixed struct {
+	Base `env:"BASE"`
+	Host string `env:"HOST"`
+}
+
+type Database struct {
+	Host string `env:"HOST"`
+}
+
+// App clashes with a field of a nested struct.
+type App struct {
+	DB     Database `env:"DB"`
+	DBHost string   `env:"DB_HOST"` // want `struct field DBHost repeats env variable "DB_HOST" also used by field DB.Host`
+	Cache  *Database
+}
+
+// Node refers to itself, the pointer is not followed.
+type Node struct {
+	Name string `env:"NAME"`
+	Next *Node
+}
@@ -0,0 +1,140 @@
+package envfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxExpandDepth is the maximum number of nested variable references that
+// are expanded before giving up.
+const maxExpandDepth = 32
+
+// LookupFunc returns the value of the variable key and whether it is set.
+type LookupFunc func(key string) (value string, ok bool)
+
+// ErrorExpansion is returned when a variable reference can not be expanded.
+type ErrorExpansion struct {
+	Key    string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e ErrorExpansion) Error() string {
+	return fmt.Sprintf("error expanding %s: %s", e.Key, e.Reason)
+}
+
+// expander replaces ${VAR} and $VAR references in values.
+type expander struct {
+	lookup LookupFunc
+	// recursive expands the references in looked up values as well.
+	recursive bool
+}
+
+// newExpander returns an expander using lookup. When lookup is nil, the
+// variables set earlier in the input and the variables in env are used.
+func newExpander(lookup LookupFunc, env Environment, earlier map[string]string) *expander {
+	if lookup != nil {
+		return &expander{lookup: lookup, recursive: true}
+	}
+	return &expander{lookup: func(key string) (string, bool) {
+		if v, ok := earlier[key]; ok {
+			return v, true
+		}
+		return env.Lookup(key)
+	}}
+}
+
+// expand replaces the references in the value of variable key. The '$'
+// characters at the offsets in literal are kept.
+func (e *expander) expand(key, value string, literal []int) (string, error) {
+	return e.expandValue(value, literal, []string{key})
+}
+
+func (e *expander) expandValue(value string, literal []int, chain []string) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+	if len(chain) > maxExpandDepth {
+		return "", ErrorExpansion{chain[0],
+			fmt.Sprintf("more than %d nested references", maxExpandDepth)}
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		if len(literal) > 0 && literal[0] == i {
+			literal = literal[1:]
+			b.WriteByte(value[i])
+			continue
+		}
+		name, w := referenceName(value[i+1:])
+		if w == 0 {
+			// Not a reference, keep the '$'.
+			b.WriteByte(value[i])
+			continue
+		}
+		i += w
+		if name == "" {
+			return "", ErrorExpansion{chain[0], "bad substitution"}
+		}
+		v, ok := e.lookup(name)
+		if ok && e.recursive {
+			for _, c := range chain[1:] {
+				if c == name {
+					return "", ErrorExpansion{chain[0], "reference cycle " +
+						strings.Join(append(chain, name), " -> ")}
+				}
+			}
+			var err error
+			v, err = e.expandValue(v, nil, append(chain, name))
+			if err != nil {
+				return "", err
+			}
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
+
+// referenceName returns the variable name at the start of s, which follows a
+// '$', and the number of bytes it used. A width of zero means s does not
+// start with a reference. An empty name with a non-zero width is returned for
+// invalid references like "${}" or a missing closing brace.
+func referenceName(s string) (name string, w int) {
+	if s[0] == '{' {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", len(s)
+		}
+		name = s[1:end]
+		if !isName(name) {
+			return "", end + 1
+		}
+		return name, end + 1
+	}
+	for w < len(s) && isNameByte(s[w], w == 0) {
+		w++
+	}
+	return s[:w], w
+}
+
+// isName reports whether s is a valid reference name.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isNameByte(s[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNameByte reports whether c can be used in a reference name.
+func isNameByte(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		!first && '0' <= c && c <= '9'
+}
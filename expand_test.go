@@ -0,0 +1,157 @@
+package envfile
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+var expandCases = []struct {
+	Name   string
+	Input  string
+	Lookup map[string]string
+	Output string
+	Error  error
+}{
+	{
+		Name:   "earlier variable",
+		Input:  "HOST=localhost\nURL=http://${HOST}:$PORT/\n",
+		Output: "http://localhost:8080/",
+	},
+	{
+		Name:   "environment variable",
+		Input:  "URL=http://${ENVFILE_TEST_HOST}/\n",
+		Output: "http://envhost/",
+	},
+	{
+		Name:   "literal dollar signs",
+		Input:  "URL=$ costs $5 $\n",
+		Output: "$ costs $5 $",
+	},
+	{
+		Name:   "single quoted value is not expanded",
+		Input:  "HOST=localhost\nURL='http://${HOST}/'\n",
+		Output: "http://${HOST}/",
+	},
+	{
+		Name:   "double quoted value is expanded",
+		Input:  "HOST=localhost\nURL=\"http://${HOST}/\"\n",
+		Output: "http://localhost/",
+	},
+	{
+		Name:   "escaped dollar sign is not expanded",
+		Input:  "B=x\nURL=\"\\$B literal $B\"\n",
+		Output: "$B literal x",
+	},
+	{
+		Name:   "unset variable",
+		Input:  "URL=${ENVFILE_TEST_UNSET}/\n",
+		Output: "/",
+	},
+	{
+		Name:  "bad substitution",
+		Input: "URL=${HOST\n",
+		Error: ErrorExpansion{"URL", "bad substitution"},
+	},
+	{
+		Name:  "custom lookup",
+		Input: "HOST=ignored\nURL=http://${HOST}/\n",
+		Lookup: map[string]string{
+			"HOST": "${NAME}.example.com",
+			"NAME": "www",
+		},
+		Output: "http://www.example.com/",
+	},
+	{
+		Name:  "reference cycle",
+		Input: "URL=${A}\n",
+		Lookup: map[string]string{
+			"A": "${B}",
+			"B": "${A}",
+		},
+		Error: ErrorExpansion{"URL", "reference cycle URL -> A -> B -> A"},
+	},
+	{
+		Name:  "self reference in lookup",
+		Input: "URL=${URL}\n",
+		Lookup: map[string]string{
+			"URL": "${URL}",
+		},
+		Error: ErrorExpansion{"URL", "reference cycle URL -> URL -> URL"},
+	},
+}
+
+func TestDecoderExpand(t *testing.T) {
+	os.Setenv("ENVFILE_TEST_HOST", "envhost")
+	defer os.Unsetenv("ENVFILE_TEST_HOST")
+	os.Setenv("PORT", "8080")
+	defer os.Unsetenv("PORT")
+	for _, c := range expandCases {
+		var got struct {
+			URL string
+		}
+		dec := NewDecoder(strings.NewReader(c.Input))
+		dec.SetExpand(true)
+		if c.Lookup != nil {
+			dec.SetLookup(func(key string) (string, bool) {
+				v, ok := c.Lookup[key]
+				return v, ok
+			})
+		}
+		err := dec.Decode(&got)
+		if err != c.Error {
+			t.Errorf("[%s] error did not match, want: %v, got %v", c.Name, c.Error, err)
+		}
+		if err == nil && got.URL != c.Output {
+			t.Errorf("[%s] output did not match, want %q, got %q", c.Name, c.Output, got.URL)
+		}
+	}
+}
+
+func TestDecoderExpandDepth(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("URL=${A}\n"))
+	dec.SetExpand(true)
+	dec.SetLookup(func(key string) (string, bool) {
+		// Every lookup references a new variable.
+		return "${" + key + "A}", true
+	})
+	var got struct {
+		URL string
+	}
+	err := dec.Decode(&got)
+	if _, ok := err.(ErrorExpansion); !ok {
+		t.Errorf("deeply nested references did not return ErrorExpansion, got %v", err)
+	}
+}
+
+func TestDecoderNoExpand(t *testing.T) {
+	var got struct {
+		URL string
+	}
+	if err := Unmarshal([]byte("URL=${HOST}\n"), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL != "${HOST}" {
+		t.Errorf("value was expanded without SetExpand, got %q", got.URL)
+	}
+}
+
+func TestEncoderQuotingExpand(t *testing.T) {
+	in := struct{ Price string }{"cost $5 $B ${C}"}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetQuoting(QuoteAsNeeded)
+	if err := enc.Encode(in); err != nil {
+		t.Fatalf("encode returned an error: %v", err)
+	}
+	var got struct{ Price string }
+	dec := NewDecoder(strings.NewReader("B=x\n" + buf.String()))
+	dec.SetExpand(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if got != in {
+		t.Errorf("output of %q did not match, want %q, got %q", buf.String(), in.Price, got.Price)
+	}
+}
@@ -0,0 +1,46 @@
+package envfile
+
+import (
+	"expvar"
+	"reflect"
+)
+
+// Publish publishes the configuration held by v as the expvar variable name,
+// so it can be inspected through the /debug/vars endpoint. The variable is a
+// JSON object mapping every variable name to its value, where the values of
+// fields with the "secret" option are replaced by Redacted.
+//
+// When v is a pointer the configuration is read again on every request, so
+// the published values follow later changes. Like expvar.Publish, it panics
+// when name is already in use.
+func Publish(name string, v interface{}) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		values, err := redactedValues(v)
+		if err != nil {
+			return err.Error()
+		}
+		return values
+	}))
+}
+
+// redactedValues returns the variables of the struct or pointer to a struct
+// v, with the values of fields with the "secret" option replaced by
+// Redacted. Fields with the "omitempty" option are included as well.
+func redactedValues(v interface{}) (map[string]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil, ErrorUnsupportedType{reflect.Invalid}
+	}
+	vars, err := encodeVars(rv.Interface(), ZeroEmit)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(vars))
+	for _, p := range vars {
+		if p.Secret && p.Value != "" {
+			p.Value = Redacted
+		}
+		values[p.Key] = p.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,29 @@
+package envfile
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	v := &struct {
+		Host     string
+		Password string `env:"PASSWORD,secret"`
+		Optional string `env:",omitempty"`
+	}{
+		Host:     "localhost",
+		Password: "hunter2",
+	}
+	Publish("envfile_test_config", v)
+	v.Host = "changed"
+	var got map[string]string
+	if err := json.Unmarshal([]byte(expvar.Get("envfile_test_config").String()), &got); err != nil {
+		t.Fatalf("published value is not a JSON object: %v", err)
+	}
+	want := map[string]string{"HOST": "changed", "PASSWORD": Redacted, "OPTIONAL": ""}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("published value did not match\nwant:\n%v\ngot:\n%v", want, got)
+	}
+}
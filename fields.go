@@ -0,0 +1,324 @@
+package envfile
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/basvdlei/envfile/internal/tag"
+)
+
+// field is a struct field that maps to a variable.
+type field struct {
+	// Name is the variable name, including the prefixes of the nested
+	// structs the field is part of.
+	Name string
+	// Index is the index sequence for reflect.Value.FieldByIndex.
+	Index []int
+	Type  reflect.Type
+	Tag   reflect.StructTag
+	Opts  envOptions
+	// Group is the path of Go field names of the nested struct the field
+	// is part of, separated by dots. It is empty for top level fields.
+	Group string
+	// Map is set for map fields, every entry maps to a variable named
+	// Name, an underscore and the entry key.
+	Map bool
+	// Indirect is set when the field is part of a nested struct that is
+	// referenced by a pointer, which is nil until the struct is used.
+	Indirect bool
+}
+
+// match reports whether the variable key maps to the field. For map fields
+// the returned mapKey is the part of key after the field prefix, which must
+// be a number for integer-keyed maps.
+func (f field) match(key string) (mapKey string, ok bool) {
+	if !f.Map {
+		return "", key == f.Name
+	}
+	prefix := f.Name + "_"
+	if len(key) <= len(prefix) || !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	mapKey = key[len(prefix):]
+	if multiMap(f.Type) {
+		mapKey = trimIndex(mapKey)
+	}
+	if f.Type.Key().Kind() != reflect.String {
+		if _, err := mapKeyValue(f.Type.Key(), mapKey); err != nil {
+			return "", false
+		}
+	}
+	return mapKey, true
+}
+
+// trimIndex removes a numeric suffix like "_2" from the key of a multi-valued
+// map entry, so the values of ACCEPT and ACCEPT_2 are collected in the same
+// entry.
+func trimIndex(mapKey string) string {
+	i := strings.LastIndexByte(mapKey, '_')
+	if i <= 0 || i == len(mapKey)-1 {
+		return mapKey
+	}
+	for _, c := range mapKey[i+1:] {
+		if c < '0' || c > '9' {
+			return mapKey
+		}
+	}
+	return mapKey[:i]
+}
+
+// supported reports whether the values of the field, or the entries of a map
+// field, can be (un)marshaled with the options of the field.
+func (f field) supported() bool {
+	typ := f.Type
+	if f.Map {
+		typ = typ.Elem()
+	}
+	return optionType(typ, f.Opts)
+}
+
+// decodable reports whether setValue can store the values of the field.
+func (f field) decodable() bool {
+	typ := f.Type
+	if f.Map {
+		typ = typ.Elem()
+	}
+	return decodeOptionType(typ, f.Opts)
+}
+
+// structInfo is the field information of a struct type.
+type structInfo struct {
+	fields []field
+	// plain is set when all fields are string fields whose values are
+	// written as is, without conversion, ordering or quoting.
+	plain bool
+	// byName maps variable names to the indexes of the fields they are
+	// stored in, maps holds the indexes of the map fields.
+	byName map[string][]int
+	maps   []int
+	// err is a ErrorDuplicateKey when multiple fields map to the same
+	// variable, the struct can then not be encoded or decoded.
+	err error
+}
+
+// check returns a ErrorDuplicateKey when multiple fields map to the same
+// variable and a ErrorUnsupportedType when a field has an unsupported type.
+func (si *structInfo) check() error {
+	if si.err != nil {
+		return si.err
+	}
+	for _, f := range si.fields {
+		if !f.supported() {
+			typ := f.Type
+			if f.Map {
+				typ = typ.Elem()
+			}
+			return ErrorUnsupportedType{typ.Kind()}
+		}
+	}
+	return nil
+}
+
+// checkDecode is like check but also returns a ErrorUnsupportedType when the
+// values of a field can be marshaled but not unmarshaled.
+func (si *structInfo) checkDecode() error {
+	if err := si.check(); err != nil {
+		return err
+	}
+	for _, f := range si.fields {
+		if !f.decodable() {
+			typ := f.Type
+			if f.Map {
+				typ = typ.Elem()
+			}
+			return ErrorUnsupportedType{typ.Kind()}
+		}
+	}
+	return nil
+}
+
+// structCache maps a reflect.Type to its *structInfo. Loads do not lock,
+// cacheMu serializes storing with resetting, and cacheGen counts the resets so
+// information computed before a reset is not stored after it.
+var (
+	structCache sync.Map
+	cacheMu     sync.Mutex
+	cacheGen    uint64
+)
+
+// cachedStruct returns the field information of struct type t, computing it
+// on first use.
+func cachedStruct(t reflect.Type) *structInfo {
+	if si, ok := structCache.Load(t); ok {
+		return si.(*structInfo)
+	}
+	cacheMu.Lock()
+	gen := cacheGen
+	cacheMu.Unlock()
+	si := &structInfo{
+		fields: appendFields(nil, t, "", "", nil, nil),
+		plain:  true,
+		byName: make(map[string][]int),
+	}
+	paths := make(map[string]string)
+	for i, f := range si.fields {
+		path, indirect := fieldPath(t, f)
+		si.fields[i].Indirect = indirect
+		if prev, ok := paths[f.Name]; ok && si.err == nil {
+			si.err = ErrorDuplicateKey{Key: f.Name, Field: prev, Other: path}
+		}
+		paths[f.Name] = path
+		if f.Map {
+			si.maps = append(si.maps, i)
+		} else {
+			si.byName[f.Name] = append(si.byName[f.Name], i)
+		}
+		if f.Map || indirect || f.Opts.Order != 0 || f.Opts.Quote || f.Opts.Encoding != "" || f.Opts.Size || f.Opts.Unit != 0 || f.Opts.Enum != nil || f.Opts.Infer || !plainString(f.Type) || !tag.ValidName(f.Name) {
+			si.plain = false
+		}
+	}
+	if si.err != nil {
+		si.plain = false
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if gen != cacheGen {
+		// A conversion was registered meanwhile, the information may
+		// be outdated already, use it once without caching it.
+		return si
+	}
+	actual, _ := structCache.LoadOrStore(t, si)
+	return actual.(*structInfo)
+}
+
+// fieldPath returns the dot separated path of Go field names of f in struct
+// type t, including the names of embedded structs. It also reports whether
+// the path passes a pointer to a nested struct.
+func fieldPath(t reflect.Type, f field) (path string, indirect bool) {
+	names := make([]string, len(f.Index))
+	for i, x := range f.Index {
+		if t.Kind() == reflect.Ptr {
+			t, indirect = t.Elem(), true
+		}
+		sf := t.Field(x)
+		names[i] = sf.Name
+		t = sf.Type
+	}
+	return strings.Join(names, "."), indirect
+}
+
+// resetStructCache discards the cached field information. It is called when
+// a conversion is registered, as that can change how fields are handled.
+func resetStructCache() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cacheGen++
+	structCache.Range(func(k, _ interface{}) bool {
+		structCache.Delete(k)
+		return true
+	})
+}
+
+// typeFields returns the fields of struct type t that map to variables in
+// declaration order. The fields of nested structs are included, ignored and
+// unexported fields are not, even when they have an env tag. The returned
+// slice is shared and must not be modified.
+//
+// Fields of a nested struct are prefixed with the variable name of the struct
+// field followed by an underscore. The fields of embedded structs without an
+// explicit name in their tag are promoted and do not get a prefix.
+func typeFields(t reflect.Type) []field {
+	return cachedStruct(t).fields
+}
+
+// appendFields appends the fields of struct type t to fields. The parents are
+// the types of the structs t is nested in, pointers to them are ignored to
+// stop at recursive types.
+func appendFields(fields []field, t reflect.Type, prefix, group string, index []int, parents []reflect.Type) []field {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !(sf.Anonymous && nestedStruct(sf.Type)) {
+			// Unexported fields can not be set, but the exported
+			// fields of embedded structs are promoted.
+			continue
+		}
+		name, opts := parseFieldOpts(sf)
+		if opts.Skip {
+			continue
+		}
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+		if nestedStruct(sf.Type) || nestedPointer(sf.Type) {
+			st := sf.Type
+			if st.Kind() == reflect.Ptr {
+				st = st.Elem()
+				if st == t || slices.Contains(parents, st) {
+					continue
+				}
+			}
+			p, g := prefix+name+"_", sf.Name
+			if group != "" {
+				g = group + "." + sf.Name
+			}
+			if sf.Anonymous && strings.Split(sf.Tag.Get("env"), ",")[0] == "" {
+				p, g = prefix, group
+			}
+			fields = appendFields(fields, st, p, g, idx, append(parents[:len(parents):len(parents)], t))
+			continue
+		}
+		fields = append(fields, field{
+			Name:  prefix + name,
+			Index: idx,
+			Type:  sf.Type,
+			Tag:   sf.Tag,
+			Opts:  opts,
+			Group: group,
+			Map:   mapField(sf.Type),
+		})
+	}
+	return fields
+}
+
+// mapField reports whether a field of type t is a map with string or integer
+// keys whose entries map to variables, rather than a single value.
+func mapField(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && mapKeyType(t.Key()) &&
+		!supportedType(t) && supportedType(t.Elem())
+}
+
+// multiMap reports whether a map field of type t holds multiple values per
+// entry, whose variables are collected in a slice.
+func multiMap(t reflect.Type) bool {
+	return t.Elem().Kind() == reflect.Slice
+}
+
+// nestedPointer reports whether a field of type t is a pointer to a nested
+// struct. The struct is allocated when one of its fields is decoded and its
+// fields are omitted from the encoding while the pointer is nil.
+func nestedPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && !supportedType(t) && nestedStruct(t.Elem())
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates the nil
+// pointers to nested structs it passes.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// nestedStruct reports whether a field of type t is a nested struct whose
+// fields map to variables, rather than a single value.
+func nestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !supportedType(t)
+}
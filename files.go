@@ -0,0 +1,84 @@
+package envfile
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// UnmarshalFiles reads the EnvironmentFiles and stores the result in the
+// value pointed to by v.
+//
+// The files are merged before decoding, when a variable is set in multiple
+// files the value of the last file wins. All files are parsed before any
+// field is assigned, so v is left untouched when one of them can not be read
+// or parsed. Errors, including values that can not be converted, are
+// reported per file as a ErrorFile. Like Unmarshal, it
+// calls the Validate method of all Validator values after the fields are
+// assigned.
+func UnmarshalFiles(v interface{}, files ...string) error {
+	if err := checkTarget(v); err != nil {
+		return err
+	}
+	var errs ErrorList
+	merged := make(map[string]string)
+	source := make(map[string]string)
+	var keys []string
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, ErrorFile{file, err})
+			continue
+		}
+		pairs, err := readPairs(bytes.NewReader(data))
+		if err != nil {
+			errs = append(errs, ErrorFile{file, err})
+			continue
+		}
+		for _, p := range pairs {
+			if _, ok := merged[p.Key]; !ok {
+				keys = append(keys, p.Key)
+			}
+			merged[p.Key] = p.Value
+			source[p.Key] = file
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for _, key := range keys {
+		if _, err := assign(v, key, merged[key], false); err != nil {
+			return ErrorFile{source[key], err}
+		}
+	}
+	return validate(v)
+}
+
+// pair is a variable assignment read from EnvironmentFile data.
+type pair struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// readPairs reads all variable assignments from r in the order they appear.
+func readPairs(r io.Reader) ([]pair, error) {
+	var pairs []pair
+	lr := newLineReader(r)
+	for lr.Next() {
+		count := lr.Line()
+		l, ok := parseLine(lr.Text())
+		if l == nil {
+			continue
+		}
+		if !ok {
+			return pairs, ErrorLineParsing{count}
+		}
+		pairs = append(pairs, pair{
+			Key:   l.Key,
+			Value: l.Value,
+			Line:  count,
+		})
+	}
+	return pairs, lr.Err()
+}
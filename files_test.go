@@ -0,0 +1,116 @@
+package envfile
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTempFiles writes the files to a new temporary directory and returns
+// their paths in the same order. The directory is removed when the test
+// completes.
+func writeTempFiles(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, c := range contents {
+		path := filepath.Join(dir, string(rune('a'+i))+".env")
+		if err := os.WriteFile(path, []byte(c), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestUnmarshalFiles(t *testing.T) {
+	paths := writeTempFiles(t,
+		"HOST=basehost\nPORT=80\n",
+		"# override\nPORT=8080\n",
+	)
+	var got struct {
+		Host string
+		Port string
+	}
+	if err := UnmarshalFiles(&got, paths...); err != nil {
+		t.Fatalf("unmarshal files returned an error: %v", err)
+	}
+	if got.Host != "basehost" || got.Port != "8080" {
+		t.Errorf("files were not merged with later-wins, got %+v", got)
+	}
+}
+
+func TestUnmarshalFilesErrors(t *testing.T) {
+	paths := writeTempFiles(t,
+		"HOST=basehost\n",
+		"PORT=8080\nINVALID\n",
+	)
+	missing := paths[0] + ".missing"
+	var got struct {
+		Host string
+		Port string
+	}
+	err := UnmarshalFiles(&got, paths[0], paths[1], missing)
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 2 {
+		t.Fatalf("want errors for two files, got %v", err)
+	}
+	want := ErrorFile{paths[1], ErrorLineParsing{2}}
+	if list[0] != want {
+		t.Errorf("error did not match, want: %v, got %v", want, list[0])
+	}
+	if fe, ok := list[1].(ErrorFile); !ok || fe.Path != missing || !errors.Is(fe, os.ErrNotExist) {
+		t.Errorf("error for missing file did not match, got %v", list[1])
+	}
+	if got.Host != "" {
+		t.Errorf("value was assigned while files had errors, got %+v", got)
+	}
+}
+
+func TestUnmarshalFilesValueError(t *testing.T) {
+	paths := writeTempFiles(t, "PORT=80\n", "PORT=http\n")
+	var got struct {
+		Port int
+	}
+	err := UnmarshalFiles(&got, paths...)
+	fe, ok := err.(ErrorFile)
+	if !ok || fe.Path != paths[1] {
+		t.Fatalf("error is not a ErrorFile for %s: %v", paths[1], err)
+	}
+	if _, ok := fe.Err.(ErrorValueParsing); !ok {
+		t.Errorf("underlying error is not a ErrorValueParsing: %v", fe.Err)
+	}
+}
+
+func TestInvalidTargets(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=localhost\n")
+	type config struct {
+		Host string
+	}
+	load := map[string]func(v interface{}) error{
+		"UnmarshalFiles": func(v interface{}) error {
+			return UnmarshalFiles(v, paths...)
+		},
+		"UnmarshalLayers": func(v interface{}) error {
+			_, err := UnmarshalLayers(v, paths...)
+			return err
+		},
+		"Loader.Load": func(v interface{}) error {
+			_, err := NewLoader(FileSource(paths[0])).Load(context.Background(), v)
+			return err
+		},
+		"LoadFlags": func(v interface{}) error {
+			return LoadFlags(flag.NewFlagSet("test", flag.ContinueOnError), nil, v, paths...)
+		},
+	}
+	for name, fn := range load {
+		for _, v := range []interface{}{nil, config{}, (*config)(nil)} {
+			if err := fn(v); !errors.As(err, new(ErrorInvalidTarget)) {
+				t.Errorf("[%s] error for %T did not match, want: ErrorInvalidTarget, got %v", name, v, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package envfile
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// fieldFlag is a flag.Value that stores into a struct field. The field is
+// looked up by its index in the struct when it is used, so nil pointers to
+// nested structs are only allocated when one of their flags is set.
+type fieldFlag struct {
+	root  reflect.Value
+	index []int
+	typ   reflect.Type
+	opts  envOptions
+	key   string
+}
+
+// String implements the flag.Value interface. Fields of nested structs
+// behind a nil pointer are shown as their zero value.
+func (f *fieldFlag) String() string {
+	if !f.root.IsValid() {
+		return ""
+	}
+	field, err := f.root.FieldByIndexErr(f.index)
+	if err != nil {
+		field = reflect.New(f.typ).Elem()
+	}
+	s, _ := formatValue(field, f.opts)
+	return s
+}
+
+// IsBoolFlag reports whether the flag can be given without a value, which
+// is the case for bool fields.
+func (f *fieldFlag) IsBoolFlag() bool {
+	return f.typ != nil && f.typ.Kind() == reflect.Bool
+}
+
+// Set implements the flag.Value interface.
+func (f *fieldFlag) Set(value string) error {
+	return setValue(fieldByIndexAlloc(f.root, f.index), f.opts, f.key, value, false)
+}
+
+// BindFlags defines a flag on fs for every field of the struct pointed to by
+// v. Setting a flag stores its value in the field using the same conversions
+// as Unmarshal, and the current value of the field is shown as the default.
+//
+// The flag name is the variable name of the field, unless it is given in a
+// `flag` struct field tag. Fields with the tag `flag:"-"` and map fields do
+// not get a flag.
+//
+//	// Field is set with -listen, or LISTEN_ADDR in an EnvironmentFile.
+//	Field string `env:"LISTEN_ADDR" flag:"listen"`
+func BindFlags(fs *flag.FlagSet, v interface{}) error {
+	rv, err := targetStruct(v)
+	if err != nil {
+		return err
+	}
+	si := cachedStruct(rv.Type())
+	if si.err != nil {
+		return si.err
+	}
+	for _, f := range si.fields {
+		name := f.Name
+		if n, ok := f.Tag.Lookup("flag"); ok {
+			name = n
+		}
+		if name == "-" || f.Map {
+			continue
+		}
+		if !f.supported() {
+			return ErrorUnsupportedType{f.Type.Kind()}
+		}
+		ff := &fieldFlag{root: rv, index: f.Index, typ: f.Type, opts: f.Opts, key: f.Name}
+		fs.Var(ff, name, fmt.Sprintf("sets variable %s", f.Name))
+	}
+	return nil
+}
+
+// LoadFlags stores configuration from defaults, EnvironmentFiles and command
+// line flags in the struct pointed to by v. Every source overrides the values
+// set by the previous ones:
+//
+//  1. the values of the `default` struct field tags
+//  2. the files, in the order they are given
+//  3. the flags in args, bound to the fields with BindFlags
+//
+// The flags are parsed with fs.Parse, so the remaining arguments are available
+// through fs.Args. After all sources are applied, the Validate method of all
+// Validator values is called.
+func LoadFlags(fs *flag.FlagSet, args []string, v interface{}, files ...string) error {
+	if err := checkTarget(v); err != nil {
+		return err
+	}
+	if err := applyDefaults(v, nil); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := decodeFile(v, file, nil); err != nil {
+			return err
+		}
+	}
+	if err := BindFlags(fs, v); err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return validate(v)
+}
@@ -0,0 +1,93 @@
+package envfile
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type flagConfig struct {
+	Host    string `env:"HOST" default:"localhost"`
+	Port    string `env:"PORT" default:"80"`
+	Listen  string `env:"LISTEN_ADDR" flag:"listen" default:":8080"`
+	Secret  string `env:"SECRET" flag:"-"`
+	Unbound string `env:"UNBOUND,omitempty"`
+}
+
+func TestLoadFlags(t *testing.T) {
+	paths := writeTempFiles(t, "PORT=8000\nLISTEN_ADDR=:9000\nSECRET=s3cr3t\n")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var got flagConfig
+	err := LoadFlags(fs, []string{"-listen", ":7000", "-UNBOUND", "x", "rest"}, &got, paths...)
+	if err != nil {
+		t.Fatalf("load flags returned an error: %v", err)
+	}
+	want := flagConfig{
+		Host:    "localhost",
+		Port:    "8000",
+		Listen:  ":7000",
+		Secret:  "s3cr3t",
+		Unbound: "x",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("output did not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	if want, got := []string{"rest"}, fs.Args(); !reflect.DeepEqual(want, got) {
+		t.Errorf("remaining arguments did not match, want %v, got %v", want, got)
+	}
+	if fs.Lookup("SECRET") != nil {
+		t.Errorf("flag defined for ignored field")
+	}
+	if f := fs.Lookup("PORT"); f == nil || f.DefValue != "8000" {
+		t.Errorf("flag default did not reflect the file value: %+v", f)
+	}
+}
+
+func TestLoadFlagsUnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var got flagConfig
+	if err := LoadFlags(fs, []string{"-unknown"}, &got); err == nil {
+		t.Errorf("unknown flag did not return an error")
+	}
+}
+
+func TestBindFlagsNestedPointer(t *testing.T) {
+	type database struct {
+		Host string
+		Port int
+	}
+	var got struct {
+		Name     string
+		Database *database `env:"DB"`
+		Cache    *database
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := BindFlags(fs, &got); err != nil {
+		t.Fatalf("bind flags returned an error: %v", err)
+	}
+	if got.Database != nil || got.Cache != nil {
+		t.Errorf("nested structs were allocated before a flag was set")
+	}
+	if def := fs.Lookup("DB_PORT").DefValue; def != "0" {
+		t.Errorf("default of unallocated field did not match, want: 0, got %q", def)
+	}
+	if err := fs.Parse([]string{"-DB_HOST", "db"}); err != nil {
+		t.Fatalf("parse returned an error: %v", err)
+	}
+	if got.Database == nil || got.Database.Host != "db" {
+		t.Errorf("nested field was not set, got %+v", got.Database)
+	}
+	if got.Cache != nil {
+		t.Errorf("nested struct without flags was allocated, got %+v", got.Cache)
+	}
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "NAME=\nDB_HOST=db\nDB_PORT=0\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+}
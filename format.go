@@ -0,0 +1,80 @@
+package envfile
+
+import (
+	"strings"
+)
+
+// FormatOptions controls the output of Format.
+type FormatOptions struct {
+	// Quoting decides which values are enclosed in double quotes. Values
+	// that would otherwise not be read back unchanged are always quoted,
+	// so QuoteNever and QuoteAsNeeded have the same result.
+	Quoting Quoting
+	// Sort orders the variables by name, see Document.Sort.
+	Sort bool
+}
+
+// Format returns EnvironmentFile data in canonical form, so files written by
+// different people and programs look the same and differ only in their
+// content. Comments and the variables they describe are preserved, and the
+// result decodes to the same variables as data:
+//
+//   - leading and trailing whitespace is removed from all lines, as is the
+//     whitespace around the '=' and after the "export" keyword
+//   - values are quoted according to opts.Quoting, in double quotes, except
+//     single-quoted values with a '$' which keep their quotes as they are
+//     not expanded
+//   - consecutive blank lines are replaced by one, blank lines at the start
+//     and end are removed and the last line ends with a line ending
+//
+// It returns a ErrorLineParsing when data can not be parsed.
+func Format(data []byte, opts FormatOptions) ([]byte, error) {
+	d, err := ParseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	lines := d.lines[:0]
+	for _, l := range d.lines {
+		switch {
+		case l.assign:
+			_, export := cutExport(strings.TrimSpace(l.raw))
+			l.raw = l.key + "=" + canonicalValue(l.value, l.literal, l.quote, opts.Quoting)
+			if export {
+				l.raw = "export " + l.raw
+			}
+		case isBlankLine(l.raw):
+			if len(lines) == 0 || isBlankLine(lines[len(lines)-1].raw) {
+				continue
+			}
+			l.raw = ""
+		default:
+			l.raw = strings.TrimSpace(l.raw)
+		}
+		lines = append(lines, l)
+	}
+	for len(lines) > 0 && isBlankLine(lines[len(lines)-1].raw) {
+		lines = lines[:len(lines)-1]
+	}
+	d.lines, d.noEOL = lines, false
+	if opts.Sort {
+		d.Sort(nil)
+	}
+	if len(d.lines) == 0 {
+		return []byte{}, nil
+	}
+	return d.Bytes(), nil
+}
+
+// canonicalValue returns value written according to the quoting policy. The
+// quote character q the value was enclosed in is only kept for single-quoted
+// values that contain a '$'. The '$' characters at the offsets in literal
+// stay escaped, the others stay references.
+func canonicalValue(value string, literal []int, q byte, quoting Quoting) string {
+	switch {
+	case q == '\'' && strings.Contains(value, "$"):
+		return "'" + value + "'"
+	case quoting == QuoteAlways || len(literal) > 0 || needsQuotingRefs(value):
+		return quoteRefs(value, literal, false)
+	}
+	return value
+}
@@ -0,0 +1,83 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+var formatCases = []struct {
+	Name   string
+	Input  string
+	Opts   FormatOptions
+	Output string
+}{
+	{
+		Name:   "spacing",
+		Input:  "\n\n  # Comment.  \nKEY = value  \nexport   OTHER=x\n\n\n\nLAST=1",
+		Output: "# Comment.\nKEY=value\nexport OTHER=x\n\nLAST=1\n",
+	},
+	{
+		Name:   "quoting as needed",
+		Input:  "A=\"plain\"\nB='with space'\nC=\"tab\\there\"\nD='$HOME'\nE=$HOME\nF=''\n",
+		Output: "A=plain\nB=\"with space\"\nC=\"tab\\there\"\nD='$HOME'\nE=$HOME\nF=\n",
+	},
+	{
+		Name:   "quoting always",
+		Input:  "A=plain\nB='$HOME'\nC=\n",
+		Opts:   FormatOptions{Quoting: QuoteAlways},
+		Output: "A=\"plain\"\nB='$HOME'\nC=\"\"\n",
+	},
+	{
+		Name:   "escaped dollar sign",
+		Input:  "A=\"\\$HOME is $HOME\"\n",
+		Output: "A=\"\\$HOME is $HOME\"\n",
+	},
+	{
+		Name:   "sorted",
+		Input:  "# Header.\n\n# Port.\nPORT=80\nHOST=localhost\n",
+		Opts:   FormatOptions{Sort: true},
+		Output: "# Header.\n\nHOST=localhost\n# Port.\nPORT=80\n",
+	},
+	{
+		Name:   "crlf",
+		Input:  "A = 1\r\n\r\n\r\nB=2\r\n",
+		Output: "A=1\r\n\r\nB=2\r\n",
+	},
+	{
+		Name:   "empty",
+		Input:  "\n\n",
+		Output: "",
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range formatCases {
+		got, err := Format([]byte(c.Input), c.Opts)
+		if err != nil {
+			t.Errorf("[%s] format returned an error: %v", c.Name, err)
+			continue
+		}
+		if string(got) != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%q\ngot:\n%q", c.Name, c.Output, got)
+		}
+		if before, after := documentValues(t, c.Input), documentValues(t, string(got)); !reflect.DeepEqual(before, after) {
+			t.Errorf("[%s] variables did not match\nwant:\n%v\ngot:\n%v", c.Name, before, after)
+		}
+	}
+	if _, err := Format([]byte("INVALID\n"), FormatOptions{}); err != (ErrorLineParsing{1}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorLineParsing{1}, err)
+	}
+}
+
+// documentValues returns the values of the variables in data.
+func documentValues(t *testing.T, data string) map[string]string {
+	d, err := ParseDocument([]byte(data))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	values := make(map[string]string)
+	for key, e := range d.All() {
+		values[key] = e.Value
+	}
+	return values
+}
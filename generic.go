@@ -0,0 +1,20 @@
+package envfile
+
+// UnmarshalInto parses the EnvironmentFile encoded data into a new value of
+// struct type T and returns it. It avoids having to declare the value and
+// pass a pointer to it.
+//
+// See the documentation for Unmarshal for details about the conversion.
+func UnmarshalInto[T any](data []byte) (T, error) {
+	var v T
+	err := Unmarshal(data, &v)
+	return v, err
+}
+
+// MarshalFor returns the EnvironmentFile encoding of v. Unlike Marshal, the
+// type of v is known at compile time.
+//
+// See the documentation for Marshal for details about the conversion.
+func MarshalFor[T any](v T) ([]byte, error) {
+	return Marshal(v)
+}
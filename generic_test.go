@@ -0,0 +1,37 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalInto(t *testing.T) {
+	type config struct {
+		Name string
+		Host string `env:"HOST"`
+	}
+	got, err := UnmarshalInto[config]([]byte("NAME=app\nHOST=localhost\n"))
+	if err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if want := (config{Name: "app", Host: "localhost"}); got != want {
+		t.Errorf("output did not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	out, err := MarshalFor(got)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if want := "NAME=app\nHOST=localhost\n"; string(out) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, out)
+	}
+	ptr, err := UnmarshalInto[*config]([]byte("NAME=app\n"))
+	if err != nil {
+		t.Fatalf("unmarshal into pointer returned an error: %v", err)
+	}
+	if ptr == nil || ptr.Name != "app" {
+		t.Errorf("pointer was not allocated and assigned, got %+v", ptr)
+	}
+	if _, err := UnmarshalInto[*string]([]byte("NAME=app\n")); err != (ErrorUnsupportedType{reflect.String}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorUnsupportedType{reflect.String}, err)
+	}
+}
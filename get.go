@@ -0,0 +1,37 @@
+package envfile
+
+import (
+	"reflect"
+)
+
+// A Getter looks up the values of variables by name. It is implemented by
+// Document and Vars.
+type Getter interface {
+	Get(key string) (value string, ok bool)
+}
+
+// Vars is a Getter for variables held in a map.
+type Vars map[string]string
+
+// Get returns the value of the variable key and whether it is set.
+func (v Vars) Get(key string) (string, bool) {
+	value, ok := v[key]
+	return value, ok
+}
+
+// Get returns the value of the variable key converted to type T, using the
+// same conversions as Unmarshal uses for a field of type T. This allows
+// single values to be read without defining a struct:
+//
+//	port, err := envfile.Get[int](doc, "PORT")
+//
+// A ErrorMissingKey is returned when the variable is not set.
+func Get[T any](g Getter, key string) (T, error) {
+	var v T
+	value, ok := g.Get(key)
+	if !ok {
+		return v, ErrorMissingKey{key}
+	}
+	err := setField(reflect.ValueOf(&v).Elem(), key, value, false)
+	return v, err
+}
@@ -0,0 +1,37 @@
+package envfile
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	doc, err := ParseDocument([]byte("PORT=8080\nDEBUG=true\nTIMEOUT=5s\nHOSTS=a,b\nBAD=x\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	if got, err := Get[int](doc, "PORT"); err != nil || got != 8080 {
+		t.Errorf("int did not match, got %v, %v", got, err)
+	}
+	if got, err := Get[bool](doc, "DEBUG"); err != nil || !got {
+		t.Errorf("bool did not match, got %v, %v", got, err)
+	}
+	if got, err := Get[time.Duration](doc, "TIMEOUT"); err != nil || got != 5*time.Second {
+		t.Errorf("duration did not match, got %v, %v", got, err)
+	}
+	if got, err := Get[[]string](doc, "HOSTS"); err != nil || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("slice did not match, got %v, %v", got, err)
+	}
+	if _, err := Get[string](doc, "MISSING"); err != (ErrorMissingKey{"MISSING"}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorMissingKey{"MISSING"}, err)
+	}
+	if _, err := Get[int](doc, "BAD"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("conversion error did not match, got %v", err)
+	}
+	if got, err := Get[uint8](Vars{"LEVEL": "3"}, "LEVEL"); err != nil || got != 3 {
+		t.Errorf("map value did not match, got %v, %v", got, err)
+	}
+}
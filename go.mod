@@ -1,3 +1,3 @@
 module github.com/basvdlei/envfile
 
-go 1.15
+go 1.23
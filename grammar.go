@@ -0,0 +1,45 @@
+package envfile
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/basvdlei/envfile/internal/tag"
+)
+
+// Valid reports whether data is a valid EnvironmentFile in the strict dialect
+// described in the package documentation.
+func Valid(data []byte) bool {
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		if l, ok := parseLineStrict(lr.Text()); l != nil && !ok {
+			return false
+		}
+	}
+	return lr.Err() == nil
+}
+
+// parseLineStrict is like parseLine but only accepts lines that match the
+// grammar of the strict dialect: names must be valid, must not look like
+// other names and the '=' separator must not be surrounded by whitespace.
+func parseLineStrict(s string) (l *line, ok bool) {
+	l, ok = parseLine(s)
+	if l == nil || !ok {
+		return l, ok
+	}
+	s = strings.Trim(s, " \t")
+	if tag.CheckNameStrict(l.Key) != nil || s != strings.TrimSpace(s) {
+		return l, false
+	}
+	s, _ = cutExport(s)
+	i := strings.IndexByte(s, '=')
+	if i <= 0 || isBlank(s[i-1]) || (i+1 < len(s) && isBlank(s[i+1])) {
+		return l, false
+	}
+	return l, true
+}
+
+// isBlank reports whether c is a space or tab.
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
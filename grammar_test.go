@@ -0,0 +1,57 @@
+package envfile
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/basvdlei/envfile/conformance"
+)
+
+// tokenVars returns the assignments read by Token from input, and false when
+// a line could not be parsed.
+func tokenVars(input string, strict bool) ([]conformance.Var, bool) {
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetStrict(strict)
+	var vars []conformance.Var
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return vars, true
+		}
+		if err != nil || tok.Kind == TokenError {
+			return nil, false
+		}
+		if tok.Kind == TokenAssignment {
+			vars = append(vars, conformance.Var{Key: tok.Key, Value: tok.Value})
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	for _, c := range conformance.Cases {
+		if got := Valid([]byte(c.Input)); got != c.Valid {
+			t.Errorf("[%s] Valid did not match, want: %v, got %v", c.Name, c.Valid, got)
+		}
+		vars, ok := tokenVars(c.Input, true)
+		if ok != c.Valid {
+			t.Errorf("[%s] strict Token did not match, want valid: %v, got %v", c.Name, c.Valid, ok)
+		}
+		if ok && c.Valid && !reflect.DeepEqual(vars, c.Vars) {
+			t.Errorf("[%s] variables did not match\nwant:\n%q\ngot:\n%q", c.Name, c.Vars, vars)
+		}
+		dec := NewDecoder(strings.NewReader(c.Input))
+		dec.SetStrict(true)
+		if err := dec.Decode(&struct{}{}); (err == nil) != c.Valid {
+			t.Errorf("[%s] strict Decode did not match, want valid: %v, got error %v", c.Name, c.Valid, err)
+		}
+		if !c.Valid {
+			continue
+		}
+		// The lenient dialect reads valid input the same way.
+		if vars, _ := tokenVars(c.Input, false); !reflect.DeepEqual(vars, c.Vars) {
+			t.Errorf("[%s] lenient variables did not match\nwant:\n%q\ngot:\n%q", c.Name, c.Vars, vars)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package envfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Handler returns an http.Handler that renders the configuration held by v,
+// a struct or a pointer to a struct, with the values of fields with the
+// "secret" option replaced by Redacted. It is meant to be mounted on an
+// internal admin endpoint.
+//
+// The configuration is written in the EnvironmentFile format, or as a JSON
+// object mapping the variable names to their values when the request has the
+// query parameter format=json or accepts application/json.
+func Handler(v interface{}) http.Handler {
+	return HandlerFunc(func() interface{} { return v })
+}
+
+// HandlerFunc is like Handler but calls current on every request to get the
+// configuration to render. Use it when the configuration is replaced while
+// the program runs, current must then return a value that is not modified
+// concurrently.
+func HandlerFunc(current func() interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := reflect.Indirect(reflect.ValueOf(current()))
+		if !v.IsValid() {
+			http.Error(w, ErrorUnsupportedType{reflect.Invalid}.Error(), http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Query().Get("format") == "json" ||
+			strings.Contains(r.Header.Get("Accept"), "application/json") {
+			values, err := redactedValues(v.Interface())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(values)
+			return
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetRedact(true)
+		if err := enc.Encode(v.Interface()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,71 @@
+package envfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	v := &struct {
+		Host     string
+		Password string `env:"PASSWORD,secret"`
+	}{
+		Host:     "localhost",
+		Password: "hunter2",
+	}
+	h := Handler(v)
+	cases := []struct {
+		Name        string
+		Target      string
+		Accept      string
+		ContentType string
+		Body        string
+	}{
+		{
+			Name:        "environment file",
+			Target:      "/config",
+			ContentType: "text/plain; charset=utf-8",
+			Body:        "HOST=localhost\nPASSWORD=" + Redacted + "\n",
+		},
+		{
+			Name:        "json query parameter",
+			Target:      "/config?format=json",
+			ContentType: "application/json",
+			Body:        `{"HOST":"localhost","PASSWORD":"` + Redacted + `"}` + "\n",
+		},
+		{
+			Name:        "json accept header",
+			Target:      "/config",
+			Accept:      "application/json",
+			ContentType: "application/json",
+			Body:        `{"HOST":"localhost","PASSWORD":"` + Redacted + `"}` + "\n",
+		},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, c.Target, nil)
+		if c.Accept != "" {
+			r.Header.Set("Accept", c.Accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("[%s] status did not match, want %d, got %d", c.Name, http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != c.ContentType {
+			t.Errorf("[%s] content type did not match, want %q, got %q", c.Name, c.ContentType, got)
+		}
+		if got := w.Body.String(); got != c.Body {
+			t.Errorf("[%s] body did not match\nwant:\n%q,\tgot\n%q", c.Name, c.Body, got)
+		}
+	}
+}
+
+func TestHandlerFuncError(t *testing.T) {
+	h := HandlerFunc(func() interface{} { return "not a struct" })
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/config", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status did not match, want %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package envfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultIncludeDepth is the number of nested #include directives an
+// Includer follows by default.
+const DefaultIncludeDepth = 8
+
+// includeDirective starts a line that includes another file.
+const includeDirective = "#include"
+
+// ErrorInclude is returned when an #include directive can not be resolved,
+// because the file can not be read, the files include each other or they are
+// nested too deep.
+type ErrorInclude struct {
+	// Path is the file containing the directive and Line its 1-based
+	// line number.
+	Path string
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e ErrorInclude) Error() string {
+	return fmt.Sprintf("%s:%d: include: %v", e.Path, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrorInclude) Unwrap() error {
+	return e.Err
+}
+
+// An Includer reads EnvironmentFile data and replaces every line of the form
+//
+//	#include other.env
+//
+// with the contents of the named file, so shared fragments do not have to be
+// duplicated. Names are resolved relative to the directory of the including
+// file. Included files can include other files up to the maximum depth, a
+// file that includes itself, directly or through other files, is reported as
+// a ErrorInclude.
+//
+// Other implementations see the directive as a comment. Line numbers in
+// errors from decoding the combined data refer to the combined data, not to
+// the original files.
+type Includer struct {
+	fsys     fs.FS
+	maxDepth int
+}
+
+// NewIncluder returns an Includer that reads files from fsys, or from the
+// operating system when fsys is nil.
+func NewIncluder(fsys fs.FS) *Includer {
+	return &Includer{fsys: fsys, maxDepth: DefaultIncludeDepth}
+}
+
+// SetMaxDepth sets the number of nested #include directives that are
+// followed, a ErrorInclude is returned for directives nested deeper. A depth
+// of 0 rejects all directives.
+func (inc *Includer) SetMaxDepth(n int) {
+	inc.maxDepth = n
+}
+
+// ReadFile returns the contents of the named file with all #include
+// directives replaced.
+func (inc *Includer) ReadFile(name string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := inc.include(&buf, inc.clean(name), nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Source returns a Source that reads the named file with ReadFile.
+func (inc *Includer) Source(name string) Source {
+	return includeSource{inc, name}
+}
+
+type includeSource struct {
+	inc  *Includer
+	name string
+}
+
+func (s includeSource) Name() string { return s.name }
+
+func (s includeSource) Read(ctx context.Context) ([]byte, error) {
+	return s.inc.ReadFile(s.name)
+}
+
+// include writes the contents of the file name to buf, replacing its
+// directives. The stack holds the files that are including it.
+func (inc *Includer) include(buf *bytes.Buffer, name string, stack []string) error {
+	data, err := inc.read(name)
+	if err != nil {
+		return err
+	}
+	stack = append(stack, name)
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		other, ok := includeName(lr.Text())
+		if !ok {
+			buf.WriteString(lr.Text())
+			buf.WriteByte('\n')
+			continue
+		}
+		other = inc.join(name, other)
+		switch {
+		case len(stack) > inc.maxDepth:
+			err = fmt.Errorf("includes nested deeper than %d", inc.maxDepth)
+		case slices.Contains(stack, other):
+			err = fmt.Errorf("include cycle %s -> %s", strings.Join(stack, " -> "), other)
+		default:
+			err = inc.include(buf, other, stack)
+			if _, ok := err.(ErrorInclude); ok {
+				return err
+			}
+		}
+		if err != nil {
+			return ErrorInclude{name, lr.Line(), err}
+		}
+	}
+	return lr.Err()
+}
+
+// includeName returns the file name of an #include directive line.
+func includeName(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	rest, ok := strings.CutPrefix(s, includeDirective)
+	if !ok || rest == "" || !isBlank(rest[0]) {
+		return "", false
+	}
+	name := strings.TrimSpace(rest)
+	return name, name != ""
+}
+
+// read returns the contents of the file name.
+func (inc *Includer) read(name string) ([]byte, error) {
+	if inc.fsys != nil {
+		return fs.ReadFile(inc.fsys, name)
+	}
+	return os.ReadFile(name)
+}
+
+// clean returns the canonical form of name, so the same file is recognized
+// when it is reached through different paths.
+func (inc *Includer) clean(name string) string {
+	if inc.fsys != nil {
+		return path.Clean(name)
+	}
+	return filepath.Clean(name)
+}
+
+// join resolves name relative to the directory of the including file.
+func (inc *Includer) join(including, name string) string {
+	if inc.fsys != nil {
+		return path.Join(path.Dir(including), name)
+	}
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(filepath.Dir(including), name)
+}
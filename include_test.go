@@ -0,0 +1,91 @@
+package envfile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIncluder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/.env":          {Data: []byte("NAME=app\n#include ../shared/db.env\nPORT=80\n")},
+		"shared/db.env":     {Data: []byte("DB_HOST=db\n  #include  common.env\n")},
+		"shared/common.env": {Data: []byte("# Common.\nREGION=eu")},
+		"cycle/a.env":       {Data: []byte("A=1\n#include b.env\n")},
+		"cycle/b.env":       {Data: []byte("B=1\n#include ./a.env\n")},
+		"missing/.env":      {Data: []byte("A=1\n#include nothere.env\n")},
+		"comment/.env":      {Data: []byte("#included=1\n#include\n")},
+		"deep/.env":         {Data: []byte("#include 1.env\n")},
+		"deep/1.env":        {Data: []byte("#include 2.env\n")},
+		"deep/2.env":        {Data: []byte("LEVEL=2\n")},
+		"self/.env":         {Data: []byte("#include .env\n")},
+	}
+	inc := NewIncluder(fsys)
+	got, err := inc.ReadFile("app/.env")
+	if err != nil {
+		t.Fatalf("read returned an error: %v", err)
+	}
+	want := "NAME=app\nDB_HOST=db\n# Common.\nREGION=eu\nPORT=80\n"
+	if string(got) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+	if got, err := inc.ReadFile("comment/.env"); err != nil || string(got) != "#included=1\n#include\n" {
+		t.Errorf("comments were not kept, got %q, %v", got, err)
+	}
+
+	cases := []struct {
+		Name string
+		// Path and Line are the location of the failing directive.
+		Path string
+		Line int
+	}{
+		{"cycle/a.env", "cycle/b.env", 2},
+		{"self/.env", "self/.env", 1},
+		{"missing/.env", "missing/.env", 2},
+	}
+	for _, c := range cases {
+		_, err := inc.ReadFile(c.Name)
+		var e ErrorInclude
+		if !errors.As(err, &e) || e.Path != c.Path || e.Line != c.Line {
+			t.Errorf("[%s] error did not match, want: ErrorInclude at %s:%d, got %v", c.Name, c.Path, c.Line, err)
+		}
+	}
+
+	inc.SetMaxDepth(1)
+	var e ErrorInclude
+	if _, err := inc.ReadFile("deep/.env"); !errors.As(err, &e) || e.Path != "deep/1.env" {
+		t.Errorf("error did not match, want: ErrorInclude in deep/1.env, got %v", err)
+	}
+	inc.SetMaxDepth(2)
+	var v struct {
+		Level int
+	}
+	if _, err := NewLoader(inc.Source("deep/.env")).Load(context.Background(), &v); err != nil || v.Level != 2 {
+		t.Errorf("load returned %d, %v", v.Level, err)
+	}
+}
+
+func TestIncluderOS(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.env")
+	if err := os.WriteFile(shared, []byte("SHARED=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "app"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	main := filepath.Join(dir, "app", ".env")
+	if err := os.WriteFile(main, []byte("#include ../shared.env\n#include "+shared+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewIncluder(nil).ReadFile(main)
+	if err != nil {
+		t.Fatalf("read returned an error: %v", err)
+	}
+	if want := "SHARED=1\nSHARED=1\n"; string(got) != want {
+		t.Errorf("output did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+}
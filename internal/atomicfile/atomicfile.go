@@ -0,0 +1,62 @@
+// Package atomicfile replaces files without exposing partially written
+// contents.
+//
+// It is shared between the envfile package and the envfile command so both
+// write files the same way.
+package atomicfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to a temporary file in the directory of name and
+// renames it over name, so readers see either the old or the new contents and
+// a crash while writing leaves the old file intact. The contents are flushed
+// to disk before the rename, and the directory after it so the rename itself
+// survives a crash.
+//
+// An existing file keeps its permissions, a new file is created with perm.
+// When name is a symbolic link the file it points to is replaced, not the
+// link.
+func WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if info, err := os.Stat(name); err == nil {
+		perm = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := write(f, data, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(name))
+}
+
+// write writes data to f, sets its permissions and closes it.
+func write(f *os.File, data []byte, perm fs.FileMode) error {
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, ".env")
+	if err := WriteFile(name, []byte("A=1\n"), 0600); err != nil {
+		t.Fatalf("write returned an error: %v", err)
+	}
+	if err := os.Chmod(name, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(name, []byte("A=2\n"), 0600); err != nil {
+		t.Fatalf("write returned an error: %v", err)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A=2\n"; string(got) != want {
+		t.Errorf("contents did not match, want %q, got %q", want, got)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := os.FileMode(0640), info.Mode().Perm(); want != got {
+		t.Errorf("permissions did not match, want %v, got %v", want, got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary file was left behind: %v", entries)
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.env")
+	link := filepath.Join(dir, ".env")
+	if err := os.WriteFile(target, []byte("A=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("can not create symbolic link: %v", err)
+	}
+	if err := WriteFile(link, []byte("A=2\n"), 0600); err != nil {
+		t.Fatalf("write returned an error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced: %v", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A=2\n"; string(got) != want {
+		t.Errorf("contents did not match, want %q, got %q", want, got)
+	}
+}
+
+func TestWriteFileMissingDir(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing", ".env")
+	if err := WriteFile(name, []byte("A=1\n"), 0600); err == nil {
+		t.Errorf("write to a missing directory did not return an error")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package atomicfile
+
+import "os"
+
+// syncDir flushes the directory entries of dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package atomicfile
+
+// syncDir does nothing, directories can not be flushed on Windows and the
+// rename is made durable by the file system.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,29 @@
+// Package filestate detects changes to files by polling.
+//
+// It is shared between the envfile package and the envfile command so both
+// watch files the same way.
+package filestate
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Of returns a description of the modification time and size of the files
+// that changes when any of the files is changed, created or removed.
+func Of(files []string) string {
+	var b []byte
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			b = append(b, "-\x00"...)
+			continue
+		}
+		b = append(b, info.ModTime().Format(time.RFC3339Nano)...)
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, info.Size(), 10)
+		b = append(b, 0)
+	}
+	return string(b)
+}
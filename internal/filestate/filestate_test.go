@@ -0,0 +1,35 @@
+package filestate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "a.env"), filepath.Join(dir, "b.env")}
+	missing := Of(files)
+	if err := os.WriteFile(files[1], []byte("A=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	created := Of(files)
+	if created == missing {
+		t.Errorf("state did not change when a file was created")
+	}
+	if Of(files) != created {
+		t.Errorf("state changed without changes to the files")
+	}
+	if err := os.WriteFile(files[1], []byte("A=12\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if Of(files) == created {
+		t.Errorf("state did not change when a file was changed")
+	}
+	if err := os.Remove(files[1]); err != nil {
+		t.Fatal(err)
+	}
+	if Of(files) != missing {
+		t.Errorf("state did not match the state before the file was created")
+	}
+}
@@ -0,0 +1,283 @@
+// Package tag parses the env struct field tags used by envfile.
+//
+// It is shared between the envfile package and the envvet analyzer so both
+// agree on the tag syntax. The envvet module keeps an identical copy in
+// envvet/internal/tag, so it can be installed without depending on envfile.
+// Changes must be made to both copies.
+package tag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options contains the options set in the field.
+type Options struct {
+	Skip      bool
+	OmitEmpty bool
+	// Quote forces the value to be quoted when encoded.
+	Quote bool
+	// Secret marks the value as sensitive, it is masked in redacted
+	// output.
+	Secret bool
+	// Order is the position of the variable in the output relative to
+	// the other variables, set with the "order=N" option.
+	Order int
+	// Encoding is "hex" or "base64" when the bytes of a string or byte
+	// slice value are encoded, set with the option of the same name.
+	Encoding string
+	// Size marks an integer value as a byte count written with a unit,
+	// like "10Mi", set with the "bytes" option.
+	Size bool
+	// Unit is the duration a bare number in a time.Duration value counts,
+	// set with the "unit=s" option. It is zero when not set.
+	Unit time.Duration
+	// Enum are the names of the integer values of the field, set with the
+	// "enum=red:1|green:2" option.
+	Enum []EnumValue
+	// Infer stores a bool, number or string in an interface field
+	// depending on the value, set with the "infer" option.
+	Infer bool
+}
+
+// EnumValue is a name for an integer value in the "enum" option.
+type EnumValue struct {
+	Name  string
+	Value int64
+}
+
+// Parse will convert a StructType field tag to an environment name and its
+// options. The returned error describes the first problem found in the tag,
+// the name and options are still filled in as far as they could be parsed.
+func Parse(fieldName, tag string) (name string, opts Options, err error) {
+	options := strings.Split(tag, ",")
+	// conversion is the first option that changes how the value is
+	// converted, only one of them can be used.
+	var conversion string
+	convert := func(key string) {
+		if conversion != "" && err == nil {
+			err = fmt.Errorf("conflicting options %q and %q", conversion, key)
+		}
+		conversion = key
+	}
+	if len(options) > 1 {
+		for _, v := range options[1:] {
+			key, value := v, ""
+			if i := strings.IndexByte(v, '='); i >= 0 {
+				key, value = v[:i], v[i+1:]
+			}
+			switch key {
+			case "order":
+				n, perr := strconv.Atoi(value)
+				if perr != nil && err == nil {
+					err = fmt.Errorf("invalid order %q", value)
+				}
+				opts.Order = n
+			case "omitempty":
+				opts.OmitEmpty = true
+			case "quote":
+				opts.Quote = true
+			case "secret":
+				opts.Secret = true
+			case "unit":
+				convert(key)
+				d, ok := units[value]
+				if !ok && err == nil {
+					err = fmt.Errorf("invalid unit %q", value)
+				}
+				opts.Unit = d
+			case "enum":
+				convert(key)
+				var perr error
+				opts.Enum, perr = parseEnum(value)
+				if perr != nil && err == nil {
+					err = perr
+				}
+			case "infer":
+				convert(key)
+				opts.Infer = true
+			case "bytes":
+				convert(key)
+				opts.Size = true
+			case "hex", "base64":
+				convert(key)
+				opts.Encoding = key
+			case "":
+				if err == nil {
+					err = fmt.Errorf("empty option in tag %q", tag)
+				}
+			default:
+				if err == nil {
+					err = fmt.Errorf("unknown option %q", v)
+				}
+			}
+		}
+	}
+	switch options[0] {
+	case "-":
+		opts.Skip = true
+		if len(options) > 1 && err == nil {
+			err = fmt.Errorf("options on ignored field have no effect")
+		}
+	case "":
+		name = KeyName(fieldName)
+	default:
+		name = options[0]
+		if !ValidName(name) && err == nil {
+			err = fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	return
+}
+
+// parseEnum parses the value of the "enum" option, a list of name:value
+// pairs separated by '|'. Names and values must be unique.
+func parseEnum(s string) ([]EnumValue, error) {
+	var enum []EnumValue
+	for _, pair := range strings.Split(s, "|") {
+		name, value, ok := strings.Cut(pair, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid enum value %q", pair)
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum value %q", pair)
+		}
+		for _, e := range enum {
+			if e.Name == name || e.Value == n {
+				return nil, fmt.Errorf("enum value %q repeats %s:%d", pair, e.Name, e.Value)
+			}
+		}
+		enum = append(enum, EnumValue{name, n})
+	}
+	return enum, nil
+}
+
+// KeyName returns the variable name used for an untagged field. The words of
+// the field name are upper-cased and separated by underscores, so HTTPPort
+// becomes HTTP_PORT and MaxRetries becomes MAX_RETRIES. A word boundary is
+// an upper case letter that follows a lower case letter or digit, or that is
+// followed by a lower case letter in a run of upper case letters.
+func KeyName(fieldName string) string {
+	runes := []rune(fieldName)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// LegacyKeyName returns the variable name used for an untagged field by
+// earlier versions, the upper-cased field name without word separators.
+func LegacyKeyName(fieldName string) string {
+	return strings.ToUpper(fieldName)
+}
+
+// ValidName reports whether name can be used as a variable name. The name
+// must be valid UTF-8, must not be empty, contain whitespace or '=' and must
+// not start with the comment character '#'.
+func ValidName(name string) bool {
+	return CheckName(name) == nil
+}
+
+// units maps the names accepted by the "unit" option to their durations,
+// the unit suffixes of time.ParseDuration.
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond, // U+00B5 micro sign
+	"μs": time.Microsecond, // U+03BC Greek letter mu
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// CheckName returns an error describing why name can not be used as a
+// variable name, or nil when it can. See ValidName for the rules.
+func CheckName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("name is empty")
+	case strings.HasPrefix(name, "#"):
+		return fmt.Errorf("name starts with the comment character '#'")
+	case !utf8.ValidString(name):
+		return fmt.Errorf("name is not valid UTF-8")
+	}
+	if i := strings.IndexFunc(name, illegal); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(name[i:])
+		return fmt.Errorf("name contains %q at offset %d", r, i)
+	}
+	return nil
+}
+
+// CheckNameStrict is like CheckName but also rejects names that can look
+// like another name: names containing invisible format characters, such as
+// zero-width spaces and direction marks, and names mixing letters of
+// different scripts, such as a Cyrillic 'А' among Latin letters.
+func CheckNameStrict(name string) error {
+	if err := CheckName(name); err != nil {
+		return err
+	}
+	var first *unicode.RangeTable
+	for i, r := range name {
+		if unicode.Is(unicode.Cf, r) {
+			return fmt.Errorf("name contains invisible character %U at offset %d", r, i)
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		script := scriptOf(r)
+		if first == nil {
+			first = script
+		} else if script != first {
+			return fmt.Errorf("name mixes scripts at %q at offset %d", r, i)
+		}
+	}
+	return nil
+}
+
+// scriptOf returns the table of the script of the letter r, or nil when it is
+// not part of a script.
+func scriptOf(r rune) *unicode.RangeTable {
+	if r < utf8.RuneSelf {
+		return unicode.Latin
+	}
+	for _, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return table
+		}
+	}
+	return nil
+}
+
+// SanitizeName returns name with whitespace, '=' and a leading '#' replaced
+// by '_', so it can be used as a variable name unless it is empty.
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if illegal(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if strings.HasPrefix(name, "#") {
+		name = "_" + name[1:]
+	}
+	return name
+}
+
+// illegal reports whether r can not be part of a variable name.
+func illegal(r rune) bool {
+	return r == '=' || unicode.IsSpace(r)
+}
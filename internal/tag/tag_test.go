@@ -0,0 +1,176 @@
+package tag
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyName(t *testing.T) {
+	cases := map[string]string{
+		"Name":        "NAME",
+		"HTTPPort":    "HTTP_PORT",
+		"MaxRetries":  "MAX_RETRIES",
+		"OAuth2Token": "O_AUTH2_TOKEN",
+		"Port2":       "PORT2",
+		"ID":          "ID",
+		"UserID":      "USER_ID",
+		"My_Var":      "MY_VAR",
+		"lowerCase":   "LOWER_CASE",
+	}
+	for in, want := range cases {
+		if got := KeyName(in); got != want {
+			t.Errorf("[%s] key name did not match, want %q, got %q", in, want, got)
+		}
+		if got := LegacyKeyName(in); got != strings.ToUpper(in) {
+			t.Errorf("[%s] legacy key name did not match, got %q", in, got)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Valid     bool
+		Sanitized string
+	}{
+		{"MY_VAR", true, "MY_VAR"},
+		{"", false, ""},
+		{"#VAR", false, "_VAR"},
+		{"MY VAR", false, "MY_VAR"},
+		{"A=B", false, "A_B"},
+		{"TAB\tVAR", false, "TAB_VAR"},
+		{"BAD\xffUTF8", false, "BAD\ufffdUTF8"},
+		{"NO\u00a0BREAK", false, "NO_BREAK"},
+	}
+	for _, c := range cases {
+		if err := CheckName(c.Name); (err == nil) != c.Valid {
+			t.Errorf("[%q] validity did not match, want %v, got error %v", c.Name, c.Valid, err)
+		}
+		if got := SanitizeName(c.Name); got != c.Sanitized {
+			t.Errorf("[%q] sanitized name did not match, want %q, got %q", c.Name, c.Sanitized, got)
+		}
+	}
+}
+
+func TestCheckNameMessage(t *testing.T) {
+	cases := map[string]string{
+		"MY VAR":        `name contains ' ' at offset 2`,
+		"NO\u00a0BREAK": `name contains '\u00a0' at offset 2`,
+	}
+	for name, want := range cases {
+		if err := CheckName(name); err == nil || err.Error() != want {
+			t.Errorf("[%q] error did not match, want: %s, got %v", name, want, err)
+		}
+	}
+}
+
+func TestCheckNameStrict(t *testing.T) {
+	cases := map[string]bool{
+		"MY_VAR":        true,
+		"GRÖSSE":        true,
+		"ΌΝΟΜΑ_1":       true,
+		"MY_VAR\u200b":  false,
+		"\ufeffMY_VAR":  false,
+		"MY_\u202eRAV":  false,
+		"P\u0410SSWORD": false,
+		"ΌΝΟΜΑ_NAME":    false,
+		"MY VAR":        false,
+		"BAD\xffUTF8":   false,
+	}
+	for name, valid := range cases {
+		if err := CheckNameStrict(name); (err == nil) != valid {
+			t.Errorf("[%q] validity did not match, want %v, got error %v", name, valid, err)
+		}
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	if _, opts, err := Parse("Key", "KEY,hex"); err != nil || opts.Encoding != "hex" {
+		t.Errorf("hex option was not parsed, got %q, %v", opts.Encoding, err)
+	}
+	if _, _, err := Parse("Key", "KEY,base64,hex"); err == nil {
+		t.Errorf("conflicting options did not return an error")
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	for unit, want := range map[string]time.Duration{"ms": time.Millisecond, "µs": time.Microsecond, "h": time.Hour} {
+		if _, opts, err := Parse("Timeout", "TIMEOUT,unit="+unit); err != nil || opts.Unit != want {
+			t.Errorf("[%s] unit option was not parsed, got %v, %v", unit, opts.Unit, err)
+		}
+	}
+	for _, tag := range []string{
+		"TIMEOUT,unit=",
+		"TIMEOUT,unit=days",
+		"TIMEOUT,unit=s,bytes",
+		"TIMEOUT,unit=1s",
+		"TIMEOUT,unit=5m",
+		"TIMEOUT,unit=-s",
+		"TIMEOUT,unit=ms1",
+	} {
+		if _, _, err := Parse("Timeout", tag); err == nil {
+			t.Errorf("[%s] invalid option did not return an error", tag)
+		}
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	_, opts, err := Parse("Color", "COLOR,enum=red:1|green:2|blue:-3")
+	want := []EnumValue{{"red", 1}, {"green", 2}, {"blue", -3}}
+	if err != nil || !reflect.DeepEqual(opts.Enum, want) {
+		t.Errorf("enum option was not parsed, want %v, got %v, %v", want, opts.Enum, err)
+	}
+	for _, tag := range []string{
+		"COLOR,enum=",
+		"COLOR,enum=red",
+		"COLOR,enum=red:x",
+		"COLOR,enum=:1",
+		"COLOR,enum=red:1|red:2",
+		"COLOR,enum=red:1|green:1",
+		"COLOR,enum=red:1,bytes",
+	} {
+		if _, _, err := Parse("Color", tag); err == nil {
+			t.Errorf("[%s] invalid option did not return an error", tag)
+		}
+	}
+}
+
+func TestParseInfer(t *testing.T) {
+	if _, opts, err := Parse("Value", "VALUE,infer"); err != nil || !opts.Infer {
+		t.Errorf("infer option was not parsed, got %v, %v", opts.Infer, err)
+	}
+	if _, _, err := Parse("Value", "VALUE,infer,hex"); err == nil {
+		t.Errorf("conflicting options did not return an error")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	if _, opts, err := Parse("Limit", "LIMIT,bytes"); err != nil || !opts.Size {
+		t.Errorf("bytes option was not parsed, got %v, %v", opts.Size, err)
+	}
+	if _, _, err := Parse("Limit", "LIMIT,bytes,hex"); err == nil {
+		t.Errorf("conflicting options did not return an error")
+	}
+}
+
+func TestEnvvetCopy(t *testing.T) {
+	want, err := os.ReadFile("tag.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", "envvet", "internal", "tag", "tag.go"))
+	if os.IsNotExist(err) {
+		t.Skip("envvet module is not available")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("envvet/internal/tag/tag.go differs from internal/tag/tag.go, copy it again")
+	}
+}
@@ -0,0 +1,55 @@
+package envfile
+
+import (
+	"bytes"
+	"iter"
+	"strings"
+)
+
+// All returns an iterator over the variable assignments in the
+// EnvironmentFile encoded data, yielding the name and value of each in the
+// order they appear. Values are unquoted like Unmarshal does, empty and
+// comment lines are skipped.
+//
+// Lines are parsed on demand, so the caller can stop early or filter without
+// the data being decoded completely. Iteration ends at the first line that
+// can not be parsed or at binary input, use Assignments to get the error.
+func All(data []byte) iter.Seq2[string, string] {
+	return func(yield func(key, value string) bool) {
+		for tok, err := range Assignments(data) {
+			if err != nil || !yield(tok.Key, tok.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Assignments returns an iterator over the variable assignments in the
+// EnvironmentFile encoded data like All, yielding each as a TokenAssignment
+// with its line number. At the first line that can not be parsed it yields a
+// ErrorLineParsing, and a ErrorBinaryInput for binary input, after which
+// iteration ends.
+func Assignments(data []byte) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		lr := newLineReader(bytes.NewReader(data))
+		for lr.Next() {
+			s := strings.TrimSpace(lr.Text())
+			l, ok := parseLine(s)
+			if l == nil {
+				continue
+			}
+			if !ok {
+				yield(Token{}, ErrorLineParsing{lr.Line()})
+				return
+			}
+			tok := Token{Kind: TokenAssignment, Line: lr.Line(), Key: l.Key, Value: l.Value, Quote: l.Quote}
+			_, tok.Export = cutExport(s)
+			if !yield(tok, nil) {
+				return
+			}
+		}
+		if err := lr.Err(); err != nil {
+			yield(Token{}, err)
+		}
+	}
+}
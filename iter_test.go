@@ -0,0 +1,81 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	data := []byte("# comment\nA=1\n\nexport B='two'\nC=3\nINVALID\nD=4")
+	var got []string
+	for k, v := range All(data) {
+		got = append(got, k+"="+v)
+	}
+	if want := []string{"A=1", "B=two", "C=3"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("pairs did not match, want %v, got %v", want, got)
+	}
+
+	got = nil
+	for k, v := range All(data) {
+		got = append(got, k+"="+v)
+		if k == "B" {
+			break
+		}
+	}
+	if want := []string{"A=1", "B=two"}; !reflect.DeepEqual(want, got) {
+		t.Errorf("pairs after early stop did not match, want %v, got %v", want, got)
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	for _, c := range []struct {
+		Name   string
+		Input  string
+		Output []Token
+		Error  error
+	}{
+		{
+			Name:  "line endings",
+			Input: "A=1\r\n# comment\r\nexport B='two'\r\n",
+			Output: []Token{
+				{Kind: TokenAssignment, Line: 1, Key: "A", Value: "1"},
+				{Kind: TokenAssignment, Line: 3, Key: "B", Value: "two", Quote: '\'', Export: true},
+			},
+		},
+		{
+			Name:   "invalid line",
+			Input:  "A=1\nINVALID\nB=2\n",
+			Output: []Token{{Kind: TokenAssignment, Line: 1, Key: "A", Value: "1"}},
+			Error:  ErrorLineParsing{2},
+		},
+		{
+			Name:   "binary input",
+			Input:  "A=1\nB=\x00\x01\n",
+			Output: []Token{{Kind: TokenAssignment, Line: 1, Key: "A", Value: "1"}},
+			Error:  ErrorBinaryInput{6, 2},
+		},
+	} {
+		var got []Token
+		var err error
+		for tok, e := range Assignments([]byte(c.Input)) {
+			if e != nil {
+				err = e
+				continue
+			}
+			got = append(got, tok)
+		}
+		if !reflect.DeepEqual(c.Output, got) {
+			t.Errorf("[%s] tokens did not match, want: %+v, got %+v", c.Name, c.Output, got)
+		}
+		if err != c.Error {
+			t.Errorf("[%s] error did not match, want: %v, got %v", c.Name, c.Error, err)
+		}
+		var pairs []string
+		for k, v := range All([]byte(c.Input)) {
+			pairs = append(pairs, k+"="+v)
+		}
+		if len(pairs) != len(c.Output) {
+			t.Errorf("[%s] pairs did not match the tokens, got %v", c.Name, pairs)
+		}
+	}
+}
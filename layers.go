@@ -0,0 +1,155 @@
+package envfile
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+)
+
+// Layer names used in a Provenance report. Files are reported by their path.
+const (
+	LayerDefault     = "default"
+	LayerEnvironment = "environment"
+)
+
+// Provenance maps variable names to the layer that supplied their value.
+type Provenance map[string]string
+
+// UnmarshalLayers stores configuration from multiple layers in the struct
+// pointed to by v. The layers are applied in the following order, where every
+// layer overrides the values set by the previous ones:
+//
+//  1. the values of the `default` struct field tags
+//  2. the files, in the order they are given
+//  3. the process environment
+//
+// The returned Provenance reports for every assigned variable which layer
+// supplied its final value. Variables that were not set by any layer are not
+// part of the report. After all layers are applied, the Validate method of all
+// Validator values is called.
+//
+// Examples of default struct field tags:
+//
+//	// Field defaults to "localhost" when not set in a file or the
+//	// environment.
+//	Host string `env:"HOST" default:"localhost"`
+func UnmarshalLayers(v interface{}, files ...string) (Provenance, error) {
+	return UnmarshalLayersLogger(nil, v, files...)
+}
+
+// UnmarshalLayersLogger is like UnmarshalLayers but reports the loading to
+// logger. Every loaded file is logged at the info level, and every default,
+// file or environment value that is assigned at the debug level, including
+// the layer whose value it overrides. The values of fields with the "secret"
+// option are replaced by Redacted. A nil logger disables logging.
+func UnmarshalLayersLogger(logger *slog.Logger, v interface{}, files ...string) (Provenance, error) {
+	return UnmarshalLayersFrom(ProcessEnvironment, logger, v, files...)
+}
+
+// UnmarshalLayersFrom is like UnmarshalLayersLogger but takes the variables of
+// the environment layer from env instead of the process environment.
+func UnmarshalLayersFrom(env Environment, logger *slog.Logger, v interface{}, files ...string) (Provenance, error) {
+	if err := checkTarget(v); err != nil {
+		return nil, err
+	}
+	p := make(Provenance)
+	secret := secretKeys(v)
+	record := func(layer string) func(key, value string) {
+		return func(key, value string) {
+			prev, overridden := p[key]
+			p[key] = layer
+			if logger == nil {
+				return
+			}
+			if secret(key) && value != "" {
+				value = Redacted
+			}
+			attrs := []any{"key", key, "value", value, "source", layer}
+			if overridden {
+				attrs = append(attrs, "overrides", prev)
+			}
+			logger.Debug("envfile: variable set", attrs...)
+		}
+	}
+	if err := applyDefaults(v, record(LayerDefault)); err != nil {
+		return p, err
+	}
+	for _, file := range files {
+		if err := decodeFile(v, file, record(file)); err != nil {
+			return p, err
+		}
+		if logger != nil {
+			logger.Info("envfile: file loaded", "path", file)
+		}
+	}
+	err := decodeEnviron(environment(env), v, "", false, record(LayerEnvironment))
+	if err != nil {
+		return p, err
+	}
+	return p, validate(v)
+}
+
+// secretKeys returns a function that reports whether the variable key maps
+// to a field of the struct pointed to by v with the "secret" option.
+func secretKeys(v interface{}) func(key string) bool {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return func(string) bool { return false }
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return func(string) bool { return false }
+	}
+	fields := typeFields(t)
+	return func(key string) bool {
+		for _, f := range fields {
+			if _, ok := f.match(key); ok && f.Opts.Secret {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// decodeFile decodes the EnvironmentFile at path into v, errors are returned
+// as a ErrorFile. When not nil, the set function is called with the name and
+// value of every assigned variable.
+func decodeFile(v interface{}, path string, set func(key, value string)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ErrorFile{path, err}
+	}
+	dec := NewDecoder(bytes.NewReader(data))
+	dec.set = set
+	if err := dec.decode(context.Background(), v); err != nil {
+		return ErrorFile{path, err}
+	}
+	return nil
+}
+
+// applyDefaults assigns the values of the `default` struct field tags to the
+// fields of v. When not nil, the set function is called with the name and
+// value of every assigned variable.
+func applyDefaults(v interface{}, set func(key, value string)) error {
+	rv, err := targetStruct(v)
+	if err != nil {
+		return err
+	}
+	for _, f := range typeFields(rv.Type()) {
+		def, ok := f.Tag.Lookup("default")
+		if !ok || f.Map {
+			continue
+		}
+		if err := setValue(fieldByIndexAlloc(rv, f.Index), f.Opts, f.Name, def, false); err != nil {
+			return err
+		}
+		if set != nil {
+			set(f.Name, def)
+		}
+	}
+	return nil
+}
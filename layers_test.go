@@ -0,0 +1,92 @@
+package envfile
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalLayers(t *testing.T) {
+	paths := writeTempFiles(t,
+		"HOST=basehost\nPORT=80\nUSER=base\n",
+		"PORT=8080\n",
+	)
+	base, override := paths[0], paths[1]
+	os.Setenv("ENVFILE_TEST_USER", "envuser")
+	defer os.Unsetenv("ENVFILE_TEST_USER")
+
+	var got struct {
+		Host    string `default:"localhost"`
+		Port    string `default:"1"`
+		User    string `env:"ENVFILE_TEST_USER" default:"nobody"`
+		Name    string `default:"myapp"`
+		Unset   string
+		Ignored string `env:"-" default:"ignored"`
+	}
+	p, err := UnmarshalLayers(&got, base, override)
+	if err != nil {
+		t.Fatalf("unmarshal layers returned an error: %v", err)
+	}
+	if got.Host != "basehost" || got.Port != "8080" || got.User != "envuser" ||
+		got.Name != "myapp" || got.Unset != "" || got.Ignored != "" {
+		t.Errorf("layers were not applied in order, got %+v", got)
+	}
+	want := Provenance{
+		"HOST":              base,
+		"PORT":              override,
+		"ENVFILE_TEST_USER": LayerEnvironment,
+		"NAME":              LayerDefault,
+	}
+	if !reflect.DeepEqual(want, p) {
+		t.Errorf("provenance did not match\nwant:\n%v\ngot:\n%v", want, p)
+	}
+}
+
+func TestUnmarshalLayersMissingFile(t *testing.T) {
+	var got struct{ Host string }
+	if _, err := UnmarshalLayers(&got, "testdata/does-not-exist.env"); err == nil {
+		t.Errorf("unmarshal layers with a missing file did not return an error")
+	}
+}
+
+func TestUnmarshalLayersLogger(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=filehost\nPASSWORD=hunter2\n")
+	os.Setenv("ENVFILE_TEST_PORT", "9090")
+	defer os.Unsetenv("ENVFILE_TEST_PORT")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	var got struct {
+		Host     string `default:"localhost"`
+		Port     string `env:"ENVFILE_TEST_PORT" default:"80"`
+		Password string `env:"PASSWORD,secret"`
+	}
+	if _, err := UnmarshalLayersLogger(logger, &got, paths[0]); err != nil {
+		t.Fatalf("unmarshal layers returned an error: %v", err)
+	}
+	want := []string{
+		`level=DEBUG msg="envfile: variable set" key=HOST value=localhost source=default`,
+		`level=DEBUG msg="envfile: variable set" key=ENVFILE_TEST_PORT value=80 source=default`,
+		`level=DEBUG msg="envfile: variable set" key=HOST value=filehost source=` + paths[0] + ` overrides=default`,
+		`level=DEBUG msg="envfile: variable set" key=PASSWORD value=******** source=` + paths[0],
+		`level=INFO msg="envfile: file loaded" path=` + paths[0],
+		`level=DEBUG msg="envfile: variable set" key=ENVFILE_TEST_PORT value=9090 source=environment overrides=default`,
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); !reflect.DeepEqual(want, got) {
+		t.Errorf("log did not match\nwant:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("log contains a secret value")
+	}
+}
@@ -0,0 +1,288 @@
+package envfile
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is the severity of a Finding.
+type Severity int
+
+// Severities, from most to least severe.
+const (
+	// SeverityError is used for lines that are not understood, or not
+	// understood the same way by all implementations.
+	SeverityError Severity = iota
+	// SeverityWarning is used for lines that are likely mistakes.
+	SeverityWarning
+	// SeverityInfo is used for style issues.
+	SeverityInfo
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return "unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler, so severities are encoded
+// by their names.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, v := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if v.String() == string(text) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// IDs of the rules returned by DefaultLintRules.
+const (
+	// RuleSyntax reports lines that can not be parsed.
+	RuleSyntax = "syntax"
+	// RuleSpacing reports whitespace around names and the '=', which
+	// other implementations treat as part of the name or value.
+	RuleSpacing = "spacing"
+	// RuleUnquotedWhitespace reports bare values with whitespace, which
+	// shells split into multiple words.
+	RuleUnquotedWhitespace = "unquoted-whitespace"
+	// RuleDuplicateKey reports variables that are set more than once.
+	RuleDuplicateKey = "duplicate-key"
+	// RuleKeyCase reports names that are not upper case.
+	RuleKeyCase = "key-case"
+)
+
+// A Finding is a problem reported by Lint.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+	// Line and Column are the 1-based start position of the problem,
+	// EndLine and EndColumn the position just after it. Columns count
+	// bytes.
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	// Fix is the suggested fix, or nil when there is none.
+	Fix *Fix
+}
+
+// String returns the finding in the form "line:column: severity: message
+// (rule)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", f.Line, f.Column, f.Severity, f.Message, f.Rule)
+}
+
+// A Fix is a suggested fix for a Finding. It replaces the text of the range of
+// the finding by NewText.
+type Fix struct {
+	Message string
+	NewText string
+}
+
+// A LintLine is a line of the input passed to the Check functions of the
+// rules.
+type LintLine struct {
+	// Line is the 1-based line number and Text the line without line
+	// ending.
+	Line int
+	Text string
+	// Valid is false when the line can not be parsed. Assign is set for
+	// assignments, for which Key, Value and Quote hold the parsed name,
+	// value and quote character.
+	Valid  bool
+	Assign bool
+	Key    string
+	Value  string
+	Quote  byte
+	// KeyStart and KeyEnd are the byte offsets of the name in Text,
+	// ValueStart and ValueEnd those of the value including its quotes.
+	KeyStart   int
+	KeyEnd     int
+	ValueStart int
+	ValueEnd   int
+}
+
+// A Rule checks the lines of the input for a kind of problem.
+type Rule struct {
+	ID       string
+	Severity Severity
+	// Check returns the findings in lines. The Rule and Severity of the
+	// findings are set by Lint when they are empty.
+	Check func(lines []LintLine) []Finding
+}
+
+// DefaultLintRules returns the rules used by Lint when none are given. The
+// result can be modified, for example to change the severity of a rule.
+func DefaultLintRules() []Rule {
+	return []Rule{
+		{RuleSyntax, SeverityError, checkSyntax},
+		{RuleSpacing, SeverityWarning, checkSpacing},
+		{RuleUnquotedWhitespace, SeverityWarning, checkUnquotedWhitespace},
+		{RuleDuplicateKey, SeverityWarning, checkDuplicateKey},
+		{RuleKeyCase, SeverityInfo, checkKeyCase},
+	}
+}
+
+// Lint checks EnvironmentFile data with the rules, or with DefaultLintRules
+// when rules is nil, and returns the findings ordered by their position.
+// Unlike the decoding functions, it does not stop at lines that can not be
+// parsed, so all problems in the input are reported at once.
+//
+// Lines below a "#envfile:disable-line" directive are not reported. An error
+// is only returned when data can not be read as text, see ErrorBinaryInput.
+func Lint(data []byte, rules []Rule) ([]Finding, error) {
+	if rules == nil {
+		rules = DefaultLintRules()
+	}
+	var lines []LintLine
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		lines = append(lines, newLintLine(lr.Line(), lr.Text()))
+	}
+	if err := lr.Err(); err != nil {
+		return nil, err
+	}
+	disabled := make(map[int]bool)
+	for _, dir := range Directives(data) {
+		if dir.Name == DirectiveDisableLine && dir.KeyLine > 0 {
+			disabled[dir.KeyLine] = true
+		}
+	}
+	var findings []Finding
+	for _, r := range rules {
+		for _, f := range r.Check(lines) {
+			if disabled[f.Line] {
+				continue
+			}
+			if f.Rule == "" {
+				f.Rule, f.Severity = r.ID, r.Severity
+			}
+			findings = append(findings, f)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return findings, nil
+}
+
+// newLintLine parses the line s with number n.
+func newLintLine(n int, s string) LintLine {
+	ll := LintLine{Line: n, Text: s}
+	l, ok := parseLine(s)
+	ll.Valid = ok
+	if l == nil || !ok {
+		return ll
+	}
+	ll.Assign, ll.Key, ll.Value, ll.Quote = true, l.Key, l.Value, l.Quote
+	rest := strings.TrimLeft(s, " \t")
+	rest, _ = cutExport(rest)
+	ll.KeyStart = len(s) - len(rest)
+	eq := ll.KeyStart + strings.IndexByte(rest, '=')
+	ll.KeyEnd = ll.KeyStart + len(l.Key)
+	after := s[eq+1:]
+	ll.ValueStart = eq + 1 + len(after) - len(strings.TrimLeft(after, " \t"))
+	ll.ValueEnd = eq + 1 + len(strings.TrimRight(after, " \t"))
+	return ll
+}
+
+// lineFinding returns a finding for the text between the byte offsets start
+// and end of line l.
+func lineFinding(l LintLine, start, end int, message string) Finding {
+	return Finding{Message: message, Line: l.Line, Column: start + 1, EndLine: l.Line, EndColumn: end + 1}
+}
+
+func checkSyntax(lines []LintLine) []Finding {
+	var findings []Finding
+	for _, l := range lines {
+		if !l.Valid {
+			findings = append(findings, lineFinding(l, 0, len(l.Text), "line is not an assignment, comment or blank line"))
+		}
+	}
+	return findings
+}
+
+func checkSpacing(lines []LintLine) []Finding {
+	var findings []Finding
+	for _, l := range lines {
+		if !l.Assign {
+			continue
+		}
+		canonical := l.Key + "=" + l.Text[l.ValueStart:l.ValueEnd]
+		if _, export := cutExport(strings.TrimSpace(l.Text)); export {
+			canonical = "export " + canonical
+		}
+		if l.Text == canonical {
+			continue
+		}
+		f := lineFinding(l, 0, len(l.Text), "whitespace around the variable name or value")
+		f.Fix = &Fix{"Remove the whitespace", canonical}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func checkUnquotedWhitespace(lines []LintLine) []Finding {
+	var findings []Finding
+	for _, l := range lines {
+		if l.Assign && l.Quote == 0 && strings.ContainsAny(l.Value, " \t") {
+			f := lineFinding(l, l.ValueStart, l.ValueEnd, "value with whitespace is not quoted")
+			f.Fix = &Fix{"Quote the value", quoteRefs(l.Value, nil, false)}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func checkDuplicateKey(lines []LintLine) []Finding {
+	var findings []Finding
+	first := make(map[string]int)
+	for _, l := range lines {
+		if !l.Assign {
+			continue
+		}
+		if n, ok := first[l.Key]; ok {
+			findings = append(findings, lineFinding(l, l.KeyStart, l.KeyEnd,
+				fmt.Sprintf("variable %q is already set on line %d", l.Key, n)))
+			continue
+		}
+		first[l.Key] = l.Line
+	}
+	return findings
+}
+
+func checkKeyCase(lines []LintLine) []Finding {
+	var findings []Finding
+	for _, l := range lines {
+		if !l.Assign {
+			continue
+		}
+		if upper := strings.ToUpper(l.Key); upper != l.Key {
+			f := lineFinding(l, l.KeyStart, l.KeyEnd, fmt.Sprintf("variable name %q is not upper case", l.Key))
+			f.Fix = &Fix{"Use upper case", upper}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,51 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	input := "# Config.\nHOST = localhost\nNAME=my app\nhost=other\nINVALID\n#envfile:disable-line\nport = 80\nexport port=\"80\"\n"
+	got, err := Lint([]byte(input), nil)
+	if err != nil {
+		t.Fatalf("lint returned an error: %v", err)
+	}
+	want := []Finding{
+		{RuleSpacing, SeverityWarning, "whitespace around the variable name or value", 2, 1, 2, 17,
+			&Fix{"Remove the whitespace", "HOST=localhost"}},
+		{RuleUnquotedWhitespace, SeverityWarning, "value with whitespace is not quoted", 3, 6, 3, 12,
+			&Fix{"Quote the value", `"my app"`}},
+		{RuleKeyCase, SeverityInfo, `variable name "host" is not upper case`, 4, 1, 4, 5,
+			&Fix{"Use upper case", "HOST"}},
+		{RuleSyntax, SeverityError, "line is not an assignment, comment or blank line", 5, 1, 5, 8, nil},
+		{RuleDuplicateKey, SeverityWarning, `variable "port" is already set on line 7`, 8, 8, 8, 12, nil},
+		{RuleKeyCase, SeverityInfo, `variable name "port" is not upper case`, 8, 8, 8, 12,
+			&Fix{"Use upper case", "PORT"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings did not match\nwant:\n%v\ngot:\n%v", want, got)
+	}
+
+	rules := []Rule{{ID: "no-empty", Severity: SeverityError, Check: func(lines []LintLine) []Finding {
+		var findings []Finding
+		for _, l := range lines {
+			if l.Assign && l.Value == "" {
+				findings = append(findings, Finding{Message: "empty value", Line: l.Line, Column: l.ValueStart + 1,
+					EndLine: l.Line, EndColumn: l.ValueEnd + 1})
+			}
+		}
+		return findings
+	}}}
+	got, err = Lint([]byte("A=1\nB=\n"), rules)
+	if err != nil {
+		t.Fatalf("lint returned an error: %v", err)
+	}
+	want = []Finding{{"no-empty", SeverityError, "empty value", 2, 3, 2, 3, nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("custom findings did not match\nwant:\n%v\ngot:\n%v", want, got)
+	}
+	if want := "2:3: error: empty value (no-empty)"; got[0].String() != want {
+		t.Errorf("string did not match, want: %q, got %q", want, got[0].String())
+	}
+}
@@ -0,0 +1,154 @@
+package envfile
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// FileSource returns a Source that reads the file at path.
+func FileSource(path string) Source {
+	return fileSource(path)
+}
+
+type fileSource string
+
+func (s fileSource) Name() string { return string(s) }
+
+func (s fileSource) Read(ctx context.Context) ([]byte, error) {
+	return os.ReadFile(string(s))
+}
+
+// FSSource returns a Source that reads the file name from fsys, for example
+// an embed.FS holding default configuration.
+func FSSource(fsys fs.FS, name string) Source {
+	return fsSource{fsys, name}
+}
+
+type fsSource struct {
+	fsys fs.FS
+	name string
+}
+
+func (s fsSource) Name() string { return s.name }
+
+func (s fsSource) Read(ctx context.Context) ([]byte, error) {
+	return fs.ReadFile(s.fsys, s.name)
+}
+
+// ReaderSource returns a Source that reads all data from r. The data is read
+// once, the name is used in errors and provenance reports.
+func ReaderSource(name string, r io.Reader) Source {
+	return &readerSource{name: name, r: r}
+}
+
+type readerSource struct {
+	name string
+	r    io.Reader
+	data []byte
+	err  error
+	read bool
+}
+
+func (s *readerSource) Name() string { return s.name }
+
+func (s *readerSource) Read(ctx context.Context) ([]byte, error) {
+	if !s.read {
+		s.data, s.err = io.ReadAll(s.r)
+		s.read = true
+	}
+	return s.data, s.err
+}
+
+// A Loader decodes configuration from an ordered list of sources into a
+// single value. Every source overrides the values set by the sources before
+// it.
+type Loader struct {
+	sources     []Source
+	defaults    bool
+	envOverride bool
+	env         Environment
+}
+
+// NewLoader returns a Loader for the sources, lowest precedence first.
+func NewLoader(sources ...Source) *Loader {
+	return &Loader{sources: sources}
+}
+
+// Add appends sources to the Loader, they take precedence over the sources
+// added before.
+func (l *Loader) Add(sources ...Source) {
+	l.sources = append(l.sources, sources...)
+}
+
+// SetDefaults controls whether the values of the `default` struct field tags
+// are applied before the sources, see UnmarshalLayers.
+func (l *Loader) SetDefaults(on bool) {
+	l.defaults = on
+}
+
+// SetEnvOverride controls whether variables set in the process environment
+// are applied after the sources, so they take precedence over all of them.
+func (l *Loader) SetEnvOverride(on bool) {
+	l.envOverride = on
+}
+
+// SetEnvironment sets the Environment whose variables are applied when
+// SetEnvOverride is enabled. A nil env selects the process environment, the
+// default.
+func (l *Loader) SetEnvironment(env Environment) {
+	l.env = env
+}
+
+// Load decodes the sources into the struct pointed to by v and reports for
+// every assigned variable the source that supplied its final value, using
+// the names of the sources and the layer names of UnmarshalLayers.
+//
+// All sources are read and parsed before any field is assigned. When any of
+// them fails, v is left untouched and the errors of all sources are returned
+// as a ErrorList of ErrorFile values. After all values are assigned, the
+// Validate method of all Validator values is called.
+func (l *Loader) Load(ctx context.Context, v interface{}) (Provenance, error) {
+	if err := checkTarget(v); err != nil {
+		return nil, err
+	}
+	var errs ErrorList
+	data := make([][]byte, len(l.sources))
+	for i, src := range l.sources {
+		b, err := src.Read(ctx)
+		if err == nil {
+			_, err = readPairs(bytes.NewReader(b))
+		}
+		if err != nil {
+			errs = append(errs, ErrorFile{src.Name(), err})
+			continue
+		}
+		data[i] = b
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	p := make(Provenance)
+	if l.defaults {
+		if err := applyDefaults(v, func(key, value string) { p[key] = LayerDefault }); err != nil {
+			return p, err
+		}
+	}
+	for i, src := range l.sources {
+		name := src.Name()
+		dec := NewDecoder(bytes.NewReader(data[i]))
+		dec.set = func(key, value string) { p[key] = name }
+		if err := dec.decode(ctx, v); err != nil {
+			return p, ErrorFile{name, err}
+		}
+	}
+	if l.envOverride {
+		err := decodeEnviron(environment(l.env), v, "", false, func(key, value string) { p[key] = LayerEnvironment })
+		if err != nil {
+			return p, err
+		}
+	}
+	return p, validate(v)
+}
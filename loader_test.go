@@ -0,0 +1,71 @@
+package envfile
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type loaderConfig struct {
+	Host string `default:"localhost"`
+	Port int    `default:"80"`
+	User string `env:"ENVFILE_TEST_LOADER_USER,omitempty"`
+	Name string `env:",omitempty"`
+}
+
+func TestLoader(t *testing.T) {
+	paths := writeTempFiles(t, "PORT=8080\n")
+	os.Setenv("ENVFILE_TEST_LOADER_USER", "envuser")
+	defer os.Unsetenv("ENVFILE_TEST_LOADER_USER")
+
+	fsys := fstest.MapFS{"defaults.env": {Data: []byte("HOST=fshost\nPORT=1\n")}}
+	l := NewLoader(FSSource(fsys, "defaults.env"), FileSource(paths[0]))
+	l.Add(ReaderSource("stdin", strings.NewReader("NAME=app\n")))
+	l.SetDefaults(true)
+	l.SetEnvOverride(true)
+	var got loaderConfig
+	p, err := l.Load(context.Background(), &got)
+	if err != nil {
+		t.Fatalf("load returned an error: %v", err)
+	}
+	want := loaderConfig{Host: "fshost", Port: 8080, User: "envuser", Name: "app"}
+	if want != got {
+		t.Errorf("output did not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+	wantP := Provenance{
+		"HOST":                     "defaults.env",
+		"PORT":                     paths[0],
+		"NAME":                     "stdin",
+		"ENVFILE_TEST_LOADER_USER": LayerEnvironment,
+	}
+	if !reflect.DeepEqual(wantP, p) {
+		t.Errorf("provenance did not match\nwant:\n%v\ngot:\n%v", wantP, p)
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	fsys := fstest.MapFS{"bad.env": {Data: []byte("INVALID\n")}}
+	l := NewLoader(
+		FSSource(fsys, "missing.env"),
+		ReaderSource("good", strings.NewReader("HOST=changed\n")),
+		FSSource(fsys, "bad.env"),
+	)
+	got := loaderConfig{Host: "unchanged"}
+	_, err := l.Load(context.Background(), &got)
+	errs, ok := err.(ErrorList)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("error is not a list of two errors: %v", err)
+	}
+	if e, ok := errs[0].(ErrorFile); !ok || e.Path != "missing.env" {
+		t.Errorf("first error did not match, got %v", errs[0])
+	}
+	if want := (ErrorFile{"bad.env", ErrorLineParsing{1}}); errs[1] != want {
+		t.Errorf("second error did not match, want: %v, got %v", want, errs[1])
+	}
+	if got.Host != "unchanged" {
+		t.Errorf("value was modified: %+v", got)
+	}
+}
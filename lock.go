@@ -0,0 +1,54 @@
+package envfile
+
+import (
+	"os"
+)
+
+// LockSuffix is appended to the path of an EnvironmentFile to get the name of
+// the file that LockFile locks.
+const LockSuffix = ".lock"
+
+// LockFile acquires an exclusive advisory lock for the EnvironmentFile at
+// path, blocking until no other process holds it, and returns the function
+// that releases it. Processes that edit the same file, like the envfile
+// command and a running program, use it to serialize their changes so they
+// do not overwrite each other's edits.
+//
+// The lock is held on a separate file named path+LockSuffix, which is created
+// when it does not exist and left in place afterwards, so the file itself
+// can be replaced while it is locked. It uses flock on Unix and LockFileEx on
+// Windows, on other systems an error matching errors.ErrUnsupported is
+// returned. The lock is advisory: processes that do not call LockFile are
+// not blocked.
+func LockFile(path string) (unlock func() error, err error) {
+	f, err := os.OpenFile(path+LockSuffix, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, ErrorFile{f.Name(), err}
+	}
+	return func() error {
+		err := unlockFile(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
+
+// UpdateFileLocked is like UpdateFile but holds the lock of LockFile while the
+// file is read and written.
+func UpdateFileLocked(path string, v interface{}) (err error) {
+	unlock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if uerr := unlock(); err == nil {
+			err = uerr
+		}
+	}()
+	return UpdateFile(path, v)
+}
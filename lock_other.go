@@ -0,0 +1,18 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package envfile
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile reports that locking is not supported on this system.
+func lockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// unlockFile reports that locking is not supported on this system.
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,48 @@
+package envfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	unlock, err := LockFile(path)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("locking is not supported on this system")
+	}
+	if err != nil {
+		t.Fatalf("lock returned an error: %v", err)
+	}
+	if _, err := os.Stat(path + LockSuffix); err != nil {
+		t.Errorf("lock file was not created: %v", err)
+	}
+
+	v := struct{ Name string }{"app"}
+	done := make(chan error)
+	go func() {
+		done <- UpdateFileLocked(path, v)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("update did not wait for the lock, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := unlock(); err != nil {
+		t.Fatalf("unlock returned an error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("update returned an error: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NAME=app\n"; string(got) != want {
+		t.Errorf("output did not match, want: %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package envfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive flock on f.
+func lockFile(f *os.File) error {
+	return flock(f, syscall.LOCK_EX)
+}
+
+// unlockFile releases the flock on f.
+func unlockFile(f *os.File) error {
+	return flock(f, syscall.LOCK_UN)
+}
+
+// flock applies the operation how to f, retrying when it is interrupted by a
+// signal.
+func flock(f *os.File, how int) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package envfile
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	// lockfileExclusiveLock is the LOCKFILE_EXCLUSIVE_LOCK flag of
+	// LockFileEx.
+	lockfileExclusiveLock = 0x2
+	// maxRange is both halves of the length of the locked byte range, so
+	// the lock covers the whole file.
+	maxRange = 0xffffffff
+)
+
+// lockFile acquires an exclusive lock on the whole of f with LockFileEx.
+func lockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0,
+		maxRange, maxRange, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// unlockFile releases the lock on f.
+func unlockFile(f *os.File) error {
+	var ol syscall.Overlapped
+	r, _, err := procUnlockFileEx.Call(f.Fd(), 0,
+		maxRange, maxRange, uintptr(unsafe.Pointer(&ol)))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
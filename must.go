@@ -0,0 +1,31 @@
+package envfile
+
+import "fmt"
+
+// MustMarshal is like Marshal but panics when v can not be encoded. It
+// simplifies the use in tests and in main functions that can not continue
+// without configuration. The panic value is an error wrapping the error of
+// Marshal, as with the other Must functions.
+func MustMarshal(v interface{}) []byte {
+	b, err := Marshal(v)
+	if err != nil {
+		panic(fmt.Errorf("envfile: marshal: %w", err))
+	}
+	return b
+}
+
+// MustUnmarshal is like Unmarshal but panics when data can not be decoded.
+func MustUnmarshal(data []byte, v interface{}) {
+	if err := Unmarshal(data, v); err != nil {
+		panic(fmt.Errorf("envfile: unmarshal: %w", err))
+	}
+}
+
+// MustLoad stores the configuration from the layers described by
+// UnmarshalLayers, defaults, the files and the process environment, in the
+// struct pointed to by v. It panics when any of them can not be loaded.
+func MustLoad(v interface{}, files ...string) {
+	if _, err := UnmarshalLayers(v, files...); err != nil {
+		panic(fmt.Errorf("envfile: load: %w", err))
+	}
+}
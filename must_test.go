@@ -0,0 +1,57 @@
+package envfile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMust(t *testing.T) {
+	v := struct {
+		Name string
+	}{
+		Name: "app",
+	}
+	data := MustMarshal(v)
+	var got struct {
+		Name string
+	}
+	MustUnmarshal(data, &got)
+	if got != v {
+		t.Errorf("round trip did not match, want %+v, got %+v", v, got)
+	}
+
+	paths := writeTempFiles(t, "NAME=file\n")
+	MustLoad(&got, paths...)
+	if got.Name != "file" {
+		t.Errorf("load did not set the value, got %q", got.Name)
+	}
+}
+
+func TestMustPanics(t *testing.T) {
+	cases := map[string]struct {
+		Fn  func()
+		Err error
+	}{
+		"marshal":   {func() { MustMarshal("not a struct") }, nil},
+		"unmarshal": {func() { MustUnmarshal([]byte("INVALID"), &struct{}{}) }, ErrorLineParsing{1}},
+		"load":      {func() { MustLoad(&struct{}{}, "testdata/does-not-exist.env") }, nil},
+	}
+	for name, c := range cases {
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok {
+					t.Errorf("[%s] did not panic with an error", name)
+					return
+				}
+				if errors.Unwrap(err) == nil {
+					t.Errorf("[%s] panic did not wrap an error, got %v", name, err)
+				}
+				if c.Err != nil && !errors.Is(err, c.Err) {
+					t.Errorf("[%s] panic did not wrap %v, got %v", name, c.Err, err)
+				}
+			}()
+			c.Fn()
+		}()
+	}
+}
@@ -0,0 +1,356 @@
+package envfile
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basvdlei/envfile/internal/tag"
+)
+
+// setValue stores the variable value in the field like setField, using the
+// conversion selected by the options of the field when there is one:
+//
+//   - "hex" and "base64" decode the value into the bytes of a string or byte
+//     slice field
+//   - "bytes" parses a size with a unit, like "10Mi" or "512MB", into the
+//     number of bytes in an integer field
+//   - "unit=s" makes a bare number count the unit in a time.Duration field
+//   - "enum=red:1|green:2" stores the value named in an integer field
+//   - "infer" stores a bool, number or string in an empty interface field
+func setValue(field reflect.Value, opts envOptions, key, value string, strict bool) error {
+	switch {
+	case opts.Encoding != "":
+		return setEncoded(field, opts.Encoding, key, value)
+	case opts.Size:
+		return setSize(field, key, value)
+	case opts.Unit != 0:
+		return setDuration(field, opts.Unit, key, value, strict)
+	case opts.Enum != nil:
+		return setEnum(field, opts.Enum, key, value)
+	case opts.Infer:
+		return setInferred(field, key, value)
+	}
+	return setField(field, key, value, strict)
+}
+
+// formatValue returns the variable value of the field like formatField, using
+// the conversion selected by the options of the field when there is one. It
+// is the reverse of setValue.
+func formatValue(field reflect.Value, opts envOptions) (string, error) {
+	switch {
+	case opts.Encoding != "":
+		return formatEncoded(field, opts.Encoding)
+	case opts.Size:
+		return formatSize(field)
+	case opts.Unit != 0:
+		return formatDuration(field, opts.Unit)
+	case opts.Enum != nil:
+		return formatEnum(field, opts.Enum)
+	}
+	return formatField(field)
+}
+
+// optionType reports whether fields of type t can be used with the
+// conversion selected by the options, or with the default conversion when
+// there is none.
+func optionType(t reflect.Type, opts envOptions) bool {
+	switch {
+	case opts.Encoding != "":
+		return encodedType(t)
+	case opts.Size, opts.Enum != nil:
+		return sizeType(t)
+	case opts.Unit != 0:
+		return t == durationType
+	case opts.Infer:
+		return anyType(t)
+	}
+	return supportedType(t)
+}
+
+// decodeOptionType is like optionType but reports whether setValue can store
+// values in fields of type t with the options.
+func decodeOptionType(t reflect.Type, opts envOptions) bool {
+	if opts.Encoding == "" && !opts.Size && opts.Enum == nil && opts.Unit == 0 && !opts.Infer {
+		return decodableType(t)
+	}
+	return optionType(t, opts)
+}
+
+// encodedType reports whether fields of type t can be used with the "hex"
+// and "base64" options: strings and byte slices.
+func encodedType(t reflect.Type) bool {
+	return t.Kind() == reflect.String ||
+		t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// setEncoded decodes value with the encoding and stores the bytes in the
+// string or byte slice field. An empty value results in an empty string or
+// a nil slice.
+func setEncoded(field reflect.Value, encoding, key, value string) error {
+	if !encodedType(field.Type()) {
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	var b []byte
+	var err error
+	switch {
+	case value == "":
+	case encoding == "hex":
+		b, err = hex.DecodeString(value)
+	default:
+		b, err = base64.StdEncoding.DecodeString(value)
+	}
+	if err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	if field.Kind() == reflect.String {
+		field.SetString(string(b))
+	} else {
+		field.SetBytes(b)
+	}
+	return nil
+}
+
+// formatEncoded returns the bytes of the string or byte slice field encoded
+// with the encoding.
+func formatEncoded(field reflect.Value, encoding string) (string, error) {
+	if !encodedType(field.Type()) {
+		return "", ErrorUnsupportedType{field.Kind()}
+	}
+	var b []byte
+	if field.Kind() == reflect.String {
+		b = []byte(field.String())
+	} else {
+		b = field.Bytes()
+	}
+	if encoding == "hex" {
+		return hex.EncodeToString(b), nil
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// sizeUnits are the units of byte counts, binary units first so they are
+// preferred when formatting.
+var sizeUnits = []struct {
+	name string
+	size uint64
+}{
+	{"Ei", 1 << 60}, {"Pi", 1 << 50}, {"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10},
+	{"EB", 1e18}, {"PB", 1e15}, {"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+}
+
+// sizeType reports whether fields of type t can be used with the "bytes"
+// and "enum" options: integers.
+func sizeType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// parseSize parses a byte count with an optional unit. Binary units are
+// written as Ki, Mi, Gi, Ti, Pi and Ei, optionally followed by B, and decimal
+// units as K, M, G, T, P and E, optionally followed by B. A plain number or
+// the unit B is a number of bytes. Units are case-insensitive and the number
+// can have a fraction, as long as the result is a whole number of bytes.
+func parseSize(s string) (uint64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+	mult := uint64(1)
+	if u := strings.TrimSuffix(strings.ToUpper(unit), "B"); u != "" {
+		mult = 0
+		for _, su := range sizeUnits {
+			name := strings.ToUpper(su.name)
+			if name == u || name == u+"B" {
+				mult = su.size
+			}
+		}
+		if mult == 0 {
+			return 0, errors.New("unknown size unit " + strconv.Quote(unit))
+		}
+	}
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxUint64/mult {
+			return 0, errors.New("size " + strconv.Quote(s) + " out of range")
+		}
+		return n * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, err
+	}
+	f *= float64(mult)
+	if f != math.Trunc(f) || f >= math.MaxUint64 {
+		return 0, errors.New("size " + strconv.Quote(s) + " is not a whole number of bytes")
+	}
+	return uint64(f), nil
+}
+
+// formatSizeValue returns n with the unit that divides it exactly into the
+// smallest number, preferring binary units.
+func formatSizeValue(n uint64) string {
+	num, unit := n, ""
+	for _, su := range sizeUnits {
+		if n > 0 && n%su.size == 0 && n/su.size < num {
+			num, unit = n/su.size, su.name
+		}
+	}
+	return strconv.FormatUint(num, 10) + unit
+}
+
+// setSize parses the byte count in value and stores it in the integer field.
+// Signed fields also accept negative sizes.
+func setSize(field reflect.Value, key, value string) error {
+	if !sizeType(field.Type()) {
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	abs, neg := strings.CutPrefix(value, "-")
+	if neg && !field.CanInt() {
+		return ErrorValueParsing{key, errors.New("negative size " + strconv.Quote(value))}
+	}
+	n, err := parseSize(abs)
+	if err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	if field.CanInt() {
+		if n > math.MaxInt64 || field.OverflowInt(int64(n)) {
+			return ErrorValueParsing{key, errors.New("size " + strconv.Quote(value) + " out of range")}
+		}
+		if neg {
+			field.SetInt(-int64(n))
+		} else {
+			field.SetInt(int64(n))
+		}
+		return nil
+	}
+	if field.OverflowUint(n) {
+		return ErrorValueParsing{key, errors.New("size " + strconv.Quote(value) + " out of range")}
+	}
+	field.SetUint(n)
+	return nil
+}
+
+// formatSize returns the byte count of the integer field with a unit.
+func formatSize(field reflect.Value) (string, error) {
+	if !sizeType(field.Type()) {
+		return "", ErrorUnsupportedType{field.Kind()}
+	}
+	if field.CanInt() {
+		n := field.Int()
+		if n < 0 {
+			return "-" + formatSizeValue(uint64(-n)), nil
+		}
+		return formatSizeValue(uint64(n)), nil
+	}
+	return formatSizeValue(field.Uint()), nil
+}
+
+// setDuration stores value in the time.Duration field. A bare number counts
+// the unit, anything else is parsed by time.ParseDuration.
+func setDuration(field reflect.Value, unit time.Duration, key, value string, strict bool) error {
+	if field.Type() != durationType {
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return setField(field, key, value, strict)
+	}
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return ErrorValueParsing{key, errors.New("duration " + strconv.Quote(value) + " out of range")}
+	}
+	field.SetInt(n * int64(unit))
+	return nil
+}
+
+// formatDuration returns the time.Duration field as a bare number of the unit
+// when it is a whole number of them, and formatted like formatField
+// otherwise.
+func formatDuration(field reflect.Value, unit time.Duration) (string, error) {
+	if field.Type() != durationType {
+		return "", ErrorUnsupportedType{field.Kind()}
+	}
+	if d := field.Int(); d%int64(unit) == 0 {
+		return strconv.FormatInt(d/int64(unit), 10), nil
+	}
+	return formatField(field)
+}
+
+// setEnum stores the integer value named by value in the field.
+func setEnum(field reflect.Value, enum []tag.EnumValue, key, value string) error {
+	if !sizeType(field.Type()) {
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	for _, e := range enum {
+		if e.Name != value {
+			continue
+		}
+		if field.CanInt() {
+			if field.OverflowInt(e.Value) {
+				break
+			}
+			field.SetInt(e.Value)
+		} else {
+			if e.Value < 0 || field.OverflowUint(uint64(e.Value)) {
+				break
+			}
+			field.SetUint(uint64(e.Value))
+		}
+		return nil
+	}
+	names := make([]string, len(enum))
+	for i, e := range enum {
+		names[i] = strconv.Quote(e.Name)
+	}
+	return ErrorValueParsing{key, errors.New(strconv.Quote(value) + " is not one of " + strings.Join(names, ", "))}
+}
+
+// formatEnum returns the name of the integer value of the field. An error is
+// returned when the value has no name.
+func formatEnum(field reflect.Value, enum []tag.EnumValue) (string, error) {
+	if !sizeType(field.Type()) {
+		return "", ErrorUnsupportedType{field.Kind()}
+	}
+	for _, e := range enum {
+		if field.CanInt() && field.Int() == e.Value ||
+			field.CanUint() && e.Value >= 0 && field.Uint() == uint64(e.Value) {
+			return e.Name, nil
+		}
+	}
+	v, _ := formatField(field)
+	return "", errors.New("value " + v + " has no name in enum")
+}
+
+// setInferred stores value in the empty interface field as the first type
+// that can hold it: an int64, a float64 written in decimal or exponent
+// notation, a bool as accepted by strconv.ParseBool or a string.
+func setInferred(field reflect.Value, key, value string) error {
+	if !anyType(field.Type()) {
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	var v interface{} = value
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		v = i
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil && strings.Trim(value, "0123456789+-.eE") == "" {
+		v = f
+	} else if b, err := strconv.ParseBool(value); err == nil {
+		v = b
+	}
+	field.Set(reflect.ValueOf(v))
+	return nil
+}
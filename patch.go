@@ -0,0 +1,81 @@
+package envfile
+
+import (
+	"fmt"
+)
+
+// PatchOp is the kind of a PatchOperation.
+type PatchOp string
+
+// Patch operations.
+const (
+	// PatchSet sets Key to Value, like Document.Set.
+	PatchSet PatchOp = "set"
+	// PatchUnset removes Key, like Document.Unset.
+	PatchUnset PatchOp = "unset"
+	// PatchRename renames Key to To, like Document.Rename.
+	PatchRename PatchOp = "rename"
+)
+
+// A PatchOperation is a single change in a Patch.
+type PatchOperation struct {
+	Op    PatchOp `json:"op"`
+	Key   string  `json:"key"`
+	Value string  `json:"value,omitempty"`
+	// To is the new name of the variable for PatchRename.
+	To string `json:"to,omitempty"`
+}
+
+// A Patch is a list of changes to a Document that are applied in order. It
+// can be stored and exchanged as JSON, for example:
+//
+//	[
+//	  {"op": "set", "key": "PORT", "value": "8080"},
+//	  {"op": "rename", "key": "DB", "to": "DATABASE_URL"},
+//	  {"op": "unset", "key": "DEBUG"}
+//	]
+type Patch []PatchOperation
+
+// ErrorPatch is returned when an operation of a Patch can not be applied.
+type ErrorPatch struct {
+	// Index is the index of the operation in the patch.
+	Index int
+	Op    PatchOp
+	Err   error
+}
+
+// Error implements the error interface.
+func (e ErrorPatch) Error() string {
+	return fmt.Sprintf("patch operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrorPatch) Unwrap() error {
+	return e.Err
+}
+
+// Apply applies all operations of the patch to the document. The patch is
+// applied atomically: when an operation fails a ErrorPatch is returned and
+// the document is left unchanged. Unsetting or renaming a variable that is
+// not set is not an error.
+func (d *Document) Apply(p Patch) error {
+	tmp := d.clone()
+	for i, op := range p {
+		var err error
+		switch op.Op {
+		case PatchSet:
+			err = tmp.Set(op.Key, op.Value)
+		case PatchUnset:
+			tmp.Unset(op.Key)
+		case PatchRename:
+			err = tmp.Rename(op.Key, op.To)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return ErrorPatch{i, op.Op, err}
+		}
+	}
+	*d = *tmp
+	return nil
+}
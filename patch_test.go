@@ -0,0 +1,68 @@
+package envfile
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDocumentApply(t *testing.T) {
+	input := "# The port.\nPORT=80\nDB=postgres://db\nURL=${DB}/app\n# Debugging.\nDEBUG=1\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	var p Patch
+	data := `[
+		{"op": "set", "key": "PORT", "value": "8080"},
+		{"op": "rename", "key": "DB", "to": "DATABASE_URL"},
+		{"op": "unset", "key": "DEBUG"},
+		{"op": "unset", "key": "MISSING"},
+		{"op": "set", "key": "NEW", "value": "x"}
+	]`
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatalf("unmarshal patch returned an error: %v", err)
+	}
+	if err := d.Apply(p); err != nil {
+		t.Fatalf("apply returned an error: %v", err)
+	}
+	want := "# The port.\nPORT=8080\nDATABASE_URL=postgres://db\nURL=${DATABASE_URL}/app\nNEW=x\n"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	out, err := json.Marshal(Patch{{Op: PatchUnset, Key: "A"}, {Op: PatchRename, Key: "B", To: "C"}})
+	if err != nil {
+		t.Fatalf("marshal patch returned an error: %v", err)
+	}
+	if want := `[{"op":"unset","key":"A"},{"op":"rename","key":"B","to":"C"}]`; string(out) != want {
+		t.Errorf("json did not match, want %s, got %s", want, out)
+	}
+}
+
+func TestDocumentApplyAtomic(t *testing.T) {
+	input := "A=1\nB=2\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	err = d.Apply(Patch{
+		{Op: PatchSet, Key: "A", Value: "changed"},
+		{Op: PatchUnset, Key: "B"},
+		{Op: PatchSet, Key: "MY KEY", Value: "x"},
+	})
+	var perr ErrorPatch
+	if !errors.As(err, &perr) || perr.Index != 2 || perr.Op != PatchSet {
+		t.Errorf("error did not match, want: ErrorPatch for operation 2, got %v", err)
+	}
+	var kerr ErrorInvalidKey
+	if !errors.As(err, &kerr) {
+		t.Errorf("error does not wrap ErrorInvalidKey, got %v", err)
+	}
+	if got := string(d.Bytes()); got != input {
+		t.Errorf("failed patch modified the document\nwant:\n%q,\tgot\n%q", input, got)
+	}
+	if err := d.Apply(Patch{{Op: "delete", Key: "A"}}); err == nil {
+		t.Errorf("unknown operation did not return an error")
+	}
+}
@@ -0,0 +1,91 @@
+package envfile
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// A Policy declares rules for the variables of a Document, see
+// Document.Validate. Patterns are matched with MatchString, so they must be
+// anchored with ^ and $ to match the complete name or value.
+type Policy struct {
+	// Required are the variables that must be set.
+	Required []string
+	// Forbidden are the variables that must not be set.
+	Forbidden []string
+	// Name, when not nil, must match the names of all variables.
+	Name *regexp.Regexp
+	// Values maps variable names to the pattern their values must match.
+	Values map[string]*regexp.Regexp
+}
+
+// ErrorPolicy is a violation of a Policy.
+type ErrorPolicy struct {
+	// Line is the number of the line that violates the policy, starting
+	// at 1. It is 0 for required variables that are not set.
+	Line   int
+	Key    string
+	Reason string
+}
+
+// Error implements the error interface.
+func (e ErrorPolicy) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("variable %q %s", e.Key, e.Reason)
+	}
+	return fmt.Sprintf("line %d: variable %q %s", e.Line, e.Key, e.Reason)
+}
+
+// Validate checks the document against the policy. Instead of stopping at the
+// first violation all violations are returned as a ErrorList of ErrorPolicy,
+// in the order of the lines followed by the missing required variables.
+// Every line that sets a variable is checked, also when the variable is set
+// again later.
+//
+// The policy is extended by the directives in the document: the arguments of
+// "#envfile:required" are required as well, and the line below a
+// "#envfile:disable-line" directive is not checked.
+func (d *Document) Validate(p Policy) error {
+	var errs ErrorList
+	forbidden := make(map[string]bool)
+	for _, key := range p.Forbidden {
+		forbidden[key] = true
+	}
+	required := p.Required
+	disabled := make(map[int]bool)
+	for _, dir := range d.Directives() {
+		switch dir.Name {
+		case DirectiveRequired:
+			required = append(required[:len(required):len(required)], dir.Args...)
+		case DirectiveDisableLine:
+			disabled[dir.KeyLine] = true
+		}
+	}
+	seen := make(map[string]bool)
+	for key, e := range d.All() {
+		seen[key] = true
+		if disabled[e.Line] {
+			continue
+		}
+		if forbidden[key] {
+			errs = append(errs, ErrorPolicy{e.Line, key, "is forbidden"})
+		}
+		if p.Name != nil && !p.Name.MatchString(key) {
+			errs = append(errs, ErrorPolicy{e.Line, key,
+				fmt.Sprintf("does not match name pattern %s", p.Name)})
+		}
+		if re := p.Values[key]; re != nil && !re.MatchString(e.Value) {
+			errs = append(errs, ErrorPolicy{e.Line, key,
+				fmt.Sprintf("has value %q not matching pattern %s", e.Value, re)})
+		}
+	}
+	for _, key := range required {
+		if !seen[key] {
+			errs = append(errs, ErrorPolicy{0, key, "is required"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
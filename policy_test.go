@@ -0,0 +1,57 @@
+package envfile
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDocumentValidate(t *testing.T) {
+	input := "# Settings.\nHOST=localhost\nport=80\nDEBUG=1\nPORT=http\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	p := Policy{
+		Required:  []string{"HOST", "NAME"},
+		Forbidden: []string{"DEBUG"},
+		Name:      regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`),
+		Values:    map[string]*regexp.Regexp{"PORT": regexp.MustCompile(`^[0-9]+$`)},
+	}
+	want := ErrorList{
+		ErrorPolicy{3, "port", "does not match name pattern ^[A-Z][A-Z0-9_]*$"},
+		ErrorPolicy{4, "DEBUG", "is forbidden"},
+		ErrorPolicy{5, "PORT", `has value "http" not matching pattern ^[0-9]+$`},
+		ErrorPolicy{0, "NAME", "is required"},
+	}
+	if err := d.Validate(p); !reflect.DeepEqual(err, want) {
+		t.Errorf("error did not match\nwant:\n%v\ngot:\n%v", want, err)
+	}
+	if want := `line 4: variable "DEBUG" is forbidden`; want != (ErrorPolicy{4, "DEBUG", "is forbidden"}).Error() {
+		t.Errorf("error message did not match %q", want)
+	}
+	if err := d.Validate(Policy{Required: []string{"HOST"}}); err != nil {
+		t.Errorf("validate returned an error: %v", err)
+	}
+}
+
+func TestDocumentValidateDirectives(t *testing.T) {
+	input := "#envfile:required HOST NAME\n#envfile:disable-line\n# Kept for old clients.\nlegacy=1\nother=2\n"
+	d, err := ParseDocument([]byte(input))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	p := Policy{Required: []string{"PORT"}, Name: regexp.MustCompile(`^[A-Z]+$`)}
+	want := ErrorList{
+		ErrorPolicy{5, "other", "does not match name pattern ^[A-Z]+$"},
+		ErrorPolicy{0, "PORT", "is required"},
+		ErrorPolicy{0, "HOST", "is required"},
+		ErrorPolicy{0, "NAME", "is required"},
+	}
+	if err := d.Validate(p); !reflect.DeepEqual(err, want) {
+		t.Errorf("error did not match\nwant:\n%v\ngot:\n%v", want, err)
+	}
+	if len(p.Required) != 1 {
+		t.Errorf("policy was modified, got required %v", p.Required)
+	}
+}
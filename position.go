@@ -0,0 +1,52 @@
+package envfile
+
+import (
+	"bytes"
+)
+
+// A Position locates a variable assignment in EnvironmentFile data, for
+// editor integrations and programs that rewrite parts of the input.
+type Position struct {
+	Key string
+	// Line is the 1-based line number of the assignment.
+	Line int
+	// KeyStart and KeyEnd are the byte offsets of the name in the input,
+	// ValueStart and ValueEnd those of the value including its quotes.
+	// The value range is empty for empty values, ValueStart is then the
+	// offset after the '='.
+	KeyStart   int64
+	KeyEnd     int64
+	ValueStart int64
+	ValueEnd   int64
+}
+
+// Positions returns the positions of all assignments in data in the order of
+// their lines. A variable that is set more than once has multiple positions,
+// the last one sets its value. Lines that can not be parsed are skipped, so
+// the positions of the other lines are available while a file is edited.
+//
+// An error is only returned when data can not be read as text, see
+// ErrorBinaryInput.
+func Positions(data []byte) ([]Position, error) {
+	var positions []Position
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		l := newLintLine(lr.Line(), lr.Text())
+		if !l.Assign {
+			continue
+		}
+		offset := lr.Offset()
+		positions = append(positions, Position{
+			Key:        l.Key,
+			Line:       l.Line,
+			KeyStart:   offset + int64(l.KeyStart),
+			KeyEnd:     offset + int64(l.KeyEnd),
+			ValueStart: offset + int64(l.ValueStart),
+			ValueEnd:   offset + int64(l.ValueEnd),
+		})
+	}
+	if err := lr.Err(); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
@@ -0,0 +1,31 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPositions(t *testing.T) {
+	input := "# Comment.\r\nHOST = localhost\r\nINVALID\r\nexport NAME=\"my app\"\r\nEMPTY=\r\nHOST=db"
+	got, err := Positions([]byte(input))
+	if err != nil {
+		t.Fatalf("positions returned an error: %v", err)
+	}
+	want := []Position{
+		{"HOST", 2, 12, 16, 19, 28},
+		{"NAME", 4, 46, 50, 51, 59},
+		{"EMPTY", 5, 61, 66, 67, 67},
+		{"HOST", 6, 69, 73, 74, 76},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("positions did not match\nwant:\n%+v\ngot:\n%+v", want, got)
+	}
+	for _, p := range got {
+		if key := input[p.KeyStart:p.KeyEnd]; key != p.Key {
+			t.Errorf("[%s] key range did not match, got %q", p.Key, key)
+		}
+	}
+	if value := input[got[1].ValueStart:got[1].ValueEnd]; value != `"my app"` {
+		t.Errorf("value range did not match, want: %q, got %q", `"my app"`, value)
+	}
+}
@@ -0,0 +1,64 @@
+package envfile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// profileStart matches the comment that starts a profile block and
+	// captures its comma-separated profile names.
+	profileStart = regexp.MustCompile(`^#\s*\[profile:([^\]]+)\]$`)
+	// profileEnd matches the comment that ends a profile block.
+	profileEnd = regexp.MustCompile(`^#\s*\[end\]$`)
+)
+
+// profileFilter selects the lines of the active profile, see
+// Decoder.SetProfile.
+type profileFilter struct {
+	active string
+	// start is the line number of the start of the current block, or 0
+	// outside a block.
+	start int
+	// skip is set inside a block of other profiles.
+	skip bool
+}
+
+// line reports whether the line with number n and text s is skipped. It
+// returns a ErrorLineParsing for a block that starts inside another block
+// and for an end without a block.
+func (p *profileFilter) line(n int, s string) (skip bool, err error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "#") {
+		return p.skip, nil
+	}
+	if m := profileStart.FindStringSubmatch(s); m != nil {
+		if p.start != 0 {
+			return false, ErrorLineParsing{n}
+		}
+		names := strings.Split(m[1], ",")
+		for i := range names {
+			names[i] = strings.TrimSpace(names[i])
+		}
+		p.start, p.skip = n, p.active == "" || !slices.Contains(names, p.active)
+		return true, nil
+	}
+	if profileEnd.MatchString(s) {
+		if p.start == 0 {
+			return false, ErrorLineParsing{n}
+		}
+		p.start, p.skip = 0, false
+		return true, nil
+	}
+	return p.skip, nil
+}
+
+// end returns a ErrorLineParsing for the start of a block that is not ended
+// at the end of the input.
+func (p *profileFilter) end() error {
+	if p.start != 0 {
+		return ErrorLineParsing{p.start}
+	}
+	return nil
+}
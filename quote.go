@@ -0,0 +1,143 @@
+package envfile
+
+import (
+	"slices"
+	"strings"
+)
+
+// Quoting is the policy used by the Encoder to decide which values are
+// enclosed in quotes.
+type Quoting int
+
+// Quoting policies.
+const (
+	// QuoteNever writes values without quotes. This is the default. Values
+	// that can not be read back unchanged without them, because they start
+	// with a quote or contain surrounding whitespace or line breaks, are
+	// quoted anyway.
+	QuoteNever Quoting = iota
+	// QuoteAsNeeded quotes values that would otherwise not be read back
+	// unchanged, like values with whitespace, '#', '$', quotes or
+	// backslashes.
+	QuoteAsNeeded
+	// QuoteAlways quotes all values, including empty ones.
+	QuoteAlways
+)
+
+// needsQuoting reports whether s must be quoted to be decoded unchanged.
+func needsQuoting(s string) bool {
+	return strings.Contains(s, "$") || needsQuotingRefs(s)
+}
+
+// needsQuotingRefs is like needsQuoting for values whose '$' references are
+// meant to be expanded, so they can be written without quotes.
+func needsQuotingRefs(s string) bool {
+	return strings.ContainsAny(s, " \t\n\r\v\f#\"'\\") || !bareValue(s)
+}
+
+// bareValue reports whether s is decoded unchanged when it is written without
+// quotes: it has no surrounding whitespace, no line breaks and does not start
+// with a quote character. The Encoder quotes all other values, regardless of
+// its quoting policy.
+func bareValue(s string) bool {
+	if s == "" {
+		return true
+	}
+	return s[0] != '"' && s[0] != '\'' && s == strings.TrimSpace(s) &&
+		!strings.ContainsAny(s, "\n\r")
+}
+
+// quote returns s enclosed in double quotes with backslash, double quote,
+// dollar and control characters escaped, so it is not expanded.
+func quote(s string) string {
+	return quoteRefs(s, nil, true)
+}
+
+// quoteRefs is like quote but only escapes the '$' characters at the offsets
+// in literal, or all of them when all is set. The others are kept as
+// references that are expanded.
+func quoteRefs(s string, literal []int, all bool) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '$':
+			if all || slices.Contains(literal, i) {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// unquote parses a raw value as it appears after the '='. Bare values have
+// their surrounding whitespace removed. Values enclosed in single quotes are
+// taken literally, in values enclosed in double quotes the escape sequences
+// \\, \", \n, \r, \t and \$ are replaced. Other backslashes are kept.
+//
+// It returns the quote character that was used, or 0 for bare values, and
+// false when a quoted value is not terminated or followed by other text.
+func unquote(raw string) (value string, q byte, ok bool) {
+	value, _, q, ok = unquoteLiteral(raw)
+	return value, q, ok
+}
+
+// unquoteLiteral is like unquote and also returns the offsets in value of the
+// '$' characters that were escaped, which must not be expanded.
+func unquoteLiteral(raw string) (value string, literal []int, q byte, ok bool) {
+	s := strings.TrimSpace(raw)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return s, nil, 0, true
+	}
+	q = s[0]
+	if q == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 || end+2 != len(s) {
+			return s, nil, q, false
+		}
+		return s[1 : end+1], nil, q, true
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return b.String(), literal, q, i+1 == len(s)
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case '$':
+				literal = append(literal, b.Len())
+				b.WriteByte(s[i])
+			case '\\', '"':
+				b.WriteByte(s[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return s, nil, q, false
+}
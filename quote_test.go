@@ -0,0 +1,114 @@
+package envfile
+
+import (
+	"bytes"
+	"testing"
+)
+
+var unquoteCases = []struct {
+	Input string
+	Value string
+	Quote byte
+	OK    bool
+}{
+	{"  bare value  ", "bare value", 0, true},
+	{"", "", 0, true},
+	{`"  spaced  "`, "  spaced  ", '"', true},
+	{`"a \"quoted\" \\ \$HOME\nline\tx\q"`, "a \"quoted\" \\ $HOME\nline\tx\\q", '"', true},
+	{`'literal \n $HOME'`, `literal \n $HOME`, '\'', true},
+	{`it's`, `it's`, 0, true},
+	{`"unterminated`, ``, '"', false},
+	{`'unterminated`, ``, '\'', false},
+	{`"quoted" trailing`, ``, '"', false},
+	{`'quoted'trailing`, ``, '\'', false},
+}
+
+func TestUnquote(t *testing.T) {
+	for _, c := range unquoteCases {
+		value, q, ok := unquote(c.Input)
+		if ok != c.OK || q != c.Quote || (ok && value != c.Value) {
+			t.Errorf("[%s] want (%q, %q, %v), got (%q, %q, %v)",
+				c.Input, c.Value, c.Quote, c.OK, value, q, ok)
+		}
+	}
+}
+
+type quotingConfig struct {
+	Plain   string
+	Spaced  string
+	Comment string
+	Quotes  string
+	Empty   string
+	Forced  string `env:"FORCED,quote"`
+}
+
+var quotingValue = quotingConfig{
+	Plain:   "abc123",
+	Spaced:  " two words ",
+	Comment: "value#hash",
+	Quotes:  `say "hi" it's`,
+	Forced:  "abc",
+}
+
+func TestEncoderQuoting(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Quoting Quoting
+		Output  string
+	}{
+		{
+			Name:    "never",
+			Quoting: QuoteNever,
+			Output: `PLAIN=abc123
+SPACED=" two words "
+COMMENT=value#hash
+QUOTES=say "hi" it's
+EMPTY=
+FORCED="abc"
+`,
+		},
+		{
+			Name:    "as needed",
+			Quoting: QuoteAsNeeded,
+			Output: `PLAIN=abc123
+SPACED=" two words "
+COMMENT="value#hash"
+QUOTES="say \"hi\" it's"
+EMPTY=
+FORCED="abc"
+`,
+		},
+		{
+			Name:    "always",
+			Quoting: QuoteAlways,
+			Output: `PLAIN="abc123"
+SPACED=" two words "
+COMMENT="value#hash"
+QUOTES="say \"hi\" it's"
+EMPTY=""
+FORCED="abc"
+`,
+		},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.SetQuoting(c.Quoting)
+		if err := enc.Encode(quotingValue); err != nil {
+			t.Fatalf("[%s] encode returned an error: %v", c.Name, err)
+		}
+		if buf.String() != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%s\ngot:\n%s", c.Name, c.Output, buf.String())
+		}
+		if c.Quoting == QuoteNever {
+			continue
+		}
+		var got quotingConfig
+		if err := Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("[%s] unmarshal returned an error: %v", c.Name, err)
+		}
+		if got != quotingValue {
+			t.Errorf("[%s] round trip did not match\nwant:\n%+v\ngot:\n%+v", c.Name, quotingValue, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package envfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+// lineReader reads lines from an input stream. Unlike bufio.Scanner it has no
+// limit on the line length, and it keeps track of the line number and byte
+// offset of every line.
+type lineReader struct {
+	r *bufio.Reader
+	// data is the input when reading from memory, the lines alias it
+	// instead of being copied.
+	data   []byte
+	text   string
+	num    int
+	offset int64
+	next   int64
+	err    error
+	// legacy enables transcoding lines that are not valid UTF-8 from
+	// Windows-1252.
+	legacy bool
+}
+
+// newLineReader returns a lineReader that reads from r.
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r)}
+}
+
+// newBorrowingLineReader returns a lineReader that reads from data. The
+// returned lines share memory with data and are only valid as long as data
+// is not modified.
+func newBorrowingLineReader(data []byte) *lineReader {
+	return &lineReader{data: data}
+}
+
+// Next advances to the next line, which is then available through Text. It
+// returns false at the end of the input or when reading failed.
+func (lr *lineReader) Next() bool {
+	if lr.err != nil {
+		return false
+	}
+	s, err := lr.read()
+	if err != nil {
+		if err != io.EOF {
+			lr.err = err
+		}
+		if s == "" || lr.err != nil {
+			return false
+		}
+	}
+	lr.num++
+	lr.offset = lr.next
+	lr.next += int64(len(s))
+	if i := binaryIndex(s); i >= 0 {
+		lr.err = ErrorBinaryInput{lr.offset + int64(i), lr.num}
+		return false
+	}
+	s = strings.TrimSuffix(s, "\n")
+	lr.text = strings.TrimSuffix(s, "\r")
+	if lr.legacy {
+		lr.text = transcodeLegacy(lr.text)
+	}
+	return true
+}
+
+// read returns the next line including its line ending, or io.EOF with the
+// remainder of the input.
+func (lr *lineReader) read() (string, error) {
+	if lr.r != nil {
+		return lr.r.ReadString('\n')
+	}
+	raw := lr.data
+	if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+		raw = raw[:i+1]
+	}
+	lr.data = lr.data[len(raw):]
+	if len(raw) == 0 {
+		return "", io.EOF
+	}
+	s := unsafe.String(&raw[0], len(raw))
+	if raw[len(raw)-1] != '\n' {
+		return s, io.EOF
+	}
+	return s, nil
+}
+
+// binaryIndex returns the index of the first byte in s that does not occur in
+// text, or -1 when there is none. These are the ASCII control characters
+// other than tab, line feed, vertical tab, form feed, carriage return and
+// escape, which is used in terminal color sequences.
+func binaryIndex(s string) int {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 && (c < '\t' || c > '\r') && c != 0x1b {
+			return i
+		}
+	}
+	return -1
+}
+
+// Text returns the current line without its line ending.
+func (lr *lineReader) Text() string {
+	return lr.text
+}
+
+// Line returns the 1-based number of the current line.
+func (lr *lineReader) Line() int {
+	return lr.num
+}
+
+// Offset returns the byte offset of the start of the current line.
+func (lr *lineReader) Offset() int64 {
+	return lr.offset
+}
+
+// Err returns the first error that occurred while reading, other than io.EOF.
+func (lr *lineReader) Err() error {
+	return lr.err
+}
@@ -0,0 +1,109 @@
+package envfile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+)
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	input := "A=1\r\n\nLONG=" + long + "\nLAST=2"
+	lr := newLineReader(strings.NewReader(input))
+	want := []struct {
+		Text   string
+		Line   int
+		Offset int64
+	}{
+		{"A=1", 1, 0},
+		{"", 2, 5},
+		{"LONG=" + long, 3, 6},
+		{"LAST=2", 4, int64(len(input) - len("LAST=2"))},
+	}
+	for _, w := range want {
+		if !lr.Next() {
+			t.Fatalf("reader stopped before line %d: %v", w.Line, lr.Err())
+		}
+		if lr.Text() != w.Text || lr.Line() != w.Line || lr.Offset() != w.Offset {
+			t.Errorf("line %d did not match, got line %d at offset %d with %d bytes",
+				w.Line, lr.Line(), lr.Offset(), len(lr.Text()))
+		}
+	}
+	if lr.Next() {
+		t.Errorf("reader returned line after end of input: %q", lr.Text())
+	}
+	if lr.Err() != nil {
+		t.Errorf("reader returned an error at end of input: %v", lr.Err())
+	}
+}
+
+func TestLineReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	lr := newLineReader(iotest.ErrReader(errRead))
+	if lr.Next() {
+		t.Errorf("reader returned a line on error")
+	}
+	if lr.Err() != errRead {
+		t.Errorf("error did not match, want: %v, got %v", errRead, lr.Err())
+	}
+}
+
+func TestUnmarshalBinaryInput(t *testing.T) {
+	var v struct {
+		Host string
+		Key  string
+	}
+	cases := []struct {
+		Input string
+		Want  error
+	}{
+		{"\x00\x00\x00\x01HOST=x\n", ErrorBinaryInput{0, 1}},
+		{"HOST=x\nKEY=\x7fELF\x02\x01\n", ErrorBinaryInput{15, 2}},
+		{"HOST=\"a\tb\x1b[0m\"\r\n", nil},
+	}
+	for _, c := range cases {
+		if err := Unmarshal([]byte(c.Input), &v); err != c.Want {
+			t.Errorf("[%q] error did not match, want: %v, got %v", c.Input, c.Want, err)
+		}
+		if err := NewDecoder(strings.NewReader(c.Input)).Decode(&v); err != c.Want {
+			t.Errorf("[%q] decoder error did not match, want: %v, got %v", c.Input, c.Want, err)
+		}
+	}
+}
+
+func TestUnmarshalLongLine(t *testing.T) {
+	long := strings.Repeat("x", 100000)
+	var v struct {
+		Long string
+	}
+	if err := Unmarshal([]byte("LONG="+long+"\n"), &v); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if v.Long != long {
+		t.Errorf("value did not match, got %d bytes", len(v.Long))
+	}
+}
+
+func TestUnmarshalNoCopy(t *testing.T) {
+	data := []byte("NAME=app\nQUOTED=\"a\\tb\"\n")
+	var v struct {
+		Name   string
+		Quoted string
+	}
+	if err := UnmarshalNoCopy(data, &v); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if v.Name != "app" || v.Quoted != "a\tb" {
+		t.Errorf("values did not match, got %+v", v)
+	}
+	start := uintptr(unsafe.Pointer(&data[0]))
+	p := uintptr(unsafe.Pointer(unsafe.StringData(v.Name)))
+	if p < start || p >= start+uintptr(len(data)) {
+		t.Errorf("value was copied instead of borrowed from the input")
+	}
+	if err := UnmarshalNoCopy(nil, &v); err != nil {
+		t.Errorf("unmarshal of nil data returned an error: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package envfile
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	stringType  = reflect.TypeOf("")
+	registryMu  sync.RWMutex
+	decoderFunc = make(map[reflect.Type]reflect.Value)
+	encoderFunc = make(map[reflect.Type]reflect.Value)
+)
+
+// RegisterDecoder registers a function that converts a variable value to a
+// field of type T. The function fn must have the signature:
+//
+//	func(string) (T, error)
+//
+// Registered decoders are consulted by Unmarshal before any other conversion,
+// including encoding.TextUnmarshaler, so types from third-party packages can
+// be supported. Registering a decoder for a type replaces any previously
+// registered decoder for that type.
+//
+// RegisterDecoder panics when fn does not have the expected signature.
+func RegisterDecoder(fn interface{}) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.In(0) != stringType ||
+		ft.NumOut() != 2 || ft.Out(1) != errorType {
+		panic(fmt.Sprintf("envfile: decoder must be a func(string) (T, error), got %v", ft))
+	}
+	registryMu.Lock()
+	decoderFunc[ft.Out(0)] = fv
+	registryMu.Unlock()
+	resetStructCache()
+}
+
+// RegisterEncoder registers a function that converts a field of type T to a
+// variable value. The function fn must have the signature:
+//
+//	func(T) (string, error)
+//
+// Registered encoders are consulted by Marshal before any other conversion,
+// including encoding.TextMarshaler. Registering an encoder for a type
+// replaces any previously registered encoder for that type.
+//
+// RegisterEncoder panics when fn does not have the expected signature.
+func RegisterEncoder(fn interface{}) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.NumOut() != 2 ||
+		ft.Out(0) != stringType || ft.Out(1) != errorType {
+		panic(fmt.Sprintf("envfile: encoder must be a func(T) (string, error), got %v", ft))
+	}
+	registryMu.Lock()
+	encoderFunc[ft.In(0)] = fv
+	registryMu.Unlock()
+	resetStructCache()
+}
+
+// registeredDecoder returns the decoder registered for type t.
+func registeredDecoder(t reflect.Type) (reflect.Value, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := decoderFunc[t]
+	return fn, ok
+}
+
+// registeredEncoder returns the encoder registered for type t.
+func registeredEncoder(t reflect.Type) (reflect.Value, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := encoderFunc[t]
+	return fn, ok
+}
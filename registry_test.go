@@ -0,0 +1,75 @@
+package envfile
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// upper is a third-party like type that does not implement
+// encoding.TextUnmarshaler.
+type upper struct {
+	s string
+}
+
+func init() {
+	RegisterDecoder(func(s string) (upper, error) {
+		if s == "" {
+			return upper{}, errors.New("empty value")
+		}
+		return upper{strings.ToUpper(s)}, nil
+	})
+	RegisterEncoder(func(u upper) (string, error) {
+		return strings.ToLower(u.s), nil
+	})
+}
+
+type registryConfig struct {
+	Name upper
+	IP   net.IP
+}
+
+func TestRegisteredConversions(t *testing.T) {
+	want := registryConfig{
+		Name: upper{"FOO"},
+		IP:   net.ParseIP("127.0.0.1"),
+	}
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal returned an error: %v", err)
+	}
+	if string(data) != "NAME=foo\nIP=127.0.0.1\n" {
+		t.Errorf("output did not match, got %q", data)
+	}
+	var got registryConfig
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("output does not match\nwant:\n%+v,\tgot\n%+v", want, got)
+	}
+}
+
+func TestRegisteredDecoderError(t *testing.T) {
+	var got registryConfig
+	err := Unmarshal([]byte("IP=not-an-ip\n"), &got)
+	var perr ErrorValueParsing
+	if !errors.As(err, &perr) || perr.Key != "IP" {
+		t.Errorf("want ErrorValueParsing for IP, got %v", err)
+	}
+	err = Unmarshal([]byte("NAME=\n"), &got)
+	if !errors.As(err, &perr) || perr.Key != "NAME" {
+		t.Errorf("want ErrorValueParsing for NAME, got %v", err)
+	}
+}
+
+func TestRegisterInvalidFunc(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering an invalid decoder did not panic")
+		}
+	}()
+	RegisterDecoder(func(i int) (upper, error) { return upper{}, nil })
+}
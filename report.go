@@ -0,0 +1,77 @@
+package envfile
+
+import (
+	"bytes"
+)
+
+// A Report lists what was assigned by UnmarshalReport, to tell a value that is
+// absent from a value explicitly set to the zero value without using pointer
+// fields.
+type Report struct {
+	// Keys holds the names of the variables that were assigned to a field.
+	Keys map[string]bool
+	// Fields holds the Go field paths, like "Database.Host", of the fields
+	// that were assigned. A map field is listed when at least one entry was
+	// assigned.
+	Fields map[string]bool
+}
+
+// UnmarshalReport is like Unmarshal but also reports which variables and
+// fields were assigned. Variables that do not map to a field, and empty
+// values of fields with the "omitempty" option, are not part of the report.
+// When an error is returned, the report lists what was assigned before it
+// occurred.
+func UnmarshalReport(data []byte, v interface{}) (Report, error) {
+	r := Report{Keys: make(map[string]bool), Fields: make(map[string]bool)}
+	values := make(map[string]string)
+	dec := NewDecoder(bytes.NewReader(data))
+	dec.set = func(key, value string) {
+		r.Keys[key] = true
+		values[key] = value
+	}
+	err := dec.Decode(v)
+	t, terr := targetType(v)
+	if terr != nil {
+		return r, err
+	}
+	si := cachedStruct(t)
+	for _, f := range si.fields {
+		for key, value := range values {
+			if _, ok := f.match(key); ok && !(f.Opts.OmitEmpty && value == "") {
+				path, _ := fieldPath(t, f)
+				r.Fields[path] = true
+				break
+			}
+		}
+	}
+	return r, err
+}
+
+// UnmarshalStrict is like Unmarshal but also requires a value for every field
+// without the "omitempty" option, so incomplete configuration is reported in
+// one call at startup. Missing variables are returned as a ErrorList of
+// ErrorMissingKey, in the order of the fields, after the values that are set
+// have been stored. Map fields are never required and ignored fields, with
+// the tag "-", are not considered.
+//
+// Unlike Decoder.SetStrict it does not change the syntax that is accepted.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	r, err := UnmarshalReport(data, v)
+	if err != nil {
+		return err
+	}
+	t, _ := targetType(v)
+	var errs ErrorList
+	for _, f := range cachedStruct(t).fields {
+		if f.Map || f.Opts.OmitEmpty {
+			continue
+		}
+		if path, _ := fieldPath(t, f); !r.Fields[path] {
+			errs = append(errs, ErrorMissingKey{f.Name})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
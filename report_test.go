@@ -0,0 +1,68 @@
+package envfile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalReport(t *testing.T) {
+	type database struct {
+		Host string
+		Port int
+	}
+	var v struct {
+		Debug    bool
+		Name     string `env:",omitempty"`
+		Labels   map[string]string
+		Database database
+	}
+	input := "DEBUG=false\nNAME=\nLABELS_TEAM=core\nDATABASE_PORT=0\nUNKNOWN=1\n"
+	got, err := UnmarshalReport([]byte(input), &v)
+	if err != nil {
+		t.Fatalf("unmarshal returned an error: %v", err)
+	}
+	want := Report{
+		Keys:   map[string]bool{"DEBUG": true, "LABELS_TEAM": true, "DATABASE_PORT": true},
+		Fields: map[string]bool{"Debug": true, "Labels": true, "Database.Port": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("report did not match\nwant:\n%+v\ngot:\n%+v", want, got)
+	}
+
+	got, err = UnmarshalReport([]byte("DEBUG=true\nDATABASE_PORT=http\n"), &v)
+	if _, ok := err.(ErrorValueParsing); !ok {
+		t.Errorf("error did not match, want: ErrorValueParsing, got %v", err)
+	}
+	if !got.Fields["Debug"] || got.Fields["Database.Port"] {
+		t.Errorf("report before error did not match, got %+v", got)
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	var v struct {
+		Host    string
+		Port    int
+		Debug   bool `env:",omitempty"`
+		Ignored int  `env:"-"`
+		Labels  map[string]string
+		Nested  struct {
+			User string
+		}
+	}
+	err := UnmarshalStrict([]byte("HOST=localhost\nPORT=0\nNESTED_USER=\n"), &v)
+	if err != nil {
+		t.Errorf("unmarshal returned an error: %v", err)
+	}
+	err = UnmarshalStrict([]byte("HOST=db\n"), &v)
+	want := ErrorList{ErrorMissingKey{"PORT"}, ErrorMissingKey{"NESTED_USER"}}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("error did not match, want: %v, got %v", want, err)
+	}
+	if v.Host != "db" {
+		t.Errorf("value did not match, want: %q, got %q", "db", v.Host)
+	}
+	if err := UnmarshalStrict([]byte("PORT=x\n"), &v); !errors.As(err, new(ErrorValueParsing)) {
+		t.Errorf("error did not match, want: ErrorValueParsing, got %v", err)
+	}
+}
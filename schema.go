@@ -0,0 +1,201 @@
+package envfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect used by JSONSchema.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of the JSON Schema vocabulary used to describe
+// an EnvironmentFile.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type"`
+	Default              *string                `json:"default,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]*jsonSchema `json:"patternProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// JSONSchema returns a JSON Schema document describing the variables that
+// Unmarshal accepts for v.
+//
+// The EnvironmentFile is described as an object where every variable is a
+// property with a string value. Fields without the "omitempty" option are
+// listed as required, the values of `default` struct field tags are included
+// as defaults, the names of the "enum" option as allowed values and variables
+// that do not map to a field are not allowed. The values of integer and bool
+// fields without conversion options are described by a pattern. The entries
+// of map fields are described by a pattern matching their prefix.
+//
+// Like Marshal, it will return a ErrorUnsupportedType when v is not a struct
+// or contains fields of unsupported types that are not explicitly ignored.
+func JSONSchema(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return []byte{}, ErrorUnsupportedType{reflect.Invalid}
+	}
+	if k := t.Kind(); k != reflect.Struct {
+		return []byte{}, ErrorUnsupportedType{k}
+	}
+	si := cachedStruct(t)
+	if si.err != nil {
+		return []byte{}, si.err
+	}
+	additional := false
+	s := jsonSchema{
+		Schema:               jsonSchemaDraft,
+		Type:                 "object",
+		Properties:           make(map[string]*jsonSchema),
+		AdditionalProperties: &additional,
+	}
+	for _, f := range si.fields {
+		if f.Map {
+			if s.PatternProperties == nil {
+				s.PatternProperties = make(map[string]*jsonSchema)
+			}
+			pattern := "^" + regexp.QuoteMeta(f.Name+"_")
+			switch f.Type.Key().Kind() {
+			case reflect.String:
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				pattern += "[+-]?[0-9]+$"
+			default:
+				pattern += "[+]?[0-9]+$"
+			}
+			s.PatternProperties[pattern] = &jsonSchema{Type: "string"}
+			continue
+		}
+		if !f.supported() {
+			return []byte{}, ErrorUnsupportedType{f.Type.Kind()}
+		}
+		s.Properties[f.Name] = &jsonSchema{Type: "string"}
+		s.Properties[f.Name].Pattern = valuePattern(f)
+		for _, e := range f.Opts.Enum {
+			s.Properties[f.Name].Enum = append(s.Properties[f.Name].Enum, e.Name)
+		}
+		if def, ok := f.Tag.Lookup("default"); ok {
+			s.Properties[f.Name].Default = &def
+		}
+		if !f.Opts.OmitEmpty {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// boolPattern matches the values accepted for bool fields outside strict mode.
+const boolPattern = "^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False|" +
+	"[Yy][Ee][Ss]|[Nn][Oo]|[Oo][Nn]|[Oo][Ff][Ff]|" +
+	"[Ee][Nn][Aa][Bb][Ll][Ee][Dd]|[Dd][Ii][Ss][Aa][Bb][Ll][Ee][Dd])$"
+
+// valuePattern returns the pattern matching the values of the field, or ""
+// when any string can be valid. Patterns are only returned for integer and
+// bool fields that are converted by strconv.
+func valuePattern(f field) string {
+	t := f.Type
+	if f.Opts.Encoding != "" || f.Opts.Size || f.Opts.Unit != 0 || f.Opts.Enum != nil || f.Opts.Infer ||
+		t == durationType || reflect.PointerTo(t).Implements(textUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(flagValueType) {
+		return ""
+	}
+	if _, ok := registeredDecoder(t); ok {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "^[+-]?[0-9]+$"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "^[+]?[0-9]+$"
+	case reflect.Bool:
+		return boolPattern
+	}
+	return ""
+}
+
+// ValidateSchema checks the document against a JSON Schema as returned by
+// JSONSchema. Like Validate, all violations are returned as a ErrorList of
+// ErrorPolicy, in the order of the lines followed by the missing required
+// variables.
+//
+// Only the parts of the vocabulary used by JSONSchema are checked: the
+// properties and patternProperties that describe the variables, whether
+// additionalProperties are allowed and the required, enum and pattern
+// keywords. An error that is not a ErrorList is returned when the schema can
+// not be parsed.
+func (d *Document) ValidateSchema(schema []byte) error {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	patterns := make([]string, 0, len(s.PatternProperties))
+	for pattern := range s.PatternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	compiled := make(map[string]*regexp.Regexp)
+	compile := func(pattern string) (*regexp.Regexp, error) {
+		re, ok := compiled[pattern]
+		if !ok {
+			var err error
+			if re, err = regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("invalid schema: %w", err)
+			}
+			compiled[pattern] = re
+		}
+		return re, nil
+	}
+	var errs ErrorList
+	seen := make(map[string]bool)
+	for key, e := range d.All() {
+		seen[key] = true
+		prop := s.Properties[key]
+		for i := 0; prop == nil && i < len(patterns); i++ {
+			pattern := patterns[i]
+			re, err := compile(pattern)
+			if err != nil {
+				return err
+			}
+			if re.MatchString(key) {
+				prop = s.PatternProperties[pattern]
+			}
+		}
+		if prop == nil {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, ErrorPolicy{e.Line, key, "is not allowed by the schema"})
+			}
+			continue
+		}
+		if prop.Enum != nil && !slices.Contains(prop.Enum, e.Value) {
+			errs = append(errs, ErrorPolicy{e.Line, key,
+				fmt.Sprintf("has value %q not in %q", e.Value, prop.Enum)})
+		}
+		if prop.Pattern != "" {
+			re, err := compile(prop.Pattern)
+			if err != nil {
+				return err
+			}
+			if !re.MatchString(e.Value) {
+				errs = append(errs, ErrorPolicy{e.Line, key,
+					fmt.Sprintf("has value %q not matching pattern %s", e.Value, re)})
+			}
+		}
+	}
+	for _, key := range s.Required {
+		if !seen[key] {
+			errs = append(errs, ErrorPolicy{0, key, "is required"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var jsonSchemaCases = []struct {
+	Name   string
+	Input  interface{}
+	Output string
+	Error  error
+}{
+	{
+		Name: "required and optional fields",
+		Input: struct {
+			Name    string
+			Setting string `env:"MY_SETTING,omitempty" default:"on"`
+			Ignored int    `env:"-"`
+		}{},
+		Output: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "MY_SETTING": {
+      "type": "string",
+      "default": "on"
+    },
+    "NAME": {
+      "type": "string"
+    }
+  },
+  "required": [
+    "NAME"
+  ],
+  "additionalProperties": false
+}`,
+	},
+	{
+		Name: "enum values",
+		Input: struct {
+			Level int `env:"LEVEL,enum=debug:-4|info:0"`
+		}{},
+		Output: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "LEVEL": {
+      "type": "string",
+      "enum": [
+        "debug",
+        "info"
+      ]
+    }
+  },
+  "required": [
+    "LEVEL"
+  ],
+  "additionalProperties": false
+}`,
+	},
+	{
+		Name: "value patterns",
+		Input: struct {
+			Port    uint16
+			Debug   bool `env:",omitempty"`
+			Timeout time.Duration
+		}{},
+		Output: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "DEBUG": {
+      "type": "string",
+      "pattern": "^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False|[Yy][Ee][Ss]|[Nn][Oo]|[Oo][Nn]|[Oo][Ff][Ff]|[Ee][Nn][Aa][Bb][Ll][Ee][Dd]|[Dd][Ii][Ss][Aa][Bb][Ll][Ee][Dd])$"
+    },
+    "PORT": {
+      "type": "string",
+      "pattern": "^[+]?[0-9]+$"
+    },
+    "TIMEOUT": {
+      "type": "string"
+    }
+  },
+  "required": [
+    "PORT",
+    "TIMEOUT"
+  ],
+  "additionalProperties": false
+}`,
+	},
+	{
+		Name: "unsupported field in struct",
+		Input: struct {
+			Test chan int
+		}{},
+		Error: ErrorUnsupportedType{reflect.Chan},
+	},
+	{
+		Name:  "no struct is passed as input",
+		Input: "blablabla",
+		Error: ErrorUnsupportedType{reflect.String},
+	},
+	{
+		Name:  "nil is passed as input",
+		Input: nil,
+		Error: ErrorUnsupportedType{reflect.Invalid},
+	},
+}
+
+func TestJSONSchema(t *testing.T) {
+	for _, c := range jsonSchemaCases {
+		got, err := JSONSchema(c.Input)
+		if err != c.Error {
+			t.Errorf("[%s] error did not match, want: %v, got %v",
+				c.Name, c.Error, err)
+		}
+		if err != nil {
+			continue
+		}
+		if string(got) != c.Output {
+			t.Errorf("[%s] output did not match\nwant:\n%s\ngot:\n%s",
+				c.Name, c.Output, got)
+		}
+	}
+}
+
+func TestDocumentValidateSchema(t *testing.T) {
+	schema, err := JSONSchema(struct {
+		Host   string
+		Port   int
+		Level  int            `env:",enum=debug:-4|info:0"`
+		Labels map[string]int `env:",omitempty"`
+		Token  string
+	}{})
+	if err != nil {
+		t.Fatalf("schema returned an error: %v", err)
+	}
+	d, err := ParseDocument([]byte("HOST=localhost\nPORT=http\nLEVEL=warn\nLABELS_A=1\nUNKNOWN=1\nPORT=80\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	want := ErrorList{
+		ErrorPolicy{2, "PORT", `has value "http" not matching pattern ^[+-]?[0-9]+$`},
+		ErrorPolicy{3, "LEVEL", `has value "warn" not in ["debug" "info"]`},
+		ErrorPolicy{5, "UNKNOWN", "is not allowed by the schema"},
+		ErrorPolicy{0, "TOKEN", "is required"},
+	}
+	if err := d.ValidateSchema(schema); !reflect.DeepEqual(err, want) {
+		t.Errorf("errors did not match\nwant:\n%v\ngot:\n%v", want, err)
+	}
+	if err := d.ValidateSchema([]byte("{")); err == nil {
+		t.Errorf("invalid schema did not return an error")
+	}
+}
@@ -0,0 +1,112 @@
+package envfile
+
+import (
+	"strings"
+)
+
+// Dialect selects the rules a Decoder uses to parse lines.
+type Dialect int
+
+// Dialects supported by Decoder.SetDialect.
+const (
+	// DialectLenient removes whitespace around names and bare values, so
+	// "KEY = value" assigns "value" to KEY. This is the default.
+	DialectLenient Dialect = iota
+	// DialectStrict only accepts lines matching the grammar described in
+	// the package documentation.
+	DialectStrict
+	// DialectShell only accepts lines that assign the same value when the
+	// file is sourced by bash.
+	DialectShell
+)
+
+// shellSpecial are the characters that bash interprets when they are not
+// quoted. Within double quotes only '$' and '`' are.
+const shellSpecial = "$`;&|<>()~"
+
+// parseLineShell parses a line the way bash does when sourcing the file. Lines
+// that bash would not read as a single assignment of a literal value are
+// rejected, so the result either matches the shell or is an error:
+//
+//   - the name must be a shell identifier, optionally preceded by "export"
+//   - the value is a single word made of bare, single-quoted and
+//     double-quoted parts, optionally followed by blanks and a comment
+//   - outside quotes a backslash escapes the next character, inside double
+//     quotes only '$', '`', '"' and '\'
+//   - expansions, command substitutions, operators, unquoted '~' and
+//     values continued on the next line are rejected
+func parseLineShell(s string) (l *line, ok bool) {
+	if t := strings.TrimSpace(s); t == "" || t[0] == '#' {
+		return nil, true
+	}
+	s, _ = cutExport(strings.TrimLeft(s, " \t"))
+	i := 0
+	for i < len(s) && isNameByte(s[i], i == 0) {
+		i++
+	}
+	l = &line{Key: s[:i]}
+	if i == 0 || i == len(s) || s[i] != '=' {
+		if j := strings.IndexByte(s, '='); j >= 0 {
+			l.Key = strings.TrimSpace(s[:j])
+		}
+		return l, false
+	}
+	var (
+		b        strings.Builder
+		start    = i + 1
+		end      = len(s)
+		quotes   int
+		quoteEnd int
+	)
+	for i = start; i < end; i++ {
+		switch c := s[i]; {
+		case isBlank(c):
+			// The word ends, only a comment can follow.
+			if rest := strings.TrimLeft(s[i:], " \t"); rest != "" && rest[0] != '#' {
+				return l, false
+			}
+			end = i
+		case c == '\\':
+			if i++; i == len(s) {
+				return l, false
+			}
+			b.WriteByte(s[i])
+		case c == '\'':
+			n := strings.IndexByte(s[i+1:], '\'')
+			if n < 0 {
+				return l, false
+			}
+			b.WriteString(s[i+1 : i+1+n])
+			i += n + 1
+			quotes, quoteEnd = quotes+1, i
+		case c == '"':
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				switch s[i] {
+				case '$', '`':
+					return l, false
+				case '\\':
+					if i+1 == len(s) {
+						return l, false
+					}
+					if strings.IndexByte("$`\"\\", s[i+1]) >= 0 {
+						i++
+					}
+				}
+				b.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return l, false
+			}
+			quotes, quoteEnd = quotes+1, i
+		case strings.IndexByte(shellSpecial, c) >= 0:
+			return l, false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quotes == 1 && quoteEnd == end-1 && (s[start] == '\'' || s[start] == '"') {
+		l.Quote = s[start]
+	}
+	l.Value = b.String()
+	return l, true
+}
@@ -0,0 +1,133 @@
+package envfile
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var shellLineTests = []struct {
+	line  string
+	value string
+	ok    bool
+}{
+	{`A=plain`, "plain", true},
+	{`A=`, "", true},
+	{`export A=exported`, "exported", true},
+	{`  A=indented`, "indented", true},
+	{`A=value # comment`, "value", true},
+	{`A=value	# comment`, "value", true},
+	{`A=value#hash`, "value#hash", true},
+	{`A=#hash`, "#hash", true},
+	{`A=a=b`, "a=b", true},
+	{`A=*.go`, "*.go", true},
+	{`A={a,b}`, "{a,b}", true},
+	{`A=a\ b`, "a b", true},
+	{`A=a\\b`, `a\b`, true},
+	{`A=\$HOME`, "$HOME", true},
+	{`A=\"x\'`, `"x'`, true},
+	{`A='single quoted'`, "single quoted", true},
+	{`A='no \escapes $HOME "'`, `no \escapes $HOME "`, true},
+	{`A="double quoted"`, "double quoted", true},
+	{`A="a \$ \" \\ \n \x"`, `a $ " \ \n \x`, true},
+	{`A="it's"`, "it's", true},
+	{`A="a"'b'c`, "abc", true},
+	{`A='a'\''b'`, "a'b", true},
+	{`A=\~`, "~", true},
+	{`A="x;y&z|<>()"`, "x;y&z|<>()", true},
+	{`A=$HOME`, "", false},
+	{`A="$HOME"`, "", false},
+	{`A=${HOME}`, "", false},
+	{`A=$'\n'`, "", false},
+	{"A=`pwd`", "", false},
+	{`A="$(pwd)"`, "", false},
+	{`A=~`, "", false},
+	{`A=x:~/bin`, "", false},
+	{`A=a b`, "", false},
+	{`A= b`, "", false},
+	{`A=a;B=b`, "", false},
+	{`A=a && true`, "", false},
+	{`A=a|cat`, "", false},
+	{`A=a>file`, "", false},
+	{`A=(a b)`, "", false},
+	{`A=1 B=2`, "", false},
+	{`A='unterminated`, "", false},
+	{`A="unterminated`, "", false},
+	{`A="continued\`, "", false},
+	{`A=continued\`, "", false},
+	{`A = spaced`, "", false},
+	{`A.B=dotted`, "", false},
+	{`1A=digit`, "", false},
+	{`A`, "", false},
+}
+
+func TestParseLineShell(t *testing.T) {
+	for _, tt := range shellLineTests {
+		l, ok := parseLineShell(tt.line)
+		if ok != tt.ok {
+			t.Errorf("[%s] ok did not match, want: %v, got %v", tt.line, tt.ok, ok)
+			continue
+		}
+		if ok && l.Value != tt.value {
+			t.Errorf("[%s] value did not match, want: %q, got %q", tt.line, tt.value, l.Value)
+		}
+	}
+}
+
+// TestParseLineShellBash compares the accepted lines with the values bash
+// assigns when sourcing them.
+func TestParseLineShellBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	dir := t.TempDir()
+	for i, tt := range shellLineTests {
+		if !tt.ok {
+			continue
+		}
+		name := filepath.Join(dir, fmt.Sprintf("%d.env", i))
+		if err := os.WriteFile(name, []byte(tt.line+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cmd := exec.Command(bash, "--norc", "--noprofile", "-c", `unset A; . "$1" && printf %s "$A"`, "bash", name)
+		cmd.Dir = dir
+		cmd.Env = []string{"HOME=/home/test", "PATH=" + os.Getenv("PATH")}
+		out, err := cmd.Output()
+		if err != nil {
+			t.Errorf("[%s] bash returned an error: %v", tt.line, err)
+			continue
+		}
+		if string(out) != tt.value {
+			t.Errorf("[%s] value did not match bash, want: %q, got %q", tt.line, out, tt.value)
+		}
+	}
+}
+
+func TestDecoderDialectShell(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST"`
+		Path string `env:"PATH_PREFIX"`
+	}
+	input := "export HOST='db'.example\nPATH_PREFIX=\\$HOME/bin # not expanded\n"
+	var got config
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetDialect(DialectShell)
+	dec.SetExpand(true)
+	if err := dec.Decode(&got); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	want := config{Host: "db.example", Path: "$HOME/bin"}
+	if got != want {
+		t.Errorf("result did not match, want: %+v, got %+v", want, got)
+	}
+
+	dec = NewDecoder(strings.NewReader("HOST=db\nPATH_PREFIX=$HOME/bin\n"))
+	dec.SetDialect(DialectShell)
+	if err, want := dec.Decode(&got), (ErrorLineParsing{2}); err != want {
+		t.Errorf("error did not match, want: %v, got %v", want, err)
+	}
+}
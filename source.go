@@ -0,0 +1,120 @@
+package envfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// A Source supplies EnvironmentFile data, for example from a file or a
+// remote configuration service.
+type Source interface {
+	// Name identifies the source in errors and provenance reports.
+	Name() string
+	// Read returns the EnvironmentFile data of the source.
+	Read(ctx context.Context) ([]byte, error)
+}
+
+// UnmarshalSource reads the data of src and decodes it into the value pointed
+// to by v, like Unmarshal. Errors are returned as a ErrorFile with the name
+// of the source as path.
+func UnmarshalSource(ctx context.Context, src Source, v interface{}) error {
+	data, err := src.Read(ctx)
+	if err != nil {
+		return ErrorFile{src.Name(), err}
+	}
+	if err := NewDecoder(bytes.NewReader(data)).DecodeContext(ctx, v); err != nil {
+		return ErrorFile{src.Name(), err}
+	}
+	return nil
+}
+
+// ErrorHTTPStatus is returned by HTTPSource when the server does not respond
+// with a 2xx status code.
+type ErrorHTTPStatus struct {
+	StatusCode int
+	Status     string
+}
+
+// Error implements the error interface.
+func (e ErrorHTTPStatus) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %s", e.Status)
+}
+
+// DefaultHTTPMaxSize is the size limit of the data read by HTTPSource when
+// its MaxSize is zero.
+const DefaultHTTPMaxSize = 1 << 20
+
+// HTTPSource is a Source that fetches the EnvironmentFile data with a GET
+// request, for example from an internal configuration service.
+type HTTPSource struct {
+	// URL is the HTTP or HTTPS URL of the data.
+	URL string
+	// Header holds additional request headers, such as Authorization.
+	Header http.Header
+	// Client is used to send the request, http.DefaultClient when nil.
+	Client *http.Client
+	// Timeout limits the duration of the request when not zero.
+	Timeout time.Duration
+	// MaxSize limits the size of the response body in bytes,
+	// DefaultHTTPMaxSize when zero.
+	MaxSize int64
+}
+
+// Name returns the URL of the source, with the password of its user
+// information redacted.
+func (s *HTTPSource) Name() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return s.URL
+	}
+	return u.Redacted()
+}
+
+// Read fetches the data. A ErrorHTTPStatus is returned when the response
+// does not have a 2xx status code, and an error when the response body is
+// larger than MaxSize.
+func (s *HTTPSource) Read(ctx context.Context) ([]byte, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, ErrorHTTPStatus{resp.StatusCode, resp.Status}
+	}
+	max := s.MaxSize
+	if max <= 0 {
+		max = DefaultHTTPMaxSize
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("response body is larger than %d bytes", max)
+	}
+	return data, nil
+}
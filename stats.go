@@ -0,0 +1,73 @@
+package envfile
+
+import (
+	"bytes"
+)
+
+// Statistics summarizes EnvironmentFile data, see Stats.
+type Statistics struct {
+	// Lines is the number of lines, of which Assignments set a variable,
+	// Comments are comment lines, Blank are empty or only whitespace and
+	// Invalid can not be parsed.
+	Lines       int
+	Assignments int
+	Comments    int
+	Blank       int
+	Invalid     int
+	// Keys is the number of different variables.
+	Keys int
+	// Duplicates are the variables that are set more than once, in the
+	// order they are first set.
+	Duplicates []string
+	// LongestLine is the length in bytes of the longest line, without line
+	// ending, and LongestLineNumber its 1-based line number.
+	LongestLine       int
+	LongestLineNumber int
+	// ValueBytes is the total length in bytes of the values of all
+	// assignments, after removing their quotes.
+	ValueBytes int64
+}
+
+// Stats returns statistics about EnvironmentFile data, for example to check
+// generated files before they are shipped. Lines that can not be parsed are
+// counted as Invalid. An error is only returned when data can not be read as
+// text, see ErrorBinaryInput.
+func Stats(data []byte) (Statistics, error) {
+	var s Statistics
+	counts := make(map[string]int)
+	var keys []string
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		text := lr.Text()
+		s.Lines++
+		if len(text) > s.LongestLine {
+			s.LongestLine, s.LongestLineNumber = len(text), lr.Line()
+		}
+		l, ok := parseLine(text)
+		switch {
+		case !ok:
+			s.Invalid++
+		case l != nil:
+			s.Assignments++
+			s.ValueBytes += int64(len(l.Value))
+			if counts[l.Key] == 0 {
+				keys = append(keys, l.Key)
+			}
+			counts[l.Key]++
+		case isCommentLine(text):
+			s.Comments++
+		default:
+			s.Blank++
+		}
+	}
+	if err := lr.Err(); err != nil {
+		return Statistics{}, err
+	}
+	s.Keys = len(keys)
+	for _, key := range keys {
+		if counts[key] > 1 {
+			s.Duplicates = append(s.Duplicates, key)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,32 @@
+package envfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	input := "# Config.\n\nHOST=localhost\nPORT='80'\nINVALID\nHOST=db\n   \nPORT=8080\nNAME=\"a longer value\"\n"
+	got, err := Stats([]byte(input))
+	if err != nil {
+		t.Fatalf("stats returned an error: %v", err)
+	}
+	want := Statistics{
+		Lines:             9,
+		Assignments:       5,
+		Comments:          1,
+		Blank:             2,
+		Invalid:           1,
+		Keys:              3,
+		Duplicates:        []string{"HOST", "PORT"},
+		LongestLine:       21,
+		LongestLineNumber: 9,
+		ValueBytes:        9 + 2 + 2 + 4 + 14,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statistics did not match\nwant:\n%+v\ngot:\n%+v", want, got)
+	}
+	if _, err := Stats([]byte("A=1\x00")); err != (ErrorBinaryInput{3, 1}) {
+		t.Errorf("error did not match, want: %v, got %v", ErrorBinaryInput{3, 1}, err)
+	}
+}
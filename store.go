@@ -0,0 +1,86 @@
+package envfile
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/basvdlei/envfile/internal/filestate"
+)
+
+// A Store holds the current configuration of type T, a struct type, and
+// allows it to be replaced while it is in use by other goroutines. Readers
+// get a consistent configuration from Load without locking, updates are
+// swapped in atomically.
+//
+// Use HandlerFunc with Load to expose the current configuration of a Store.
+type Store[T any] struct {
+	p atomic.Pointer[T]
+}
+
+// NewStore returns a Store holding v.
+func NewStore[T any](v T) *Store[T] {
+	s := &Store[T]{}
+	s.p.Store(&v)
+	return s
+}
+
+// Load returns the current configuration. The zero value of T is returned
+// when nothing was stored yet.
+func (s *Store[T]) Load() T {
+	if p := s.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store replaces the current configuration by v.
+func (s *Store[T]) Store(v T) {
+	s.p.Store(&v)
+}
+
+// Reload decodes the files into a new configuration with UnmarshalLayers and
+// replaces the current configuration with it. The current configuration is
+// kept when reading, decoding or validating fails.
+func (s *Store[T]) Reload(files ...string) error {
+	var v T
+	if _, err := UnmarshalLayers(&v, files...); err != nil {
+		return err
+	}
+	s.Store(v)
+	return nil
+}
+
+// DefaultWatchInterval is the interval used by Store.Watch when the given
+// interval is not positive.
+const DefaultWatchInterval = time.Second
+
+// Watch checks the files for changes every interval, or DefaultWatchInterval
+// when interval is not positive, and calls Reload when the modification time
+// or size of any of them changed, until ctx is done. Errors of Reload are
+// passed to onError, when not nil, and the previous configuration stays in
+// use. Watch blocks and returns the context error.
+func (s *Store[T]) Watch(ctx context.Context, interval time.Duration, onError func(error), files ...string) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	last := filestate.Of(files)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		current := filestate.Of(files)
+		if current == last {
+			continue
+		}
+		last = current
+		if err := s.Reload(files...); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
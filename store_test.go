@@ -0,0 +1,91 @@
+package envfile
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+type storeConfig struct {
+	Host string
+	Port string
+}
+
+func (c storeConfig) Validate() error {
+	if c.Port == "invalid" {
+		return ErrorMissingKey{"PORT"}
+	}
+	return nil
+}
+
+func TestStoreReload(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=a\nPORT=80\n")
+	s := NewStore(storeConfig{Host: "initial"})
+	if got := s.Load().Host; got != "initial" {
+		t.Errorf("initial value did not match, got %q", got)
+	}
+	if err := s.Reload(paths...); err != nil {
+		t.Fatalf("reload returned an error: %v", err)
+	}
+	if want, got := (storeConfig{"a", "80"}), s.Load(); want != got {
+		t.Errorf("reloaded value did not match, want %+v, got %+v", want, got)
+	}
+	if err := os.WriteFile(paths[0], []byte("HOST=b\nPORT=invalid\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(paths...); err == nil {
+		t.Errorf("reload of an invalid configuration did not return an error")
+	}
+	if want, got := (storeConfig{"a", "80"}), s.Load(); want != got {
+		t.Errorf("invalid configuration was stored, want %+v, got %+v", want, got)
+	}
+}
+
+func TestStoreWatch(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=a\n")
+	var s Store[storeConfig]
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ctx, 5*time.Millisecond, nil, paths...)
+	}()
+	if err := os.WriteFile(paths[0], []byte("HOST=changed\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Keep changing the modification time, as the watcher might take its
+	// initial snapshot after the write and file systems can have a coarse
+	// time resolution.
+	later := time.Now()
+	deadline := later.Add(5 * time.Second)
+	for s.Load().Host != "changed" && time.Now().Before(deadline) {
+		later = later.Add(time.Second)
+		if err := os.Chtimes(paths[0], later, later); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("watch error did not match, want: %v, got %v", context.Canceled, err)
+	}
+	if got := s.Load().Host; got != "changed" {
+		t.Errorf("watch did not reload the configuration, got %q", got)
+	}
+}
+
+func TestStoreWatchInterval(t *testing.T) {
+	paths := writeTempFiles(t, "HOST=a\n")
+	var s Store[storeConfig]
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- s.Watch(ctx, interval, nil, paths...)
+		}()
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("[%v] watch error did not match, want: %v, got %v", interval, context.Canceled, err)
+		}
+	}
+}
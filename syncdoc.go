@@ -0,0 +1,76 @@
+package envfile
+
+import "sync"
+
+// A SyncDocument is a Document that is safe for concurrent use. Readers see
+// the document either before or after an update, never in between, so a
+// service can serve its configuration while another goroutine updates it.
+type SyncDocument struct {
+	// update serializes the calls to Update.
+	update sync.Mutex
+	mu     sync.RWMutex
+	doc    *Document
+}
+
+// NewSyncDocument returns a SyncDocument holding a copy of d. An empty
+// document is used when d is nil.
+func NewSyncDocument(d *Document) *SyncDocument {
+	if d == nil {
+		d = &Document{newline: "\n"}
+	}
+	return &SyncDocument{doc: d.clone()}
+}
+
+// Get returns the value of the variable key and whether it is set.
+func (s *SyncDocument) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc.Get(key)
+}
+
+// Keys returns the names of the variables in the order they first appear.
+func (s *SyncDocument) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc.Keys()
+}
+
+// Bytes returns the EnvironmentFile encoding of the document. The returned
+// slice is a copy and is not affected by later updates.
+func (s *SyncDocument) Bytes() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc.Bytes()
+}
+
+// Snapshot returns a copy of the document that can be used and modified
+// without affecting the SyncDocument.
+func (s *SyncDocument) Snapshot() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc.clone()
+}
+
+// Update calls fn with a copy of the document and replaces the document by
+// the copy when fn returns nil. Updates are serialized and readers are not
+// blocked while fn runs. The document passed to fn must not be used after fn
+// returns.
+func (s *SyncDocument) Update(fn func(d *Document) error) error {
+	s.update.Lock()
+	defer s.update.Unlock()
+	d := s.Snapshot()
+	if err := fn(d); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.doc = d
+	s.mu.Unlock()
+	return nil
+}
+
+// Apply applies the patch to the document, like Document.Apply.
+func (s *SyncDocument) Apply(p Patch) error {
+	return s.Update(func(d *Document) error {
+		return d.Apply(p)
+	})
+}
@@ -0,0 +1,68 @@
+package envfile
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSyncDocument(t *testing.T) {
+	d, err := ParseDocument([]byte("# Counter.\nCOUNT=0\n"))
+	if err != nil {
+		t.Fatalf("parse document returned an error: %v", err)
+	}
+	s := NewSyncDocument(d)
+	d.Set("COUNT", "changed")
+	if v, _ := s.Get("COUNT"); v != "0" {
+		t.Errorf("document was not copied, got %q", v)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			err := s.Update(func(d *Document) error {
+				v, _ := d.Get("COUNT")
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return err
+				}
+				d.Set("COUNT", strconv.Itoa(n+1))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("update returned an error: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := ParseDocument(s.Bytes()); err != nil {
+				t.Errorf("read inconsistent document: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if want, got := "# Counter.\nCOUNT=10\n", string(s.Bytes()); got != want {
+		t.Errorf("document did not match\nwant:\n%q,\tgot\n%q", want, got)
+	}
+
+	errFailed := errors.New("failed")
+	err = s.Update(func(d *Document) error {
+		d.Set("COUNT", "lost")
+		return errFailed
+	})
+	if err != errFailed {
+		t.Errorf("error did not match, want: %v, got %v", errFailed, err)
+	}
+	if v, _ := s.Get("COUNT"); v != "10" {
+		t.Errorf("failed update modified the document, got %q", v)
+	}
+	if err := s.Apply(Patch{{Op: PatchUnset, Key: "COUNT"}}); err != nil {
+		t.Errorf("apply returned an error: %v", err)
+	}
+	if keys := s.Keys(); len(keys) != 0 {
+		t.Errorf("keys did not match, want none, got %v", keys)
+	}
+}
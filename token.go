@@ -0,0 +1,102 @@
+package envfile
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+// Token kinds.
+const (
+	// TokenAssignment is a variable assignment, Key and Value are set.
+	TokenAssignment TokenKind = iota
+	// TokenComment is a comment line, Text holds the comment without the
+	// leading '#' or other comment character set with SetCommentChars.
+	TokenComment
+	// TokenBlank is an empty line or a line with only whitespace.
+	TokenBlank
+	// TokenError is a line that can not be parsed, Text holds the line and
+	// Err the ErrorLineParsing.
+	TokenError
+)
+
+// String returns the name of the token kind.
+func (k TokenKind) String() string {
+	switch k {
+	case TokenAssignment:
+		return "assignment"
+	case TokenComment:
+		return "comment"
+	case TokenBlank:
+		return "blank"
+	case TokenError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// A Token is a single line of EnvironmentFile data as returned by
+// Decoder.Token.
+type Token struct {
+	Kind TokenKind
+	// Line is the 1-based line number.
+	Line int
+	// Key and Value are the variable name and the unquoted value of an
+	// assignment, Quote is the quote character the value was enclosed in,
+	// or 0 for bare values.
+	Key   string
+	Value string
+	Quote byte
+	// Export is set when the assignment has the "export" keyword.
+	Export bool
+	// Text is the comment text or the line that could not be parsed.
+	Text string
+	// Err is the parsing error of a TokenError.
+	Err error
+}
+
+// Token returns the next line of the input as a Token. At the end of the
+// input it returns io.EOF. Lines that can not be parsed are returned as a
+// TokenError instead of an error, so processing can continue after them.
+//
+// Token reads the lines as they are and ignores the prefix and expansion
+// settings of the Decoder. With SetStrict, lines that do not match the strict
+// grammar are returned as a TokenError. Decode continues with the line after
+// the last returned Token.
+func (dec *Decoder) Token() (Token, error) {
+	lr := dec.lineReader()
+	if !lr.Next() {
+		if err := lr.Err(); err != nil {
+			return Token{}, err
+		}
+		return Token{}, io.EOF
+	}
+	tok := Token{Line: lr.Line()}
+	s := strings.TrimSpace(lr.Text())
+	switch {
+	case s == "":
+		tok.Kind = TokenBlank
+		return tok, nil
+	case dec.isComment(s):
+		_, size := utf8.DecodeRuneInString(s)
+		tok.Kind = TokenComment
+		tok.Text = s[size:]
+		return tok, nil
+	}
+	_, tok.Export = cutExport(s)
+	// Parse the line as it is, like Decode does, as the strict dialect
+	// rejects surrounding whitespace.
+	l, ok := dec.parseLine(lr.Text())
+	if !ok {
+		tok.Kind = TokenError
+		tok.Text = lr.Text()
+		tok.Err = ErrorLineParsing{tok.Line}
+		return tok, nil
+	}
+	tok.Kind = TokenAssignment
+	tok.Key, tok.Value, tok.Quote = l.Key, l.Value, l.Quote
+	return tok, nil
+}
@@ -0,0 +1,77 @@
+package envfile
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoderToken(t *testing.T) {
+	input := "# Settings\nexport NAME=app\n\nMESSAGE='hi there'\nINVALID\n"
+	dec := NewDecoder(strings.NewReader(input))
+	want := []Token{
+		{Kind: TokenComment, Line: 1, Text: " Settings"},
+		{Kind: TokenAssignment, Line: 2, Key: "NAME", Value: "app", Export: true},
+		{Kind: TokenBlank, Line: 3},
+		{Kind: TokenAssignment, Line: 4, Key: "MESSAGE", Value: "hi there", Quote: '\''},
+		{Kind: TokenError, Line: 5, Text: "INVALID", Err: ErrorLineParsing{5}},
+	}
+	for _, w := range want {
+		got, err := dec.Token()
+		if err != nil {
+			t.Fatalf("token returned an error: %v", err)
+		}
+		if !reflect.DeepEqual(w, got) {
+			t.Errorf("token did not match\nwant:\n%+v,\tgot\n%+v", w, got)
+		}
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		t.Errorf("error at end of input did not match, want: %v, got %v", io.EOF, err)
+	}
+}
+
+func TestDecoderTokenThenDecode(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("SKIPPED=x\nNAME=app\n"))
+	if _, err := dec.Token(); err != nil {
+		t.Fatalf("token returned an error: %v", err)
+	}
+	var v struct {
+		Name string
+	}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode returned an error: %v", err)
+	}
+	if v.Name != "app" {
+		t.Errorf("value did not match, got %q", v.Name)
+	}
+}
+
+func TestDecoderTokenStrict(t *testing.T) {
+	// Token accepts the same assignments as Decode.
+	for _, input := range []string{
+		"NAME=app\n",
+		" NAME=app \n",
+		"\tNAME=app\n",
+		"NAME = app\n",
+		"\fNAME=app\n",
+		"NAME=app\v\n",
+		"\u00a0NAME=app\n",
+	} {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.SetStrict(true)
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("[%q] token returned an error: %v", input, err)
+		}
+		dec = NewDecoder(strings.NewReader(input))
+		dec.SetStrict(true)
+		var v struct {
+			Name string
+		}
+		decodeErr := dec.Decode(&v)
+		if (tok.Kind == TokenError) != (decodeErr != nil) {
+			t.Errorf("[%q] results did not match, token: %+v, decode: %v", input, tok, decodeErr)
+		}
+	}
+}
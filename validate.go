@@ -0,0 +1,91 @@
+package envfile
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// ValidateAgainst checks the EnvironmentFile encoded data against the fields
+// of v without storing any values. The value v can be a struct or a pointer
+// to a struct and is never modified, so a zero value of the configuration
+// type can be used as a prototype.
+//
+// Instead of stopping at the first problem all violations are collected and
+// returned as a ErrorList. Reported are lines that can not be parsed
+// (ErrorLineParsing), variables that do not map to a field (ErrorUnknownKey),
+// fields without the "omitempty" option that are not set (ErrorMissingKey)
+// and values that can not be stored in their field. Map fields are never
+// required.
+func ValidateAgainst(data []byte, v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return ErrorUnsupportedType{rv.Kind()}
+	}
+	si := cachedStruct(rv.Type())
+	if si.err != nil {
+		return si.err
+	}
+	var errs ErrorList
+	all := si.fields
+	var required []string
+	for _, f := range all {
+		if !f.Opts.OmitEmpty && !f.Map {
+			required = append(required, f.Name)
+		}
+	}
+
+	seen := make(map[string]bool)
+	lr := newLineReader(bytes.NewReader(data))
+	for lr.Next() {
+		count := lr.Line()
+		l, ok := parseLine(lr.Text())
+		if l == nil {
+			continue
+		}
+		if !ok {
+			errs = append(errs, ErrorLineParsing{count})
+			continue
+		}
+		key := l.Key
+		var matches []field
+		for _, f := range all {
+			if _, ok := f.match(key); ok {
+				matches = append(matches, f)
+			}
+		}
+		if len(matches) == 0 {
+			errs = append(errs, ErrorUnknownKey{count, key})
+			continue
+		}
+		seen[key] = true
+		for _, f := range matches {
+			// Empty values are not stored in "omitempty" fields, like
+			// in si.assign.
+			if f.Opts.OmitEmpty && l.Value == "" {
+				continue
+			}
+			typ := f.Type
+			if f.Map {
+				typ = typ.Elem()
+			}
+			// Store the value in a scratch value of the field type to
+			// find out if it can be decoded.
+			scratch := reflect.New(typ).Elem()
+			if err := setValue(scratch, f.Opts, key, l.Value, false); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := lr.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, key := range required {
+		if !seen[key] {
+			errs = append(errs, ErrorMissingKey{key})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package envfile
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type validateConfig struct {
+	Host    string
+	Port    string `env:"PORT,omitempty"`
+	Count   int    `env:"COUNT,omitempty"`
+	Ignored string `env:"-"`
+}
+
+var validateCases = []struct {
+	Name  string
+	Input []byte
+	Error error
+}{
+	{
+		Name:  "valid data",
+		Input: []byte("HOST=localhost\nPORT=80\n"),
+	},
+	{
+		Name:  "only required variables",
+		Input: []byte("# comment\nHOST=localhost\n"),
+	},
+	{
+		Name:  "missing required variable",
+		Input: []byte("PORT=80\n"),
+		Error: ErrorList{ErrorMissingKey{"HOST"}},
+	},
+	{
+		Name: "all violations are reported",
+		Input: []byte(`HOST=localhost
+UNKNOWN=value
+INVALID
+COUNT=many
+IGNORED=value
+`),
+		Error: ErrorList{
+			ErrorUnknownKey{2, "UNKNOWN"},
+			ErrorLineParsing{3},
+			ErrorValueParsing{"COUNT", &strconv.NumError{Func: "ParseInt", Num: "many", Err: strconv.ErrSyntax}},
+			ErrorUnknownKey{5, "IGNORED"},
+		},
+	},
+}
+
+func TestValidateAgainst(t *testing.T) {
+	for _, c := range validateCases {
+		proto := validateConfig{Host: "unchanged"}
+		err := ValidateAgainst(c.Input, &proto)
+		if !reflect.DeepEqual(err, c.Error) {
+			t.Errorf("[%s] error did not match, want: %v, got %v",
+				c.Name, c.Error, err)
+		}
+		if proto.Host != "unchanged" {
+			t.Errorf("[%s] prototype was modified: %+v", c.Name, proto)
+		}
+	}
+}
+
+func TestValidateAgainstUnmarshal(t *testing.T) {
+	// Data that Unmarshal accepts is valid and the other way around.
+	for _, input := range []string{
+		"HOST=localhost\nCOUNT=\n",
+		"HOST=localhost\nCOUNT=3\nPORT=\n",
+		"HOST=localhost\nCOUNT=many\n",
+		"HOST=localhost\nCOUNT= \n",
+	} {
+		var got validateConfig
+		unmarshalErr := Unmarshal([]byte(input), &got)
+		validateErr := ValidateAgainst([]byte(input), validateConfig{})
+		if (unmarshalErr == nil) != (validateErr == nil) {
+			t.Errorf("[%q] results did not match, unmarshal: %v, validate: %v", input, unmarshalErr, validateErr)
+		}
+	}
+}
+
+func TestValidateAgainstNoStruct(t *testing.T) {
+	err := ValidateAgainst([]byte("TEST=123"), "blablabla")
+	if err != (ErrorUnsupportedType{reflect.String}) {
+		t.Errorf("validate against string did not return an error, got %v", err)
+	}
+}
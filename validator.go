@@ -0,0 +1,81 @@
+package envfile
+
+import "reflect"
+
+// Validator is implemented by types that can check their own values. The
+// decoding functions call Validate on the target and on all nested structs
+// after the values have been stored, so validation rules can live with the
+// type.
+type Validator interface {
+	Validate() error
+}
+
+// validate calls the Validate method of the value pointed to by v and of all
+// its nested structs. The errors are returned as a ErrorList.
+func validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil
+	}
+	visited := make(map[visit]bool)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		visited[visit{rv.Pointer(), rv.Type()}] = true
+		rv = rv.Elem()
+	}
+	var errs ErrorList
+	validateValue(rv, &errs, visited)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// visit identifies a pointer that validateValue followed. The type is part
+// of it, as a pointer to a struct and to its first field are equal.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// validateValue validates the nested structs of v before v itself and adds
+// the errors to errs. Pointers in visited are not followed again, so values
+// that point back to themselves are validated once.
+func validateValue(v reflect.Value, errs *ErrorList, visited map[visit]bool) {
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if _, opts := parseFieldOpts(sf); opts.Skip {
+				continue
+			}
+			switch fv := v.Field(i); {
+			case nestedStruct(sf.Type):
+				validateValue(fv, errs, visited)
+			case nestedPointer(sf.Type) && !fv.IsNil():
+				key := visit{fv.Pointer(), fv.Type()}
+				if visited[key] {
+					continue
+				}
+				visited[key] = true
+				validateValue(fv.Elem(), errs, visited)
+			}
+		}
+	}
+	if !v.CanInterface() {
+		return
+	}
+	val, ok := v.Interface().(Validator)
+	if !ok && v.CanAddr() {
+		val, ok = v.Addr().Interface().(Validator)
+	}
+	if !ok {
+		return
+	}
+	if err := val.Validate(); err != nil {
+		if list, ok := err.(ErrorList); ok {
+			*errs = append(*errs, list...)
+		} else {
+			*errs = append(*errs, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package envfile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errNoHost = errors.New("host is required")
+var errNoPort = errors.New("port is required")
+
+type validatedDatabase struct {
+	Host string
+}
+
+func (d validatedDatabase) Validate() error {
+	if d.Host == "" {
+		return errNoHost
+	}
+	return nil
+}
+
+type validatedConfig struct {
+	Port     string
+	Database validatedDatabase `env:"DB"`
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Port == "" {
+		return errNoPort
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	var got validatedConfig
+	if err := Unmarshal([]byte("PORT=80\nDB_HOST=localhost\n"), &got); err != nil {
+		t.Errorf("unmarshal of valid data returned an error: %v", err)
+	}
+
+	got = validatedConfig{}
+	err := Unmarshal([]byte("# empty\n"), &got)
+	want := ErrorList{errNoHost, errNoPort}
+	if !reflect.DeepEqual(want, err) {
+		t.Errorf("error did not match, want: %v, got %v", want, err)
+	}
+	if !errors.Is(err, errNoPort) {
+		t.Errorf("validation error is not wrapped by %v", err)
+	}
+}
+
+type cyclicConfig struct {
+	Name  string
+	Next  *cyclicConfig
+	calls *int
+}
+
+func (c *cyclicConfig) Validate() error {
+	*c.calls++
+	return nil
+}
+
+func TestValidatorCycle(t *testing.T) {
+	var calls int
+	first := &cyclicConfig{Name: "first", calls: &calls}
+	second := &cyclicConfig{Name: "second", Next: first, calls: &calls}
+	first.Next = second
+	if err := validate(first); err != nil {
+		t.Errorf("validation returned an error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("validate calls did not match, want: 2, got %d", calls)
+	}
+}
@@ -0,0 +1,469 @@
+package envfile
+
+import (
+	"encoding"
+	"flag"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	locationType        = reflect.TypeOf((*time.Location)(nil))
+)
+
+// sliceSeparator separates the elements of slice values.
+const sliceSeparator = ","
+
+// setField stores the variable value in the struct field. The conversion is
+// done by the first of the following that applies to the field type:
+//
+//  1. a decoder registered with RegisterDecoder
+//  2. the encoding.TextUnmarshaler implementation of the field, or else the
+//     Set method of its flag.Value implementation. Pointer fields that
+//     implement encoding.TextUnmarshaler are allocated, or set to nil for an
+//     empty value
+//  3. a time.Duration field, parsed by time.ParseDuration, or a
+//     *time.Location field, loaded by time.LoadLocation
+//  4. a string, bool, integer or floating point field, parsed by strconv
+//     except for bools outside strict mode, see parseBool
+//  5. a slice field, whose comma-separated elements are converted in the
+//     same way after leading and trailing whitespace is removed
+//  6. an empty interface field, which holds the value as a string
+//
+// Conversion errors are returned as a ErrorValueParsing for the variable key.
+func setField(field reflect.Value, key, value string, strict bool) error {
+	if fn, ok := registeredDecoder(field.Type()); ok {
+		out := fn.Call([]reflect.Value{reflect.ValueOf(value)})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return ErrorValueParsing{key, err}
+		}
+		field.Set(out[0])
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		if err != nil {
+			return ErrorValueParsing{key, err}
+		}
+		return nil
+	}
+	if field.Kind() == reflect.Ptr && field.Type().Implements(textUnmarshalerType) {
+		return setTextPointer(field, key, value)
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(flagValueType) {
+		if err := field.Addr().Interface().(flag.Value).Set(value); err != nil {
+			return ErrorValueParsing{key, err}
+		}
+		return nil
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return ErrorValueParsing{key, err}
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	if field.Type() == locationType {
+		return setLocation(field, key, value)
+	}
+	var err error
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		var b bool
+		b, err = parseBool(value, strict)
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(value, 10, field.Type().Bits())
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(value, 10, field.Type().Bits())
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(value, field.Type().Bits())
+		field.SetFloat(f)
+	case reflect.Slice:
+		if !sliceType(field.Type()) {
+			return ErrorUnsupportedType{field.Kind()}
+		}
+		return setSlice(field, key, value, strict)
+	case reflect.Interface:
+		if !anyType(field.Type()) {
+			return ErrorUnsupportedType{field.Kind()}
+		}
+		field.Set(reflect.ValueOf(value))
+	default:
+		return ErrorUnsupportedType{field.Kind()}
+	}
+	if err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	return nil
+}
+
+// parseBool returns the boolean value of s. In strict mode only the values
+// accepted by strconv.ParseBool are allowed, otherwise yes, no, on, off,
+// enabled and disabled are accepted as well, in any case.
+func parseBool(s string, strict bool) (bool, error) {
+	if !strict {
+		switch strings.ToLower(s) {
+		case "yes", "on", "enabled":
+			return true, nil
+		case "no", "off", "disabled":
+			return false, nil
+		}
+	}
+	return strconv.ParseBool(s)
+}
+
+// setTextPointer stores value in the pointer field whose type implements
+// encoding.TextUnmarshaler, in a newly allocated value. An empty value
+// results in a nil pointer.
+func setTextPointer(field reflect.Value, key, value string) error {
+	if value == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	p := reflect.New(field.Type().Elem())
+	if err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	field.Set(p)
+	return nil
+}
+
+// setLocation stores the time zone named by value in the *time.Location
+// field. An empty value results in a nil location.
+func setLocation(field reflect.Value, key, value string) error {
+	if value == "" {
+		field.Set(reflect.Zero(locationType))
+		return nil
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	field.Set(reflect.ValueOf(loc))
+	return nil
+}
+
+// setSlice stores the comma-separated elements of value in the slice field.
+// An empty value results in a nil slice.
+func setSlice(field reflect.Value, key, value string, strict bool) error {
+	if value == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	parts := strings.Split(value, sliceSeparator)
+	s := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setField(s.Index(i), key, strings.TrimSpace(part), strict); err != nil {
+			return err
+		}
+	}
+	field.Set(s)
+	return nil
+}
+
+// setMapEntry stores the variable value in map m under mapKey, allocating the
+// map when it is nil. The value is converted like a field of the map element
+// type with the options of the map field. For maps with slice values the
+// elements are appended to the existing entry.
+func setMapEntry(m reflect.Value, opts envOptions, key, mapKey, value string, strict bool) error {
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := setValue(elem, opts, key, value, strict); err != nil {
+		return err
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	k, err := mapKeyValue(m.Type().Key(), mapKey)
+	if err != nil {
+		return ErrorValueParsing{key, err}
+	}
+	if prev := m.MapIndex(k); prev.IsValid() && multiMap(m.Type()) {
+		elem = reflect.AppendSlice(prev, elem)
+	}
+	m.SetMapIndex(k, elem)
+	return nil
+}
+
+// formatField returns the variable value of the struct field. The conversion
+// is done by the first of the following that applies to the field type:
+//
+//  1. an encoder registered with RegisterEncoder
+//  2. the encoding.TextMarshaler implementation of the field, or else the
+//     String method of its flag.Value implementation. Nil pointer fields are
+//     written as an empty value
+//  3. a time.Duration field, formatted by its String method, or a
+//     *time.Location field, formatted as the name of the time zone
+//  4. a string, bool, integer or floating point field, formatted by strconv
+//  5. a slice field, whose elements are formatted in the same way and
+//     joined by commas. Elements containing a comma are rejected with a
+//     ErrorSliceSeparator
+//  6. an empty interface field, whose dynamic value is formatted in the same
+//     way, or written as an empty value when it is nil
+func formatField(field reflect.Value) (string, error) {
+	if fn, ok := registeredEncoder(field.Type()); ok {
+		out := fn.Call([]reflect.Value{field})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return "", err
+		}
+		return out[0].String(), nil
+	}
+	if field.Type().Implements(textMarshalerType) {
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			return "", nil
+		}
+		b, err := field.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textMarshalerType) {
+		b, err := field.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if reflect.PtrTo(field.Type()).Implements(flagValueType) {
+		if !field.CanAddr() {
+			// Copy the value so String can be called on its address.
+			v := reflect.New(field.Type())
+			v.Elem().Set(field)
+			field = v.Elem()
+		}
+		return field.Addr().Interface().(flag.Value).String(), nil
+	}
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String(), nil
+	}
+	if field.Type() == locationType {
+		if field.IsNil() {
+			return "", nil
+		}
+		return field.Interface().(*time.Location).String(), nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'g', -1, field.Type().Bits()), nil
+	case reflect.Interface:
+		if !anyType(field.Type()) {
+			return "", ErrorUnsupportedType{field.Kind()}
+		}
+		if field.IsNil() {
+			return "", nil
+		}
+		elem := field.Elem()
+		if !supportedType(elem.Type()) || elem.Kind() == reflect.Interface {
+			return "", ErrorUnsupportedType{elem.Kind()}
+		}
+		// Copy the value so it is addressable.
+		v := reflect.New(elem.Type()).Elem()
+		v.Set(elem)
+		return formatField(v)
+	case reflect.Slice:
+		if !sliceType(field.Type()) {
+			return "", ErrorUnsupportedType{field.Kind()}
+		}
+		parts := make([]string, field.Len())
+		for i := range parts {
+			s, err := formatField(field.Index(i))
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(s, sliceSeparator) {
+				return "", ErrorSliceSeparator{s}
+			}
+			parts[i] = s
+		}
+		return strings.Join(parts, sliceSeparator), nil
+	default:
+		return "", ErrorUnsupportedType{field.Kind()}
+	}
+}
+
+// formatMap returns the keys of map m in sorted order together with the
+// formatted values of the entries, so the output does not depend on the map
+// iteration order. Integer keys are sorted by value. The values are formatted
+// with the options of the map field.
+func formatMap(m reflect.Value, opts envOptions) (keys, values []string, err error) {
+	mapKeys := m.MapKeys()
+	sort.Slice(mapKeys, func(i, j int) bool {
+		a, b := mapKeys[i], mapKeys[j]
+		switch {
+		case a.CanInt():
+			return a.Int() < b.Int()
+		case a.CanUint():
+			return a.Uint() < b.Uint()
+		}
+		return a.String() < b.String()
+	})
+	values = make([]string, len(mapKeys))
+	for i, k := range mapKeys {
+		keys = append(keys, formatMapKey(k))
+		// Copy the entry so it is addressable.
+		elem := reflect.New(m.Type().Elem()).Elem()
+		elem.Set(m.MapIndex(k))
+		if values[i], err = formatValue(elem, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return keys, values, nil
+}
+
+// mapKeyType reports whether t can be used as the key type of a map field:
+// strings and integers.
+func mapKeyType(t reflect.Type) bool {
+	return t.Kind() == reflect.String || sizeType(t)
+}
+
+// mapKeyValue converts the variable name segment s after the prefix of a map
+// field to a key of type t.
+func mapKeyValue(t reflect.Type, s string) (reflect.Value, error) {
+	k := reflect.New(t).Elem()
+	switch {
+	case k.CanInt():
+		i, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return k, err
+		}
+		k.SetInt(i)
+	case k.CanUint():
+		u, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return k, err
+		}
+		k.SetUint(u)
+	default:
+		k.SetString(s)
+	}
+	return k, nil
+}
+
+// formatMapKey returns the variable name segment for the map key k, the
+// reverse of mapKeyValue.
+func formatMapKey(k reflect.Value) string {
+	switch {
+	case k.CanInt():
+		return strconv.FormatInt(k.Int(), 10)
+	case k.CanUint():
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+	return k.String()
+}
+
+// plainString reports whether fields of type t are strings that are
+// (un)marshaled without any conversion.
+func plainString(t reflect.Type) bool {
+	if t.Kind() != reflect.String {
+		return false
+	}
+	if _, ok := registeredDecoder(t); ok {
+		return false
+	}
+	if _, ok := registeredEncoder(t); ok {
+		return false
+	}
+	return !t.Implements(textMarshalerType) && !reflect.PtrTo(t).Implements(textMarshalerType) &&
+		!reflect.PtrTo(t).Implements(textUnmarshalerType) && !reflect.PtrTo(t).Implements(flagValueType)
+}
+
+// supportedType reports whether fields of type t can be (un)marshaled.
+func supportedType(t reflect.Type) bool {
+	if _, ok := registeredDecoder(t); ok {
+		return true
+	}
+	if _, ok := registeredEncoder(t); ok {
+		return true
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) ||
+		reflect.PtrTo(t).Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(flagValueType) ||
+		t == locationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return sliceType(t)
+	case reflect.Interface:
+		return anyType(t)
+	}
+	return false
+}
+
+// decodableType reports whether setField can store values in fields of type
+// t. Unlike supportedType it rejects types that can only be marshaled, such
+// as types that implement encoding.TextMarshaler but not its counterpart.
+func decodableType(t reflect.Type) bool {
+	if _, ok := registeredDecoder(t); ok {
+		return true
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(flagValueType) ||
+		t.Kind() == reflect.Ptr && t.Implements(textUnmarshalerType) ||
+		t == durationType || t == locationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return sliceType(t) && decodableType(t.Elem())
+	case reflect.Interface:
+		return anyType(t)
+	}
+	return false
+}
+
+// anyType reports whether t is an empty interface type like any, whose
+// fields hold the value as a string.
+func anyType(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// sliceType reports whether t is a slice whose elements are (un)marshaled as
+// comma-separated values. Byte slices and nested slices are not.
+func sliceType(t reflect.Type) bool {
+	elem := t.Elem()
+	return elem.Kind() != reflect.Uint8 && elem.Kind() != reflect.Slice && supportedType(elem)
+}
+
+// emptyValue reports whether the field holding the formatted value is empty
+// for the "omitempty" option: an empty value, or the zero value of a bool or
+// number.
+func emptyValue(field reflect.Value, value string) bool {
+	if value == "" {
+		return true
+	}
+	switch field.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return field.IsZero()
+	}
+	return false
+}